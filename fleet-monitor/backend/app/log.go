@@ -2,55 +2,69 @@ package app
 
 import (
 	"fleet-monitor/backend/utils"
-	"os"
+	"time"
 
 	wailsLogger "github.com/wailsapp/wails/v2/pkg/logger"
 	gormLogger "gorm.io/gorm/logger"
 )
 
 const (
-	LogPrefixDatabase = "DBS"
-	LogPrefixWails    = "WLS"
-	LogPrefixI18n     = "I18"
-	LogPrefixTray     = "TRY"
-	LogPrefixWeb      = "WEB"
-	LogPrefixServices = "SEV"
+	LogPrefixDatabase = utils.LogPrefixDatabase
+	LogPrefixWails    = utils.LogPrefixWails
+	LogPrefixI18n     = utils.LogPrefixI18n
+	LogPrefixTray     = utils.LogPrefixTray
+	LogPrefixWeb      = utils.LogPrefixWeb
 )
 
+// defaultRotation keeps about a month of logs in files of at most 10 MB.
+var defaultRotation = utils.RotationOptions{
+	MaxSizeMB:  10,
+	MaxAge:     24 * time.Hour,
+	MaxBackups: 30,
+	Retention:  30 * 24 * time.Hour,
+}
+
 type log struct {
+	output   *utils.LogOutput
 	database gormLogger.Interface
 	wails    wailsLogger.Logger
 	tray     *utils.Logger
 	web      *utils.Logger
-	services *utils.Logger
 }
 
-func NewConsoleLogger() *log {
+// NewLogger creates the loggers of every subsystem, all writing to one output.
+// Example
+// logger := NewLogger(utils.LogOptions{Format: utils.LogFormatJSON, Level: utils.LevelInfo, Levels: map[string]utils.Level{LogPrefixDatabase: utils.LevelWarn}})
+func NewLogger(opts utils.LogOptions) *log {
+	output, err := utils.NewLogOutput(opts)
+	if err != nil {
+		utils.Utils().Panic("failed to open log: " + err.Error())
+	}
 	return &log{
-		database: utils.NewGormConsoleLogger(LogPrefixDatabase),
-		wails:    utils.NewWailsConsoleLogger(LogPrefixWails),
-		tray:     utils.NewConsoleLogger(LogPrefixTray),
-		web:      utils.NewConsoleLogger(LogPrefixWeb),
-		services: utils.NewConsoleLogger(LogPrefixServices),
+		output:   output,
+		database: utils.NewGormLogger(output.Logger(LogPrefixDatabase)),
+		wails:    utils.NewWailsLogger(output.Logger(LogPrefixWails)),
+		tray:     output.Logger(LogPrefixTray),
+		web:      output.Logger(LogPrefixWeb),
 	}
 }
 
+func NewConsoleLogger() *log {
+	return NewLogger(utils.LogOptions{Format: utils.LogFormatConsole, Level: utils.LevelDebug})
+}
+
 func NewFileLogger(logPath string) *log {
-	logFile, err := os.OpenFile(
-		logPath,
-		os.O_CREATE|os.O_WRONLY|os.O_APPEND,
-		0666,
-	)
-	if err != nil {
-		utils.Utils().Panic("failed to open log file: " + err.Error())
-	}
-	return &log{
-		database: utils.NewGormFileLogger(LogPrefixDatabase, logFile),
-		wails:    utils.NewWailsFileLogger(LogPrefixWails, logFile),
-		tray:     utils.NewFileLogger(LogPrefixTray, logFile),
-		web:      utils.NewFileLogger(LogPrefixWeb, logFile),
-		services: utils.NewFileLogger(LogPrefixServices, logFile),
-	}
+	return NewLogger(utils.LogOptions{
+		Format:   utils.LogFormatJSON,
+		Level:    utils.LevelInfo,
+		Levels:   map[string]utils.Level{LogPrefixDatabase: utils.LevelError},
+		File:     logPath,
+		Rotation: defaultRotation,
+	})
+}
+
+func (l *log) Database() gormLogger.Interface {
+	return l.database
 }
 
 func (l *log) Wails() wailsLogger.Logger {
@@ -65,6 +79,7 @@ func (l *log) Web() *utils.Logger {
 	return l.web
 }
 
-func (l *log) Services() *utils.Logger {
-	return l.services
+// Close closes the log file, if any.
+func (l *log) Close() error {
+	return l.output.Close()
 }
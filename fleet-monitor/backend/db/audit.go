@@ -0,0 +1,74 @@
+package db
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AuditAction is what a change recorded in the audit log did to its entity.
+type AuditAction string
+
+const (
+	AuditActionCreate AuditAction = "create"
+	AuditActionUpdate AuditAction = "update"
+	// AuditActionDelete is a soft delete, which AuditActionRestore undoes.
+	AuditActionDelete  AuditAction = "delete"
+	AuditActionRestore AuditAction = "restore"
+	// AuditActionPurge permanently removes a soft-deleted record.
+	AuditActionPurge AuditAction = "purge"
+)
+
+// IsValid reports whether a is one of the known audit actions.
+func (a AuditAction) IsValid() bool {
+	switch a {
+	case AuditActionCreate, AuditActionUpdate, AuditActionDelete, AuditActionRestore, AuditActionPurge:
+		return true
+	}
+	return false
+}
+
+// AuditChange is the value of a field before and after a change. Before is nil for
+// created records and After for deleted ones.
+type AuditChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// AuditChanges maps the JSON names of the fields a change touched to their values.
+type AuditChanges map[string]AuditChange
+
+// ErrAuditLogAppendOnly is returned when an audit entry is about to be changed or
+// removed.
+var ErrAuditLogAppendOnly = errors.New("the audit log is append-only")
+
+// AuditEntry records who changed a drone, task or user, or quarantined a sample, how
+// and when. Actor is the authenticated user of the web request, "anonymous" without
+// one, or "system" for changes made outside of web requests such as by the retention
+// purge.
+type AuditEntry struct {
+	ID        uint         `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time    `json:"created_at" gorm:"index"`
+	Actor     string       `json:"actor" gorm:"index" validate:"required,max=200"`
+	Action    AuditAction  `json:"action" gorm:"index" validate:"enum"`
+	Entity    string       `json:"entity" gorm:"index:idx_audit_log_entity" validate:"required,max=50"`
+	EntityID  uint         `json:"entity_id" gorm:"index:idx_audit_log_entity"`
+	Changes   AuditChanges `json:"changes" gorm:"serializer:json"`
+	RequestID string       `json:"request_id" gorm:"index" validate:"max=128"`
+	SourceIP  string       `json:"source_ip" validate:"max=100"`
+}
+
+func (AuditEntry) TableName() string {
+	return "audit_log"
+}
+
+// BeforeUpdate keeps gorm from changing audit entries.
+func (AuditEntry) BeforeUpdate(*gorm.DB) error {
+	return ErrAuditLogAppendOnly
+}
+
+// BeforeDelete keeps gorm from removing audit entries.
+func (AuditEntry) BeforeDelete(*gorm.DB) error {
+	return ErrAuditLogAppendOnly
+}
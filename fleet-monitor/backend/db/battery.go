@@ -0,0 +1,42 @@
+package db
+
+import "time"
+
+// BatteryPack is a physical battery pack, installed in at most one drone at a time.
+// The estimated capacity and health are unset until enough discharges are recorded.
+type BatteryPack struct {
+	ID               uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+	SerialNumber     string    `json:"serial_number" gorm:"uniqueIndex" validate:"required,max=100"`
+	Chemistry        string    `json:"chemistry" validate:"max=50"`
+	CellCount        int       `json:"cell_count" validate:"gte=0,lte=24"`
+	RatedCapacityMah int       `json:"rated_capacity_mah" validate:"gte=1"`
+	// DroneID is the drone the pack is installed in, 0 if it is not installed.
+	DroneID uint `json:"drone_id" gorm:"index"`
+	Retired bool `json:"retired"`
+
+	Cycles               int      `json:"cycles"`
+	EstimatedCapacityMah *float64 `json:"estimated_capacity_mah"`
+	HealthPercent        *float64 `json:"health_percent"`
+	// LowHealth is set while HealthPercent is below the warning threshold.
+	LowHealth bool `json:"low_health"`
+}
+
+// BatteryDischarge is one flight's use of a battery pack. Voltages are in volts and
+// a remaining charge of -1 means the drone did not report it.
+type BatteryDischarge struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	PackID         uint      `json:"pack_id" gorm:"index"`
+	DroneID        uint      `json:"drone_id"`
+	StartedAt      time.Time `json:"started_at"`
+	EndedAt        time.Time `json:"ended_at"`
+	StartVoltage   float64   `json:"start_voltage" validate:"gte=0"`
+	EndVoltage     float64   `json:"end_voltage" validate:"gte=0"`
+	StartRemaining int       `json:"start_remaining" validate:"gte=-1,lte=100"`
+	EndRemaining   int       `json:"end_remaining" validate:"gte=-1,lte=100"`
+	ConsumedMah    float64   `json:"consumed_mah" validate:"gte=0"`
+	// EstimatedCapacityMah is the full capacity the discharge suggests, unset if
+	// too little of the pack was used to tell.
+	EstimatedCapacityMah *float64 `json:"estimated_capacity_mah"`
+}
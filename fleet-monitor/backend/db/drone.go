@@ -2,18 +2,94 @@ package db
 
 import "gorm.io/gorm"
 
-type FlyingStatus string
+// HealthStatus is the drone's condition, whatever it is doing.
+type HealthStatus string
 
 const (
-	FlyingStatusWaiting   FlyingStatus = "damaged"
-	FlyingStatusOngoing   FlyingStatus = "stable"
-	FlyingStatusCompleted FlyingStatus = "offline"
-	FlyingStatusAborted   FlyingStatus = "disconnected"
+	HealthStatusStable  HealthStatus = "stable"
+	HealthStatusDamaged HealthStatus = "damaged"
+	// HealthStatusOffline drones are powered off, HealthStatusDisconnected drones
+	// have lost their link.
+	HealthStatusOffline      HealthStatus = "offline"
+	HealthStatusDisconnected HealthStatus = "disconnected"
 )
 
+// IsValid reports whether s is one of the known health statuses.
+func (s HealthStatus) IsValid() bool {
+	switch s {
+	case HealthStatusStable, HealthStatusDamaged, HealthStatusOffline, HealthStatusDisconnected:
+		return true
+	}
+	return false
+}
+
+// FlightPhase is what the drone is doing, from sitting disarmed on the ground to landing.
+type FlightPhase string
+
+const (
+	FlightPhaseOnGround  FlightPhase = "on_ground"
+	FlightPhaseArmed     FlightPhase = "armed"
+	FlightPhaseTakingOff FlightPhase = "taking_off"
+	FlightPhaseAirborne  FlightPhase = "airborne"
+	FlightPhaseReturning FlightPhase = "returning"
+	FlightPhaseLanding   FlightPhase = "landing"
+)
+
+// IsValid reports whether p is one of the known flight phases.
+func (p FlightPhase) IsValid() bool {
+	switch p {
+	case FlightPhaseOnGround, FlightPhaseArmed, FlightPhaseTakingOff, FlightPhaseAirborne, FlightPhaseReturning, FlightPhaseLanding:
+		return true
+	}
+	return false
+}
+
+// SigningPolicy decides what happens to the MAVLink frames of a drone that are not
+// signed. Frames with a bad signature are always dropped once the drone has a
+// signing key.
+type SigningPolicy string
+
+const (
+	SigningPolicyAccept SigningPolicy = "accept"
+	// SigningPolicyFlag accepts unsigned frames but raises a security alert.
+	SigningPolicyFlag   SigningPolicy = "flag"
+	SigningPolicyReject SigningPolicy = "reject"
+)
+
+// IsValid reports whether p is one of the known signing policies.
+func (p SigningPolicy) IsValid() bool {
+	switch p {
+	case SigningPolicyAccept, SigningPolicyFlag, SigningPolicyReject:
+		return true
+	}
+	return false
+}
+
 type GPS struct {
-	Latitude  float64 `json:"latitude"`
-	Longitude float64 `json:"longitude"`
+	Latitude  float64 `json:"latitude" validate:"gte=-90,lte=90"`
+	Longitude float64 `json:"longitude" validate:"gte=-180,lte=180"`
+	// FixType is the MAVLink GPS_FIX_TYPE: 0 no GPS, 1 no fix, 2 2D, 3 3D, 4 DGPS,
+	// 5 RTK float, 6 RTK fixed, 7 static, 8 PPP. Satellites and HDOP are 0 when unknown.
+	FixType    int     `json:"fix_type" validate:"gte=0,lte=8"`
+	Satellites int     `json:"satellites" validate:"gte=0"`
+	HDOP       float64 `json:"hdop" validate:"gte=0"`
+}
+
+// Attitude is the drone's orientation in degrees. Yaw is relative to north, like the
+// heading, but in -180 to 180.
+type Attitude struct {
+	Roll  float64 `json:"roll"`
+	Pitch float64 `json:"pitch"`
+	Yaw   float64 `json:"yaw"`
+}
+
+// Radio is the telemetry link quality reported by the radios, in their own units
+// (0-254 for SiK radios). Remote values are measured by the radio on the drone.
+type Radio struct {
+	RSSI        int `json:"rssi"`
+	Noise       int `json:"noise"`
+	RemoteRSSI  int `json:"remote_rssi"`
+	RemoteNoise int `json:"remote_noise"`
 }
 
 type Velocity struct {
@@ -25,13 +101,43 @@ type Velocity struct {
 type Drone struct {
 	gorm.Model
 	Name         string       `json:"name"`
-	DroneID      int          `json:"drone_id"`
-	MavlinkID    string       `json:"mavlink_id"`
+	MavlinkID    string       `json:"mavlink_id" validate:"required"`
 	TaskID       int          `json:"task_id"`
-	OwnerID      int          `json:"owner_id"`
-	GPS          GPS          `json:"gps"`
-	Velocity     Velocity     `json:"velocity"`
+	OwnerID      int          `json:"owner_id" validate:"required"`
+	GPS          GPS          `json:"gps" gorm:"embedded;embeddedPrefix:gps_"`
+	Velocity     Velocity     `json:"velocity" gorm:"embedded;embeddedPrefix:velocity_"`
 	Altitude     float64      `json:"altitude"`
-	FlightStatus FlyingStatus `json:"flight_status"`
-	Battery      int          `json:"battery"`
+	HealthStatus HealthStatus `json:"health_status" validate:"omitempty,enum"`
+	FlightPhase  FlightPhase  `json:"flight_phase" validate:"omitempty,enum"`
+	Battery      int          `json:"battery" validate:"gte=0,lte=100"`
+	// Heading is in degrees from north, speeds are in m/s.
+	Heading     float64  `json:"heading" validate:"gte=0,lte=360"`
+	Attitude    Attitude `json:"attitude" gorm:"embedded;embeddedPrefix:attitude_"`
+	GroundSpeed float64  `json:"ground_speed"`
+	Airspeed    float64  `json:"airspeed"`
+	Radio       Radio    `json:"radio" gorm:"embedded;embeddedPrefix:radio_"`
+
+	// Registry data kept for regulators and the maintenance crew.
+	AirframeType       string  `json:"airframe_type" validate:"max=100"`
+	SerialNumber       string  `json:"serial_number" validate:"max=100"`
+	RegistrationNumber string  `json:"registration_number" validate:"max=100"`
+	RemoteID           string  `json:"remote_id" validate:"max=100"`
+	MaxTakeoffWeightKg float64 `json:"max_takeoff_weight_kg" validate:"gte=0"`
+	// MaxSpeedMps is the fastest the airframe can fly, which position updates are
+	// checked against. Zero uses a default suiting most multirotors.
+	MaxSpeedMps float64 `json:"max_speed_mps" validate:"gte=0"`
+	// FirmwareVersion and AutopilotType are filled in from the drone's MAVLink
+	// AUTOPILOT_VERSION and HEARTBEAT messages when it connects.
+	FirmwareVersion string `json:"firmware_version" validate:"max=100"`
+	AutopilotType   string `json:"autopilot_type" validate:"max=100"`
+
+	// Totals of the drone's flights. A grounded drone is overdue for one of its
+	// service intervals and can't be given new tasks.
+	FlightSeconds int64 `json:"flight_seconds"`
+	FlightCycles  int   `json:"flight_cycles"`
+	Grounded      bool  `json:"grounded"`
+
+	// SigningPolicy applies to the drone's unsigned MAVLink frames. Its signing key
+	// is kept in the secrets table.
+	SigningPolicy SigningPolicy `json:"signing_policy" gorm:"default:accept" validate:"omitempty,enum"`
 }
@@ -0,0 +1,176 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// uniqueIndex is a unique index over the rows that are not soft-deleted,
+// so a deleted drone or user does not block reusing its MavlinkID or username.
+type uniqueIndex struct {
+	Name   string
+	Table  string
+	Column string
+}
+
+var uniqueIndexes = []uniqueIndex{
+	{Name: "idx_drones_mavlink_id", Table: "drones", Column: "mavlink_id"},
+	{Name: "idx_users_user_name", Table: "users", Column: "user_name"},
+}
+
+// reference is a foreign key column that must point at an existing row.
+type reference struct {
+	Table    string
+	Column   string
+	RefTable string
+}
+
+var references = []reference{
+	{Table: "drones", Column: "owner_id", RefTable: "users"},
+	{Table: "tasks", Column: "user_id", RefTable: "users"},
+	{Table: "tasks", Column: "drone_id", RefTable: "drones"},
+}
+
+// Duplicate is a value shared by several live rows of a column that must be unique.
+type Duplicate struct {
+	Table  string
+	Column string
+	Value  string
+	IDs    []uint
+}
+
+// Orphan is a row whose foreign key points at a row that does not exist.
+type Orphan struct {
+	Table  string
+	ID     uint
+	Column string
+	RefID  int
+}
+
+// IntegrityError lists the rows that prevent the unique indexes and
+// foreign key constraints from being applied.
+type IntegrityError struct {
+	Duplicates []Duplicate
+	Orphans    []Orphan
+}
+
+func (e *IntegrityError) Error() string {
+	var lines []string
+	for _, d := range e.Duplicates {
+		lines = append(lines, fmt.Sprintf("%s.%s %q is used by rows %v", d.Table, d.Column, d.Value, d.IDs))
+	}
+	for _, o := range e.Orphans {
+		lines = append(lines, fmt.Sprintf("%s row %d references missing %s %d", o.Table, o.ID, o.Column, o.RefID))
+	}
+	return "database integrity check failed:\n  " + strings.Join(lines, "\n  ")
+}
+
+// CheckIntegrity reports duplicate values in unique columns and rows with dangling
+// foreign keys. It must pass before the constraints can be applied to an existing database.
+func CheckIntegrity(db *gorm.DB) error {
+	result := &IntegrityError{}
+
+	for _, idx := range uniqueIndexes {
+		if !db.Migrator().HasTable(idx.Table) {
+			continue
+		}
+
+		duplicates, err := findDuplicates(db, idx)
+		if err != nil {
+			return err
+		}
+		result.Duplicates = append(result.Duplicates, duplicates...)
+	}
+
+	for _, ref := range references {
+		if !db.Migrator().HasTable(ref.Table) || !db.Migrator().HasTable(ref.RefTable) {
+			continue
+		}
+
+		orphans, err := findOrphans(db, ref)
+		if err != nil {
+			return err
+		}
+		result.Orphans = append(result.Orphans, orphans...)
+	}
+
+	if len(result.Duplicates) > 0 || len(result.Orphans) > 0 {
+		return result
+	}
+
+	return nil
+}
+
+func findDuplicates(db *gorm.DB, idx uniqueIndex) ([]Duplicate, error) {
+	var values []string
+	err := db.Table(idx.Table).
+		Where("deleted_at IS NULL").
+		Group(idx.Column).
+		Having("COUNT(*) > 1").
+		Pluck(idx.Column, &values).Error
+	if err != nil {
+		return nil, err
+	}
+
+	duplicates := make([]Duplicate, 0, len(values))
+	for _, value := range values {
+		duplicate := Duplicate{Table: idx.Table, Column: idx.Column, Value: value}
+		err := db.Table(idx.Table).
+			Where("deleted_at IS NULL AND "+idx.Column+" = ?", value).
+			Order("id").
+			Pluck("id", &duplicate.IDs).Error
+		if err != nil {
+			return nil, err
+		}
+		duplicates = append(duplicates, duplicate)
+	}
+
+	return duplicates, nil
+}
+
+func findOrphans(db *gorm.DB, ref reference) ([]Orphan, error) {
+	var rows []struct {
+		ID    uint
+		RefID int
+	}
+	err := db.Table(ref.Table).
+		Select("id, " + ref.Column + " AS ref_id").
+		Where(ref.Column + " NOT IN (SELECT id FROM " + ref.RefTable + ")").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	orphans := make([]Orphan, 0, len(rows))
+	for _, row := range rows {
+		orphans = append(orphans, Orphan{Table: ref.Table, ID: row.ID, Column: ref.Column, RefID: row.RefID})
+	}
+
+	return orphans, nil
+}
+
+// createUniqueIndexes creates the partial unique indexes if they do not exist yet.
+func createUniqueIndexes(db *gorm.DB) error {
+	for _, idx := range uniqueIndexes {
+		sql := fmt.Sprintf(
+			"CREATE UNIQUE INDEX IF NOT EXISTS %s ON %s (%s) WHERE deleted_at IS NULL",
+			idx.Name, idx.Table, idx.Column,
+		)
+		if err := db.Exec(sql).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dropUniqueIndexes drops the partial unique indexes if they exist.
+func dropUniqueIndexes(db *gorm.DB) error {
+	for _, idx := range uniqueIndexes {
+		if err := db.Exec("DROP INDEX IF EXISTS " + idx.Name).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
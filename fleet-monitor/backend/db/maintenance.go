@@ -0,0 +1,66 @@
+package db
+
+import "time"
+
+type FlightSource string
+
+const (
+	// FlightSourceMavlink flights were detected from the drone arming and disarming.
+	FlightSourceMavlink FlightSource = "mavlink"
+	// FlightSourceManual flights were entered by hand, e.g. from the pilot's log book.
+	FlightSourceManual FlightSource = "manual"
+)
+
+// IsValid reports whether s is one of the known flight sources.
+func (s FlightSource) IsValid() bool {
+	switch s {
+	case FlightSourceMavlink, FlightSourceManual:
+		return true
+	}
+	return false
+}
+
+// Flight is one flight of a drone, from takeoff or arming to landing or disarming.
+// Every flight counts as one cycle.
+type Flight struct {
+	ID        uint         `json:"id" gorm:"primaryKey"`
+	DroneID   uint         `json:"drone_id" gorm:"index"`
+	StartedAt time.Time    `json:"started_at" validate:"required"`
+	EndedAt   time.Time    `json:"ended_at" validate:"required"`
+	Source    FlightSource `json:"source" validate:"enum"`
+}
+
+// Duration is how long the flight lasted.
+func (f Flight) Duration() time.Duration {
+	return f.EndedAt.Sub(f.StartedAt)
+}
+
+// ServiceInterval is a recurring service a drone needs after a number of flight hours
+// or cycles, whichever comes first. A zero limit is not checked.
+type ServiceInterval struct {
+	ID          uint    `json:"id" gorm:"primaryKey"`
+	DroneID     uint    `json:"drone_id" gorm:"index"`
+	Name        string  `json:"name" validate:"required,max=100"`
+	FlightHours float64 `json:"flight_hours" validate:"gte=0"`
+	Cycles      int     `json:"cycles" validate:"gte=0"`
+	// The drone's counters when the service was last done, or when the interval was added.
+	ServicedAt            time.Time `json:"serviced_at"`
+	ServicedFlightSeconds int64     `json:"serviced_flight_seconds"`
+	ServicedCycles        int       `json:"serviced_cycles"`
+}
+
+// MaintenanceLog is an entry in a drone's maintenance history. ServiceIntervalID
+// is set when the work completes a service interval, restarting it.
+type MaintenanceLog struct {
+	ID                uint      `json:"id" gorm:"primaryKey"`
+	DroneID           uint      `json:"drone_id" gorm:"index"`
+	PerformedAt       time.Time `json:"performed_at"`
+	Action            string    `json:"action" validate:"required,max=200"`
+	Parts             string    `json:"parts" validate:"max=500"`
+	Technician        string    `json:"technician" validate:"max=100"`
+	Notes             string    `json:"notes" validate:"max=1000"`
+	ServiceIntervalID uint      `json:"service_interval_id"`
+	// The drone's counters when the work was done.
+	FlightSeconds int64 `json:"flight_seconds"`
+	Cycles        int   `json:"cycles"`
+}
@@ -0,0 +1,242 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Migration is one versioned schema change.
+// Up and Down run inside a transaction; returning an error rolls the step back.
+// Migrations must only reference their own snapshot structs, never the live models,
+// so that later model changes cannot alter what an old migration does.
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+}
+
+// SchemaMigration records an applied migration in the schema_migrations table.
+type SchemaMigration struct {
+	Version   int    `gorm:"primaryKey;autoIncrement:false"`
+	Name      string `gorm:"not null"`
+	AppliedAt time.Time
+}
+
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// MigrationStatus reports whether a known migration has been applied.
+type MigrationStatus struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+// ErrNoMigrationApplied is returned by MigrateDown when there is nothing to roll back.
+var ErrNoMigrationApplied = errors.New("no migration has been applied")
+
+// Migrations returns the registered migrations ordered by version.
+func Migrations() []Migration {
+	sorted := make([]Migration, len(migrations))
+	copy(sorted, migrations)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+	return sorted
+}
+
+// MigrateUp applies every pending migration in version order and returns the ones it applied.
+// It stops at the first failing migration, whose changes are rolled back.
+// Example
+// applied, err := db.MigrateUp(conn)
+func MigrateUp(db *gorm.DB) ([]Migration, error) {
+	var applied []Migration
+
+	err := withMigrationConn(db, func(conn *gorm.DB) error {
+		done, err := appliedMigrations(conn)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range Migrations() {
+			if _, ok := done[m.Version]; ok {
+				continue
+			}
+
+			err := runMigration(conn, m, m.Up, func(tx *gorm.DB) error {
+				return tx.Create(&SchemaMigration{Version: m.Version, Name: m.Name, AppliedAt: time.Now()}).Error
+			})
+			if err != nil {
+				return fmt.Errorf("migration %d (%s) failed: %w", m.Version, m.Name, err)
+			}
+			applied = append(applied, m)
+		}
+
+		return nil
+	})
+
+	return applied, err
+}
+
+// MigrateDown rolls back the last steps applied migrations, newest first,
+// and returns the ones it rolled back.
+// Example
+// reverted, err := db.MigrateDown(conn, 1)
+func MigrateDown(db *gorm.DB, steps int) ([]Migration, error) {
+	var reverted []Migration
+
+	err := withMigrationConn(db, func(conn *gorm.DB) error {
+		done, err := appliedMigrations(conn)
+		if err != nil {
+			return err
+		}
+		if len(done) == 0 {
+			return ErrNoMigrationApplied
+		}
+
+		known := Migrations()
+		for i := len(known) - 1; i >= 0 && len(reverted) < steps; i-- {
+			m := known[i]
+			if _, ok := done[m.Version]; !ok {
+				continue
+			}
+
+			err := runMigration(conn, m, m.Down, func(tx *gorm.DB) error {
+				return tx.Delete(&SchemaMigration{}, m.Version).Error
+			})
+			if err != nil {
+				return fmt.Errorf("rollback of migration %d (%s) failed: %w", m.Version, m.Name, err)
+			}
+			reverted = append(reverted, m)
+		}
+
+		return nil
+	})
+
+	return reverted, err
+}
+
+// MigrationStatuses lists every known migration and whether it has been applied.
+func MigrationStatuses(db *gorm.DB) ([]MigrationStatus, error) {
+	var statuses []MigrationStatus
+
+	err := withMigrationConn(db, func(conn *gorm.DB) error {
+		done, err := appliedMigrations(conn)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range Migrations() {
+			status := MigrationStatus{Version: m.Version, Name: m.Name}
+			if record, ok := done[m.Version]; ok {
+				appliedAt := record.AppliedAt
+				status.Applied = true
+				status.AppliedAt = &appliedAt
+			}
+			statuses = append(statuses, status)
+		}
+
+		return nil
+	})
+
+	return statuses, err
+}
+
+// PendingMigrations returns the migrations that have not been applied yet. Unlike
+// UnappliedMigrations, it creates schema_migrations on a database never migrated.
+func PendingMigrations(db *gorm.DB) ([]Migration, error) {
+	if err := db.AutoMigrate(&SchemaMigration{}); err != nil {
+		return nil, err
+	}
+
+	return UnappliedMigrations(context.Background(), db)
+}
+
+// UnappliedMigrations returns the migrations that have not been applied yet, for
+// health checks. It only reads schema_migrations, without creating it or pinning a
+// connection, and stops when ctx is done. A database that was never migrated
+// reports an error.
+func UnappliedMigrations(ctx context.Context, db *gorm.DB) ([]Migration, error) {
+	var versions []int
+	if err := db.WithContext(ctx).Model(&SchemaMigration{}).Pluck("version", &versions).Error; err != nil {
+		return nil, err
+	}
+
+	done := make(map[int]bool, len(versions))
+	for _, version := range versions {
+		done[version] = true
+	}
+
+	var pending []Migration
+	for _, m := range Migrations() {
+		if !done[m.Version] {
+			pending = append(pending, m)
+		}
+	}
+
+	return pending, nil
+}
+
+// withMigrationConn runs fn on a single pooled connection. On SQLite foreign key
+// enforcement is switched off, as table rebuilds require, and each migration
+// verifies the foreign keys itself before it commits.
+func withMigrationConn(db *gorm.DB, fn func(conn *gorm.DB) error) error {
+	return db.Connection(func(conn *gorm.DB) error {
+		// The pinned connection handle is single-use; a session can run several statements.
+		conn = conn.Session(&gorm.Session{})
+
+		if conn.Dialector.Name() == DialectSQLite {
+			if err := conn.Exec("PRAGMA foreign_keys = OFF").Error; err != nil {
+				return err
+			}
+			defer conn.Exec("PRAGMA foreign_keys = ON")
+		}
+
+		if err := conn.AutoMigrate(&SchemaMigration{}); err != nil {
+			return err
+		}
+
+		return fn(conn)
+	})
+}
+
+func appliedMigrations(conn *gorm.DB) (map[int]SchemaMigration, error) {
+	var records []SchemaMigration
+	if err := conn.Order("version").Find(&records).Error; err != nil {
+		return nil, err
+	}
+
+	done := make(map[int]SchemaMigration, len(records))
+	for _, record := range records {
+		done[record.Version] = record
+	}
+
+	return done, nil
+}
+
+// runMigration runs step and record in one transaction, checking the foreign keys before committing.
+func runMigration(conn *gorm.DB, m Migration, step, record func(tx *gorm.DB) error) error {
+	if step == nil {
+		return fmt.Errorf("migration %d has no step in this direction", m.Version)
+	}
+
+	return conn.Transaction(func(tx *gorm.DB) error {
+		if err := step(tx); err != nil {
+			return err
+		}
+
+		if tx.Dialector.Name() == DialectSQLite {
+			if err := checkSQLiteForeignKeys(tx); err != nil {
+				return err
+			}
+		}
+
+		return record(tx)
+	})
+}
@@ -0,0 +1,276 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// openTestSQLite opens an empty SQLite database in a temporary directory.
+func openTestSQLite(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := Connect(filepath.Join(t.TempDir(), "fleet.db"))
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+// assertApplied fails unless exactly the first n known migrations are applied.
+func assertApplied(t *testing.T, db *gorm.DB, n int) {
+	t.Helper()
+	statuses, err := MigrationStatuses(db)
+	if err != nil {
+		t.Fatalf("MigrationStatuses: %v", err)
+	}
+	if len(statuses) != len(Migrations()) {
+		t.Fatalf("got %d statuses, want %d", len(statuses), len(Migrations()))
+	}
+	for i, status := range statuses {
+		if want := i < n; status.Applied != want {
+			t.Errorf("migration %d (%s) applied = %v, want %v", status.Version, status.Name, status.Applied, want)
+		}
+		if status.Applied && status.AppliedAt == nil {
+			t.Errorf("migration %d has no applied_at", status.Version)
+		}
+	}
+}
+
+// tablesOf returns the tables of db, leaving out the ones SQLite keeps for itself.
+func tablesOf(t *testing.T, db *gorm.DB) []string {
+	t.Helper()
+	all, err := db.Migrator().GetTables()
+	if err != nil {
+		t.Fatalf("GetTables: %v", err)
+	}
+	var tables []string
+	for _, table := range all {
+		if table != "sqlite_sequence" {
+			tables = append(tables, table)
+		}
+	}
+	return tables
+}
+
+func TestMigrationsAreOrdered(t *testing.T) {
+	for i, m := range Migrations() {
+		if m.Version != i+1 {
+			t.Errorf("migration %d (%s) has version %d", i+1, m.Name, m.Version)
+		}
+		if m.Up == nil || m.Down == nil {
+			t.Errorf("migration %d (%s) is missing a step", m.Version, m.Name)
+		}
+	}
+}
+
+func TestMigrateEmptyDatabase(t *testing.T) {
+	db := openTestSQLite(t)
+	total := len(Migrations())
+	if _, err := UnappliedMigrations(context.Background(), db); err == nil {
+		t.Fatal("UnappliedMigrations passed on a database that was never migrated")
+	}
+	pending, err := PendingMigrations(db)
+	if err != nil || len(pending) != total {
+		t.Fatalf("PendingMigrations = %d, %v, want %d", len(pending), err, total)
+	}
+	assertApplied(t, db, 0)
+
+	applied, err := MigrateUp(db)
+	if err != nil {
+		t.Fatalf("MigrateUp: %v", err)
+	}
+	if len(applied) != total {
+		t.Fatalf("applied %d migrations, want %d", len(applied), total)
+	}
+	assertApplied(t, db, total)
+
+	applied, err = MigrateUp(db)
+	if err != nil || len(applied) != 0 {
+		t.Fatalf("second MigrateUp applied %d migrations, err %v", len(applied), err)
+	}
+	pending, err = PendingMigrations(db)
+	if err != nil || len(pending) != 0 {
+		t.Fatalf("PendingMigrations = %d, %v", len(pending), err)
+	}
+
+	// Roll back one migration at a time, so every Down step runs on the schema
+	// its Up step left behind.
+	for n := total; n > 0; n-- {
+		reverted, err := MigrateDown(db, 1)
+		if err != nil {
+			t.Fatalf("MigrateDown from %d: %v", n, err)
+		}
+		if len(reverted) != 1 || reverted[0].Version != n {
+			t.Fatalf("MigrateDown from %d reverted %v", n, reverted)
+		}
+		assertApplied(t, db, n-1)
+		unapplied, err := UnappliedMigrations(context.Background(), db)
+		if err != nil || len(unapplied) != total-n+1 || unapplied[0].Version != n {
+			t.Fatalf("UnappliedMigrations from %d = %v, %v", n, unapplied, err)
+		}
+	}
+
+	if _, err := MigrateDown(db, 1); !errors.Is(err, ErrNoMigrationApplied) {
+		t.Fatalf("MigrateDown on an empty database: %v, want ErrNoMigrationApplied", err)
+	}
+	if tables := tablesOf(t, db); len(tables) != 1 || tables[0] != "schema_migrations" {
+		t.Errorf("tables %v left after rolling back every migration", tables)
+	}
+
+	if _, err := MigrateUp(db); err != nil {
+		t.Fatalf("MigrateUp after rolling back: %v", err)
+	}
+	assertApplied(t, db, total)
+}
+
+// seedLegacy creates the schema AutoMigrate left before versioned migrations, without
+// schema_migrations, and fills it with rows that break the constraints of migration 2:
+// a MavlinkID used by two drones and a task of a user that no longer exists.
+func seedLegacy(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	if err := db.AutoMigrate(&user0001{}, &drone0001{}, &task0001{}); err != nil {
+		t.Fatalf("AutoMigrate: %v", err)
+	}
+
+	rows := []interface{}{
+		&user0001{Model: gorm.Model{ID: 1}, UserName: "alice"},
+		&user0001{Model: gorm.Model{ID: 2}, UserName: "bob"},
+		&drone0001{Model: gorm.Model{ID: 1}, Name: "scout", MavlinkID: "1", OwnerID: 1, Altitude: 120, FlightStatus: "stable"},
+		&drone0001{Model: gorm.Model{ID: 2}, Name: "hauler", MavlinkID: "2", OwnerID: 2, FlightStatus: "stable"},
+		&drone0001{Model: gorm.Model{ID: 3}, Name: "scout copy", MavlinkID: "1", OwnerID: 1},
+		&task0001{Model: gorm.Model{ID: 1}, UserID: 1, DroneID: 1, Description: "survey", Status: "ongoing"},
+		&task0001{Model: gorm.Model{ID: 2}, UserID: 9, DroneID: 2, Description: "orphan", Status: "waiting"},
+	}
+	for _, row := range rows {
+		if err := db.Create(row).Error; err != nil {
+			t.Fatalf("seeding %T: %v", row, err)
+		}
+	}
+}
+
+func TestMigrateSeededLegacyDatabase(t *testing.T) {
+	db := openTestSQLite(t)
+	seedLegacy(t, db)
+	total := len(Migrations())
+
+	// Migration 1 adopts the existing tables; migration 2 refuses the bad rows.
+	_, err := MigrateUp(db)
+	var integrity *IntegrityError
+	if !errors.As(err, &integrity) {
+		t.Fatalf("MigrateUp: %v, want an IntegrityError", err)
+	}
+	if len(integrity.Duplicates) != 1 || integrity.Duplicates[0].Value != "1" || len(integrity.Duplicates[0].IDs) != 2 {
+		t.Errorf("duplicates = %+v, want MavlinkID 1 on drones 1 and 3", integrity.Duplicates)
+	}
+	if len(integrity.Orphans) != 1 || integrity.Orphans[0].Table != "tasks" || integrity.Orphans[0].ID != 2 {
+		t.Errorf("orphans = %+v, want task 2", integrity.Orphans)
+	}
+	assertApplied(t, db, 1)
+
+	// A soft-deleted duplicate does not block the partial unique index.
+	if err := db.Delete(&drone0001{}, 3).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Model(&task0001{}).Where("id = ?", 2).Update("user_id", 2).Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := MigrateUp(db); err != nil {
+		t.Fatalf("MigrateUp after fixing the rows: %v", err)
+	}
+	assertApplied(t, db, total)
+
+	var drone Drone
+	if err := db.First(&drone, 1).Error; err != nil {
+		t.Fatalf("reading the seeded drone: %v", err)
+	}
+	if drone.HealthStatus != HealthStatusStable || drone.FlightPhase != FlightPhaseAirborne {
+		t.Errorf("drone 1 status = %q/%q, want stable/airborne", drone.HealthStatus, drone.FlightPhase)
+	}
+	if drone.SigningPolicy != SigningPolicyAccept {
+		t.Errorf("drone 1 signing policy = %q, want the accept default", drone.SigningPolicy)
+	}
+	var count int64
+	if err := db.Model(&Task{}).Count(&count).Error; err != nil || count != 2 {
+		t.Errorf("tasks after migrating = %d, %v, want 2", count, err)
+	}
+
+	// The unique indexes and foreign keys hold on the migrated schema.
+	if err := db.Create(&Drone{MavlinkID: "1", OwnerID: 1}).Error; !errors.Is(err, gorm.ErrDuplicatedKey) {
+		t.Errorf("creating a drone with a used MavlinkID: %v, want ErrDuplicatedKey", err)
+	}
+	if err := db.Create(&Drone{MavlinkID: "7", OwnerID: 9}).Error; !errors.Is(err, gorm.ErrForeignKeyViolated) {
+		t.Errorf("creating a drone of a missing user: %v, want ErrForeignKeyViolated", err)
+	}
+
+	for n := total; n > 0; n-- {
+		if _, err := MigrateDown(db, 1); err != nil {
+			t.Fatalf("MigrateDown from %d: %v", n, err)
+		}
+		// Rebuilding a table must not lose its indexes or rows.
+		if n > 2 {
+			for _, idx := range uniqueIndexes {
+				if !db.Migrator().HasIndex(idx.Table, idx.Name) {
+					t.Fatalf("index %s lost rolling back migration %d", idx.Name, n)
+				}
+			}
+		}
+		if n > 1 {
+			if err := db.Table("drones").Count(&count).Error; err != nil || count != 3 {
+				t.Fatalf("drones after rolling back migration %d = %d, %v", n, count, err)
+			}
+		}
+	}
+	assertApplied(t, db, 0)
+}
+
+func TestMigrateRollsBackFailedStep(t *testing.T) {
+	db := openTestSQLite(t)
+	if _, err := MigrateUp(db); err != nil {
+		t.Fatalf("MigrateUp: %v", err)
+	}
+	total := len(Migrations())
+	tables := tablesOf(t, db)
+
+	// A row written with enforcement off, as by an older build, fails the foreign
+	// key check that ends every migration step.
+	err := db.Connection(func(conn *gorm.DB) error {
+		if err := conn.Exec("PRAGMA foreign_keys = OFF").Error; err != nil {
+			return err
+		}
+		defer conn.Exec("PRAGMA foreign_keys = ON")
+		return conn.Exec("INSERT INTO tasks (user_id, drone_id, status) VALUES (9, 9, 'waiting')").Error
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := checkSQLiteForeignKeys(db); err == nil {
+		t.Fatal("checkSQLiteForeignKeys passed with a dangling task")
+	}
+
+	if _, err := MigrateDown(db, 1); err == nil {
+		t.Fatal("MigrateDown passed with a dangling task")
+	}
+	assertApplied(t, db, total)
+	if after := tablesOf(t, db); len(after) != len(tables) {
+		t.Fatalf("tables %v after the failed rollback, want %v", after, tables)
+	}
+
+	if err := db.Exec("DELETE FROM tasks WHERE user_id = 9").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := checkSQLiteForeignKeys(db); err != nil {
+		t.Fatalf("checkSQLiteForeignKeys: %v", err)
+	}
+	if _, err := MigrateDown(db, 1); err != nil {
+		t.Fatalf("MigrateDown: %v", err)
+	}
+	assertApplied(t, db, total-1)
+}
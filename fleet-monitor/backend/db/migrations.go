@@ -0,0 +1,653 @@
+package db
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// migrations is the ordered schema history. Append new migrations with the next
+// version number; never edit or renumber one that has been released.
+var migrations = []Migration{
+	{
+		Version: 1,
+		Name:    "create_initial_schema",
+		Up: func(tx *gorm.DB) error {
+			// AutoMigrate only creates what is missing, so databases created
+			// before versioned migrations are adopted as they are.
+			return tx.AutoMigrate(&user0001{}, &drone0001{}, &task0001{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&task0001{}, &drone0001{}, &user0001{})
+		},
+	},
+	{
+		Version: 2,
+		Name:    "add_unique_indexes_and_foreign_keys",
+		Up: func(tx *gorm.DB) error {
+			if err := CheckIntegrity(tx); err != nil {
+				return err
+			}
+
+			for _, c := range constraints0002 {
+				if tx.Migrator().HasConstraint(c.model, c.name) {
+					continue
+				}
+				if err := tx.Migrator().CreateConstraint(c.model, c.name); err != nil {
+					return err
+				}
+			}
+
+			if err := restoreDeletedAtIndexes(tx, "drones", "tasks"); err != nil {
+				return err
+			}
+
+			return createUniqueIndexes(tx)
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := dropUniqueIndexes(tx); err != nil {
+				return err
+			}
+
+			for _, c := range constraints0002 {
+				if !tx.Migrator().HasConstraint(c.model, c.name) {
+					continue
+				}
+				if err := tx.Migrator().DropConstraint(c.model, c.name); err != nil {
+					return err
+				}
+			}
+
+			return restoreDeletedAtIndexes(tx, "drones", "tasks")
+		},
+	},
+	{
+		Version: 3,
+		Name:    "create_drone_telemetry",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.AutoMigrate(&telemetry0003{}); err != nil {
+				return err
+			}
+
+			timescale, err := hasTimescaleDB(tx)
+			if err != nil || !timescale {
+				return err
+			}
+			return tx.Exec("SELECT create_hypertable('drone_telemetry', 'recorded_at', if_not_exists => TRUE, migrate_data => TRUE)").Error
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&telemetry0003{})
+		},
+	},
+	{
+		Version: 4,
+		Name:    "add_user_profile",
+		Up: func(tx *gorm.DB) error {
+			for _, field := range []string{"DisplayName", "Email", "ContactPhone"} {
+				if err := tx.Migrator().AddColumn(&user0004{}, field); err != nil {
+					return err
+				}
+			}
+			if err := tx.Migrator().DropColumn(&user0004{}, "TaskID"); err != nil {
+				return err
+			}
+
+			// SQLite drops a column by rebuilding the table, which loses its indexes.
+			if err := restoreDeletedAtIndexes(tx, "users"); err != nil {
+				return err
+			}
+			return createUniqueIndexes(tx)
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().AddColumn(&user0004{}, "TaskID"); err != nil {
+				return err
+			}
+			for _, field := range []string{"DisplayName", "Email", "ContactPhone"} {
+				if err := tx.Migrator().DropColumn(&user0004{}, field); err != nil {
+					return err
+				}
+			}
+
+			if err := restoreDeletedAtIndexes(tx, "users"); err != nil {
+				return err
+			}
+			return createUniqueIndexes(tx)
+		},
+	},
+	{
+		Version: 5,
+		Name:    "add_drone_registry",
+		Up: func(tx *gorm.DB) error {
+			for _, field := range fields0005 {
+				if err := tx.Migrator().AddColumn(&drone0005{}, field); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			for _, field := range fields0005 {
+				if err := tx.Migrator().DropColumn(&drone0005{}, field); err != nil {
+					return err
+				}
+			}
+
+			// SQLite drops a column by rebuilding the table, which loses its indexes.
+			if err := restoreDeletedAtIndexes(tx, "drones"); err != nil {
+				return err
+			}
+			return createUniqueIndexes(tx)
+		},
+	},
+	{
+		Version: 6,
+		Name:    "add_drone_maintenance",
+		Up: func(tx *gorm.DB) error {
+			for _, field := range fields0006 {
+				if err := tx.Migrator().AddColumn(&drone0006{}, field); err != nil {
+					return err
+				}
+			}
+			return tx.AutoMigrate(&flight0006{}, &serviceInterval0006{}, &maintenanceLog0006{})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropTable(&maintenanceLog0006{}, &serviceInterval0006{}, &flight0006{}); err != nil {
+				return err
+			}
+			for _, field := range fields0006 {
+				if err := tx.Migrator().DropColumn(&drone0006{}, field); err != nil {
+					return err
+				}
+			}
+
+			if err := restoreDeletedAtIndexes(tx, "drones"); err != nil {
+				return err
+			}
+			return createUniqueIndexes(tx)
+		},
+	},
+	{
+		Version: 7,
+		Name:    "create_battery_packs",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&batteryPack0007{}, &batteryDischarge0007{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&batteryDischarge0007{}, &batteryPack0007{})
+		},
+	},
+	{
+		Version: 8,
+		Name:    "add_drone_attitude_and_link",
+		Up: func(tx *gorm.DB) error {
+			for _, model := range []interface{}{&drone0008{}, &telemetry0008{}} {
+				for _, field := range fields0008 {
+					if err := tx.Migrator().AddColumn(model, field); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			for _, model := range []interface{}{&drone0008{}, &telemetry0008{}} {
+				for _, field := range fields0008 {
+					if err := tx.Migrator().DropColumn(model, field); err != nil {
+						return err
+					}
+				}
+			}
+
+			if err := restoreDeletedAtIndexes(tx, "drones"); err != nil {
+				return err
+			}
+			return createUniqueIndexes(tx)
+		},
+	},
+	{
+		Version: 9,
+		Name:    "split_drone_flight_status",
+		Up: func(tx *gorm.DB) error {
+			// The old flight status values are health statuses. A drone reported stable
+			// above the ground is taken to be flying; a drone that never reported a
+			// status keeps an unknown phase.
+			for _, model := range []interface{}{&drone0009{}, &telemetry0009{}} {
+				if err := tx.Migrator().RenameColumn(model, "FlightStatus", "HealthStatus"); err != nil {
+					return err
+				}
+				if err := tx.Migrator().AddColumn(model, "FlightPhase"); err != nil {
+					return err
+				}
+				err := tx.Session(&gorm.Session{AllowGlobalUpdate: true}).Model(model).Update("flight_phase", gorm.Expr(
+					"CASE WHEN health_status IS NULL OR health_status = '' THEN '' WHEN health_status = ? AND altitude > 0 THEN ? ELSE ? END",
+					"stable", "airborne", "on_ground")).Error
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			for _, model := range []interface{}{&drone0009{}, &telemetry0009{}} {
+				if err := tx.Migrator().DropColumn(model, "FlightPhase"); err != nil {
+					return err
+				}
+				if err := tx.Migrator().RenameColumn(model, "HealthStatus", "FlightStatus"); err != nil {
+					return err
+				}
+			}
+
+			if err := restoreDeletedAtIndexes(tx, "drones"); err != nil {
+				return err
+			}
+			return createUniqueIndexes(tx)
+		},
+	},
+	{
+		Version: 10,
+		Name:    "create_secrets",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&secret0010{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&secret0010{})
+		},
+	},
+	{
+		Version: 11,
+		Name:    "add_mavlink_signing",
+		Up: func(tx *gorm.DB) error {
+			// The default fills in the existing drones.
+			if err := tx.Migrator().AddColumn(&drone0011{}, "SigningPolicy"); err != nil {
+				return err
+			}
+			return tx.AutoMigrate(&securityAlert0011{})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropTable(&securityAlert0011{}); err != nil {
+				return err
+			}
+			if err := tx.Migrator().DropColumn(&drone0011{}, "SigningPolicy"); err != nil {
+				return err
+			}
+
+			if err := restoreDeletedAtIndexes(tx, "drones"); err != nil {
+				return err
+			}
+			return createUniqueIndexes(tx)
+		},
+	},
+	{
+		Version: 12,
+		Name:    "add_telemetry_quarantine",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.Migrator().AddColumn(&drone0012{}, "MaxSpeedMps"); err != nil {
+				return err
+			}
+			return tx.AutoMigrate(&quarantinedSample0012{})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropTable(&quarantinedSample0012{}); err != nil {
+				return err
+			}
+			if err := tx.Migrator().DropColumn(&drone0012{}, "MaxSpeedMps"); err != nil {
+				return err
+			}
+
+			if err := restoreDeletedAtIndexes(tx, "drones"); err != nil {
+				return err
+			}
+			return createUniqueIndexes(tx)
+		},
+	},
+	{
+		Version: 13,
+		Name:    "create_audit_log",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&auditEntry0013{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&auditEntry0013{})
+		},
+	},
+}
+
+// restoreDeletedAtIndexes recreates the gorm.Model soft-delete indexes, which SQLite
+// loses when a table is rebuilt to add or drop a constraint.
+func restoreDeletedAtIndexes(tx *gorm.DB, tables ...string) error {
+	for _, table := range tables {
+		sql := "CREATE INDEX IF NOT EXISTS idx_" + table + "_deleted_at ON " + table + " (deleted_at)"
+		if err := tx.Exec(sql).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Snapshot models for migration 1.
+
+type user0001 struct {
+	gorm.Model
+	UserName string
+	TaskID   int
+}
+
+func (user0001) TableName() string { return "users" }
+
+type drone0001 struct {
+	gorm.Model
+	Name         string
+	MavlinkID    string
+	TaskID       int
+	OwnerID      int
+	GPSLatitude  float64
+	GPSLongitude float64
+	VelocityX    float64
+	VelocityY    float64
+	VelocityZ    float64
+	Altitude     float64
+	FlightStatus string
+	Battery      int
+}
+
+func (drone0001) TableName() string { return "drones" }
+
+type task0001 struct {
+	gorm.Model
+	UserID      int
+	DroneID     int
+	StartLon    float64
+	StartLat    float64
+	EndLon      float64
+	EndLat      float64
+	Description string
+	Status      string
+}
+
+func (task0001) TableName() string { return "tasks" }
+
+// Snapshot models for migration 2, reduced to the relations that carry constraints.
+
+type user0002 struct {
+	ID     uint
+	Drones []drone0002 `gorm:"foreignKey:OwnerID;constraint:OnDelete:RESTRICT"`
+}
+
+func (user0002) TableName() string { return "users" }
+
+type drone0002 struct {
+	ID      uint
+	OwnerID int
+}
+
+func (drone0002) TableName() string { return "drones" }
+
+type task0002 struct {
+	ID      uint
+	UserID  int
+	User    user0002 `gorm:"foreignKey:UserID;constraint:OnDelete:RESTRICT"`
+	DroneID int
+	Drone   drone0002 `gorm:"foreignKey:DroneID;constraint:OnDelete:RESTRICT"`
+}
+
+func (task0002) TableName() string { return "tasks" }
+
+var constraints0002 = []struct {
+	model interface{}
+	name  string
+}{
+	{model: &user0002{}, name: "Drones"},
+	{model: &task0002{}, name: "User"},
+	{model: &task0002{}, name: "Drone"},
+}
+
+// Snapshot model for migration 3.
+
+type telemetry0003 struct {
+	DroneID      uint      `gorm:"primaryKey;autoIncrement:false"`
+	RecordedAt   time.Time `gorm:"primaryKey"`
+	GPSLatitude  float64
+	GPSLongitude float64
+	VelocityX    float64
+	VelocityY    float64
+	VelocityZ    float64
+	Altitude     float64
+	FlightStatus string
+	Battery      int
+}
+
+func (telemetry0003) TableName() string { return "drone_telemetry" }
+
+// Snapshot model for migration 4. TaskID is the column it removes.
+
+type user0004 struct {
+	DisplayName  string
+	Email        string
+	ContactPhone string
+	TaskID       int
+}
+
+func (user0004) TableName() string { return "users" }
+
+// Snapshot model for migration 5.
+
+type drone0005 struct {
+	AirframeType       string
+	SerialNumber       string
+	RegistrationNumber string
+	RemoteID           string
+	MaxTakeoffWeightKg float64
+	FirmwareVersion    string
+	AutopilotType      string
+}
+
+func (drone0005) TableName() string { return "drones" }
+
+var fields0005 = []string{
+	"AirframeType", "SerialNumber", "RegistrationNumber", "RemoteID",
+	"MaxTakeoffWeightKg", "FirmwareVersion", "AutopilotType",
+}
+
+// Snapshot models for migration 6.
+
+type drone0006 struct {
+	FlightSeconds int64
+	FlightCycles  int
+	Grounded      bool
+}
+
+func (drone0006) TableName() string { return "drones" }
+
+var fields0006 = []string{"FlightSeconds", "FlightCycles", "Grounded"}
+
+type flight0006 struct {
+	ID        uint `gorm:"primaryKey"`
+	DroneID   uint `gorm:"index"`
+	StartedAt time.Time
+	EndedAt   time.Time
+	Source    string
+}
+
+func (flight0006) TableName() string { return "flights" }
+
+type serviceInterval0006 struct {
+	ID                    uint `gorm:"primaryKey"`
+	DroneID               uint `gorm:"index"`
+	Name                  string
+	FlightHours           float64
+	Cycles                int
+	ServicedAt            time.Time
+	ServicedFlightSeconds int64
+	ServicedCycles        int
+}
+
+func (serviceInterval0006) TableName() string { return "service_intervals" }
+
+type maintenanceLog0006 struct {
+	ID                uint `gorm:"primaryKey"`
+	DroneID           uint `gorm:"index"`
+	PerformedAt       time.Time
+	Action            string
+	Parts             string
+	Technician        string
+	Notes             string
+	ServiceIntervalID uint
+	FlightSeconds     int64
+	Cycles            int
+}
+
+func (maintenanceLog0006) TableName() string { return "maintenance_logs" }
+
+// Snapshot models for migration 7.
+
+type batteryPack0007 struct {
+	ID                   uint `gorm:"primaryKey"`
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+	SerialNumber         string `gorm:"uniqueIndex"`
+	Chemistry            string
+	CellCount            int
+	RatedCapacityMah     int
+	DroneID              uint `gorm:"index"`
+	Retired              bool
+	Cycles               int
+	EstimatedCapacityMah *float64
+	HealthPercent        *float64
+	LowHealth            bool
+}
+
+func (batteryPack0007) TableName() string { return "battery_packs" }
+
+type batteryDischarge0007 struct {
+	ID                   uint `gorm:"primaryKey"`
+	PackID               uint `gorm:"index"`
+	DroneID              uint
+	StartedAt            time.Time
+	EndedAt              time.Time
+	StartVoltage         float64
+	EndVoltage           float64
+	StartRemaining       int
+	EndRemaining         int
+	ConsumedMah          float64
+	EstimatedCapacityMah *float64
+}
+
+func (batteryDischarge0007) TableName() string { return "battery_discharges" }
+
+// Snapshot models for migration 8. Both tables get the same columns.
+
+type drone0008 struct {
+	GPSFixType       int     `gorm:"column:gps_fix_type"`
+	GPSSatellites    int     `gorm:"column:gps_satellites"`
+	GPSHDOP          float64 `gorm:"column:gps_hdop"`
+	Heading          float64
+	AttitudeRoll     float64
+	AttitudePitch    float64
+	AttitudeYaw      float64
+	GroundSpeed      float64
+	Airspeed         float64
+	RadioRSSI        int `gorm:"column:radio_rssi"`
+	RadioNoise       int `gorm:"column:radio_noise"`
+	RadioRemoteRSSI  int `gorm:"column:radio_remote_rssi"`
+	RadioRemoteNoise int `gorm:"column:radio_remote_noise"`
+}
+
+func (drone0008) TableName() string { return "drones" }
+
+type telemetry0008 drone0008
+
+func (telemetry0008) TableName() string { return "drone_telemetry" }
+
+var fields0008 = []string{
+	"GPSFixType", "GPSSatellites", "GPSHDOP", "Heading", "AttitudeRoll", "AttitudePitch", "AttitudeYaw",
+	"GroundSpeed", "Airspeed", "RadioRSSI", "RadioNoise", "RadioRemoteRSSI", "RadioRemoteNoise",
+}
+
+// Snapshot models for migration 9. FlightStatus is the column renamed to HealthStatus.
+
+type drone0009 struct {
+	FlightStatus string
+	HealthStatus string
+	FlightPhase  string
+	Altitude     float64
+}
+
+func (drone0009) TableName() string { return "drones" }
+
+type telemetry0009 drone0009
+
+func (telemetry0009) TableName() string { return "drone_telemetry" }
+
+// Snapshot models for migration 10. Value holds the ciphertext.
+
+type secret0010 struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Name      string `gorm:"uniqueIndex;not null"`
+	Value     string `gorm:"not null"`
+}
+
+func (secret0010) TableName() string { return "secrets" }
+
+// Snapshot models for migration 11.
+
+type drone0011 struct {
+	SigningPolicy string `gorm:"default:accept"`
+}
+
+func (drone0011) TableName() string { return "drones" }
+
+type securityAlert0011 struct {
+	ID        uint      `gorm:"primaryKey"`
+	CreatedAt time.Time `gorm:"index"`
+	DroneID   uint      `gorm:"index"`
+	SystemID  int
+	Link      string
+	Message   string
+	Kind      string
+	Rejected  bool
+}
+
+func (securityAlert0011) TableName() string { return "security_alerts" }
+
+// Snapshot models for migration 12.
+
+type drone0012 struct {
+	MaxSpeedMps float64
+}
+
+func (drone0012) TableName() string { return "drones" }
+
+type quarantinedSample0012 struct {
+	ID         uint      `gorm:"primaryKey"`
+	DroneID    uint      `gorm:"index"`
+	ReceivedAt time.Time `gorm:"index"`
+	Latitude   float64
+	Longitude  float64
+	Altitude   float64
+	VelocityX  float64
+	VelocityY  float64
+	VelocityZ  float64
+	Score      float64
+	Reasons    string
+	Details    string
+}
+
+func (quarantinedSample0012) TableName() string { return "quarantined_samples" }
+
+// Snapshot models for migration 13.
+
+type auditEntry0013 struct {
+	ID        uint      `gorm:"primaryKey"`
+	CreatedAt time.Time `gorm:"index"`
+	Actor     string    `gorm:"index"`
+	Action    string    `gorm:"index"`
+	Entity    string    `gorm:"index:idx_audit_log_entity"`
+	EntityID  uint      `gorm:"index:idx_audit_log_entity"`
+	Changes   string
+	RequestID string `gorm:"index"`
+	SourceIP  string
+}
+
+func (auditEntry0013) TableName() string { return "audit_log" }
@@ -0,0 +1,24 @@
+package db
+
+import (
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// openPostgres opens a PostgreSQL connection from a postgres:// URL.
+func openPostgres(dsn string) gorm.Dialector {
+	return postgres.Open(dsn)
+}
+
+// hasTimescaleDB reports whether the TimescaleDB extension is installed in the database.
+// The extension has to be created by a superuser beforehand:
+// CREATE EXTENSION IF NOT EXISTS timescaledb;
+func hasTimescaleDB(tx *gorm.DB) (bool, error) {
+	if tx.Dialector.Name() != DialectPostgres {
+		return false, nil
+	}
+
+	var count int64
+	err := tx.Raw("SELECT COUNT(*) FROM pg_extension WHERE extname = ?", "timescaledb").Scan(&count).Error
+	return count > 0, err
+}
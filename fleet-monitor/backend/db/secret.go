@@ -0,0 +1,23 @@
+package db
+
+import (
+	"time"
+
+	"fleet-monitor/backend/secrets"
+)
+
+// Secret is a named sensitive value, such as an API key, a webhook secret or a MAVLink
+// signing key. Its value is encrypted with the master key and never serialized to JSON.
+type Secret struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Name      string    `json:"name" gorm:"uniqueIndex;not null" validate:"required,max=100"`
+	Value     string    `json:"-" gorm:"serializer:encrypted;not null" validate:"required"`
+}
+
+// EncryptedColumns lists the columns encrypted with the master key, which
+// `fleet-monitor secrets rotate` re-encrypts. Add every serializer:encrypted field here.
+var EncryptedColumns = []secrets.Column{
+	{Table: "secrets", Column: "value"},
+}
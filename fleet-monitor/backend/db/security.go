@@ -0,0 +1,44 @@
+package db
+
+import "time"
+
+// SecurityAlertKind is why a MAVLink frame raised a security alert. The values match
+// the mavlink.SignatureStatus of the frame.
+type SecurityAlertKind string
+
+const (
+	// SecurityAlertBadSignature frames are signed with another key or were altered.
+	SecurityAlertBadSignature SecurityAlertKind = "bad_signature"
+	// SecurityAlertReplayed frames are correctly signed but older than frames already
+	// received, e.g. recorded and sent again.
+	SecurityAlertReplayed SecurityAlertKind = "replayed"
+	// SecurityAlertUnsigned frames are not signed although the drone's signing policy
+	// flags or rejects them.
+	SecurityAlertUnsigned SecurityAlertKind = "unsigned"
+)
+
+// IsValid reports whether k is one of the known alert kinds.
+func (k SecurityAlertKind) IsValid() bool {
+	switch k {
+	case SecurityAlertBadSignature, SecurityAlertReplayed, SecurityAlertUnsigned:
+		return true
+	}
+	return false
+}
+
+// SecurityAlert records a suspected spoofing attempt: a MAVLink frame claiming to come
+// from a drone that failed its signature checks. Repeated frames of the same kind
+// raise one alert per minute.
+type SecurityAlert struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+	DroneID   uint      `json:"drone_id" gorm:"index"`
+	// SystemID is the MAVLink system ID the frame claimed, Link the telemetry link it
+	// arrived on and Message the name of its message, e.g. GLOBAL_POSITION_INT.
+	SystemID int               `json:"system_id"`
+	Link     string            `json:"link" validate:"max=200"`
+	Message  string            `json:"message" validate:"max=100"`
+	Kind     SecurityAlertKind `json:"kind" validate:"enum"`
+	// Rejected is set when the frame was dropped rather than only flagged.
+	Rejected bool `json:"rejected"`
+}
@@ -1,23 +1,58 @@
 package db
 
 import (
+	"errors"
+	"fmt"
+	"strings"
+
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
-// OpenDB opens the SQLite database and performs migrations.
-// Example
-// db, err := db.OpenDB("tasks.db")
-func OpenDB(databasePath string) (*gorm.DB, error) {
-	db, err := gorm.Open(sqlite.Open(databasePath), &gorm.Config{})
-	if err != nil {
-		return nil, err
+// openSQLite opens databasePath with foreign key enforcement switched on.
+func openSQLite(databasePath string) gorm.Dialector {
+	return sqlite.Open(withForeignKeys(databasePath))
+}
+
+// withForeignKeys adds the go-sqlite3 DSN option that enables foreign key enforcement.
+func withForeignKeys(databasePath string) string {
+	if strings.Contains(databasePath, "?") {
+		return databasePath + "&_foreign_keys=on"
 	}
+	return databasePath + "?_foreign_keys=on"
+}
 
-	err = db.AutoMigrate(&User{}, &Drone{}, &Task{})
+// foreignKeyViolation is a row reported by PRAGMA foreign_key_check.
+type foreignKeyViolation struct {
+	Table  string
+	RowID  int64
+	Parent string
+	FKID   int
+}
+
+// checkSQLiteForeignKeys fails if any row violates a foreign key constraint.
+// SQLite only needs this while enforcement is switched off for a migration.
+func checkSQLiteForeignKeys(tx *gorm.DB) error {
+	rows, err := tx.Raw("PRAGMA foreign_key_check").Rows()
 	if err != nil {
-		return nil, err
+		return err
 	}
+	defer rows.Close()
 
-	return db, nil
+	var lines []string
+	for rows.Next() {
+		var v foreignKeyViolation
+		if err := rows.Scan(&v.Table, &v.RowID, &v.Parent, &v.FKID); err != nil {
+			return err
+		}
+		lines = append(lines, fmt.Sprintf("%s row %d references a missing %s row", v.Table, v.RowID, v.Parent))
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if len(lines) == 0 {
+		return nil
+	}
+	return errors.New("foreign key check failed:\n  " + strings.Join(lines, "\n  "))
 }
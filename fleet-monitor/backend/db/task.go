@@ -11,17 +11,26 @@ const (
 	TaskStatusAborted   TaskStatus = "aborted"
 )
 
+// IsValid reports whether s is one of the known task statuses.
+func (s TaskStatus) IsValid() bool {
+	switch s {
+	case TaskStatusWaiting, TaskStatusOngoing, TaskStatusCompleted, TaskStatusAborted:
+		return true
+	}
+	return false
+}
+
 // Task struct represents a task assigned to a drone.
 type Task struct {
 	gorm.Model
-	UserID      int        `json:"userId"`
-	User        User       `json:"user" gorm:"foreignKey:UserID"`
-	DroneID     int        `json:"droneId"`
-	Drone       Drone      `json:"drone" gorm:"foreignKey:DroneID"`
-	StartLon    float64    `json:"startLon"`
-	StartLat    float64    `json:"startLat"`
-	EndLon      float64    `json:"endLon"`
-	EndLat      float64    `json:"endLat"`
+	UserID      int        `json:"userId" validate:"required"`
+	User        User       `json:"user" gorm:"foreignKey:UserID;constraint:OnDelete:RESTRICT" validate:"-"`
+	DroneID     int        `json:"droneId" validate:"required"`
+	Drone       Drone      `json:"drone" gorm:"foreignKey:DroneID;constraint:OnDelete:RESTRICT" validate:"-"`
+	StartLon    float64    `json:"startLon" validate:"gte=-180,lte=180"`
+	StartLat    float64    `json:"startLat" validate:"gte=-90,lte=90"`
+	EndLon      float64    `json:"endLon" validate:"gte=-180,lte=180"`
+	EndLat      float64    `json:"endLat" validate:"gte=-90,lte=90"`
 	Description string     `json:"description"`
-	Status      TaskStatus `json:"status"`
+	Status      TaskStatus `json:"status" validate:"enum"`
 }
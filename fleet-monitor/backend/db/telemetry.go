@@ -0,0 +1,60 @@
+package db
+
+import "time"
+
+// Telemetry is one historical sample of a drone's real-time state.
+// The primary key is (drone_id, recorded_at) with no surrogate ID, so on
+// PostgreSQL the table can be a TimescaleDB hypertable partitioned by time. Of two
+// samples for the same drone and time, such as an API and an ingest update within a
+// microsecond, the first is kept.
+type Telemetry struct {
+	DroneID      uint         `json:"drone_id" gorm:"primaryKey;autoIncrement:false"`
+	RecordedAt   time.Time    `json:"recorded_at" gorm:"primaryKey"`
+	GPS          GPS          `json:"gps" gorm:"embedded;embeddedPrefix:gps_"`
+	Velocity     Velocity     `json:"velocity" gorm:"embedded;embeddedPrefix:velocity_"`
+	Altitude     float64      `json:"altitude"`
+	HealthStatus HealthStatus `json:"health_status"`
+	FlightPhase  FlightPhase  `json:"flight_phase"`
+	Battery      int          `json:"battery"`
+	Heading      float64      `json:"heading"`
+	Attitude     Attitude     `json:"attitude" gorm:"embedded;embeddedPrefix:attitude_"`
+	GroundSpeed  float64      `json:"ground_speed"`
+	Airspeed     float64      `json:"airspeed"`
+	Radio        Radio        `json:"radio" gorm:"embedded;embeddedPrefix:radio_"`
+}
+
+func (Telemetry) TableName() string {
+	return "drone_telemetry"
+}
+
+// AnomalyReason is why a telemetry sample looks implausible.
+type AnomalyReason string
+
+const (
+	// AnomalyNullIsland samples are at 0,0 although the drone has a GPS fix.
+	AnomalyNullIsland AnomalyReason = "null_island"
+	// AnomalyPositionJump samples are further from the previous position than the
+	// drone can fly in the time between them.
+	AnomalyPositionJump AnomalyReason = "position_jump"
+	// AnomalyAltitudeSpike samples climb or sink faster than any drone can.
+	AnomalyAltitudeSpike AnomalyReason = "altitude_spike"
+	// AnomalyVelocityMismatch samples report a velocity that disagrees with the
+	// distance covered since the previous position.
+	AnomalyVelocityMismatch AnomalyReason = "velocity_mismatch"
+)
+
+// QuarantinedSample is a position update held back from the drone's live state and
+// telemetry history because it looked implausible. Reasons lists the failed checks,
+// comma separated, and Details explains them.
+type QuarantinedSample struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	DroneID    uint      `json:"drone_id" gorm:"index"`
+	ReceivedAt time.Time `json:"received_at" gorm:"index"`
+	Latitude   float64   `json:"latitude"`
+	Longitude  float64   `json:"longitude"`
+	Altitude   float64   `json:"altitude"`
+	Velocity   Velocity  `json:"velocity" gorm:"embedded;embeddedPrefix:velocity_"`
+	Score      float64   `json:"score"`
+	Reasons    string    `json:"reasons"`
+	Details    string    `json:"details"`
+}
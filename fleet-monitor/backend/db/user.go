@@ -4,8 +4,12 @@ import "gorm.io/gorm"
 
 type User struct {
 	gorm.Model
-	UserID   int     `json:"user_id"`
-	UserName string  `json:"username"`
-	TaskID   int     `json:"task_id"`
-	Drones   []Drone `json:"drones" gorm:"foreignKey:OwnerID"`
+	UserName    string `json:"username" validate:"required"`
+	DisplayName string `json:"displayName" validate:"max=100"`
+	Email       string `json:"email" validate:"omitempty,email"`
+	// ContactPhone is called when one of the user's drones needs attention,
+	// in E.164 format such as +14155550100.
+	ContactPhone string  `json:"contactPhone" validate:"omitempty,e164"`
+	Drones       []Drone `json:"drones,omitempty" gorm:"foreignKey:OwnerID;constraint:OnDelete:RESTRICT" validate:"-"`
+	Tasks        []Task  `json:"tasks,omitempty" gorm:"foreignKey:UserID;constraint:OnDelete:RESTRICT" validate:"-"`
 }
@@ -0,0 +1,167 @@
+// Package health checks the components fleet-monitor depends on, for the /healthz
+// and /readyz endpoints.
+package health
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"fleet-monitor/backend/db"
+	"fleet-monitor/backend/ingest"
+
+	"gorm.io/gorm"
+)
+
+// checkTimeout bounds each check so a hung database cannot hang the probe.
+const checkTimeout = 2 * time.Second
+
+type Status string
+
+const (
+	StatusOK Status = "ok"
+	// StatusDegraded still serves, e.g. with some of the telemetry links down.
+	StatusDegraded Status = "degraded"
+	StatusDown     Status = "down"
+)
+
+// severity orders the statuses from best to worst.
+var severity = map[Status]int{StatusOK: 0, StatusDegraded: 1, StatusDown: 2}
+
+// Component is the result of one check.
+type Component struct {
+	Status  Status      `json:"status"`
+	Message string      `json:"message,omitempty"`
+	Details interface{} `json:"details,omitempty"`
+}
+
+// Report is the result of all checks. Its status is the worst of its components.
+// Example
+// {"status": "degraded", "components": {"database": {"status": "ok"}, "links": {"status": "degraded", ...}}}
+type Report struct {
+	Status     Status               `json:"status"`
+	Components map[string]Component `json:"components"`
+}
+
+// Check checks one component.
+type Check func(ctx context.Context) Component
+
+// Checker runs a set of named checks.
+type Checker struct {
+	checks map[string]Check
+}
+
+// NewChecker creates a Checker with no checks; a Checker without checks reports ok.
+// Example
+// checker := health.NewChecker()
+// checker.Add("database", health.Database(conn))
+// checker.Add("migrations", health.Migrations(conn))
+// checker.Add("links", health.Links(listener.Links))
+func NewChecker() *Checker {
+	return &Checker{checks: map[string]Check{}}
+}
+
+// Add registers check under name, replacing any check of that name.
+func (c *Checker) Add(name string, check Check) {
+	c.checks[name] = check
+}
+
+// Run runs all checks concurrently.
+func (c *Checker) Run(ctx context.Context) Report {
+	report := Report{Status: StatusOK, Components: make(map[string]Component, len(c.checks))}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range c.checks {
+		wg.Add(1)
+		go func(name string, check Check) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+			defer cancel()
+			component := check(ctx)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Components[name] = component
+			if severity[component.Status] > severity[report.Status] {
+				report.Status = component.Status
+			}
+		}(name, check)
+	}
+	wg.Wait()
+
+	return report
+}
+
+// Database checks that the database answers.
+func Database(conn *gorm.DB) Check {
+	return func(ctx context.Context) Component {
+		sqlDB, err := conn.DB()
+		if err == nil {
+			err = sqlDB.PingContext(ctx)
+		}
+		if err != nil {
+			return Component{Status: StatusDown, Message: err.Error()}
+		}
+		return Component{Status: StatusOK}
+	}
+}
+
+// Migrations checks that every migration has been applied.
+func Migrations(conn *gorm.DB) Check {
+	return func(ctx context.Context) Component {
+		pending, err := db.UnappliedMigrations(ctx, conn)
+		if err != nil {
+			return Component{Status: StatusDown, Message: err.Error()}
+		}
+		if len(pending) > 0 {
+			names := make([]string, len(pending))
+			for i, m := range pending {
+				names[i] = fmt.Sprintf("%04d %s", m.Version, m.Name)
+			}
+			return Component{
+				Status:  StatusDown,
+				Message: fmt.Sprintf("%d pending migrations, run fleet-monitor migrate up", len(pending)),
+				Details: names,
+			}
+		}
+		return Component{Status: StatusOK}
+	}
+}
+
+// Links checks the telemetry links reported by links, usually Listener.Links. The
+// component is degraded while some links are silent or closed, and down when no link
+// is open.
+func Links(links func() []ingest.LinkStatus) Check {
+	return func(ctx context.Context) Component {
+		statuses := links()
+		if len(statuses) == 0 {
+			return Component{Status: StatusOK, Message: "no telemetry links configured"}
+		}
+
+		var open int
+		var notConnected []string
+		for _, link := range statuses {
+			if link.State != ingest.LinkClosed {
+				open++
+			}
+			if link.State != ingest.LinkConnected {
+				notConnected = append(notConnected, link.Name+" "+string(link.State))
+			}
+		}
+
+		component := Component{Status: StatusOK, Details: statuses}
+		switch {
+		case open == 0:
+			component.Status = StatusDown
+		case len(notConnected) > 0:
+			component.Status = StatusDegraded
+		}
+		if len(notConnected) > 0 {
+			component.Message = strings.Join(notConnected, ", ")
+		}
+		return component
+	}
+}
@@ -0,0 +1,109 @@
+package ingest
+
+import (
+	"time"
+
+	"fleet-monitor/backend/mavlink"
+	"fleet-monitor/backend/service"
+)
+
+// batteryReading is the main battery's state as last reported. Unknown values are -1.
+type batteryReading struct {
+	volts       float64
+	remaining   int
+	consumedMah float64
+}
+
+var unknownBattery = batteryReading{volts: -1, remaining: -1, consumedMah: -1}
+
+// batteryTracker follows a drone's main battery across a flight. The charge used comes
+// from the consumption reported in BATTERY_STATUS, or is integrated from the current
+// in SYS_STATUS for autopilots that do not report it.
+type batteryTracker struct {
+	latest batteryReading
+	// atArm is the reading when the drone was armed.
+	atArm batteryReading
+	// integratedMah is the charge used since arming according to SYS_STATUS.
+	integratedMah float64
+	lastCurrentAt time.Time
+	armed         bool
+	seen          bool
+}
+
+func (b *batteryTracker) reading() *batteryReading {
+	if !b.seen {
+		b.latest = unknownBattery
+		b.seen = true
+	}
+	return &b.latest
+}
+
+func (b *batteryTracker) sysStatus(m *mavlink.SysStatus, now time.Time) {
+	r := b.reading()
+	r.volts = m.Volts()
+	if m.BatteryRemaining >= 0 {
+		r.remaining = int(m.BatteryRemaining)
+	}
+
+	if amps := m.Amps(); b.armed && amps >= 0 {
+		if !b.lastCurrentAt.IsZero() {
+			b.integratedMah += amps * now.Sub(b.lastCurrentAt).Hours() * 1000
+		}
+		b.lastCurrentAt = now
+	}
+}
+
+func (b *batteryTracker) batteryStatus(m *mavlink.BatteryStatus) {
+	// Only the first battery is the one installed as the drone's pack.
+	if m.ID != 0 {
+		return
+	}
+
+	r := b.reading()
+	if m.CurrentConsumed >= 0 {
+		r.consumedMah = float64(m.CurrentConsumed)
+	}
+	if m.BatteryRemaining >= 0 {
+		r.remaining = int(m.BatteryRemaining)
+	}
+}
+
+func (b *batteryTracker) arm() {
+	b.atArm = *b.reading()
+	b.integratedMah = 0
+	b.lastCurrentAt = time.Time{}
+	b.armed = true
+}
+
+// disarm ends the flight and returns its discharge. It reports false if the drone did
+// not send enough battery data to measure one.
+func (b *batteryTracker) disarm(startedAt, endedAt time.Time) (service.DischargeDetails, bool) {
+	b.armed = false
+	start, end := b.atArm, *b.reading()
+
+	consumed := b.integratedMah
+	if start.consumedMah >= 0 && end.consumedMah >= start.consumedMah {
+		consumed = end.consumedMah - start.consumedMah
+	}
+	if consumed <= 0 || (start.volts <= 0 && start.remaining < 0) {
+		return service.DischargeDetails{}, false
+	}
+
+	return service.DischargeDetails{
+		StartedAt:      startedAt,
+		EndedAt:        endedAt,
+		StartVoltage:   positive(start.volts),
+		EndVoltage:     positive(end.volts),
+		StartRemaining: start.remaining,
+		EndRemaining:   end.remaining,
+		ConsumedMah:    consumed,
+	}, true
+}
+
+// positive maps an unknown reading of -1 to 0.
+func positive(v float64) float64 {
+	if v < 0 {
+		return 0
+	}
+	return v
+}
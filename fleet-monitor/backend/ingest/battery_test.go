@@ -0,0 +1,79 @@
+package ingest
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"fleet-monitor/backend/mavlink"
+)
+
+func TestBatteryTrackerReportedConsumption(t *testing.T) {
+	start := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	end := start.Add(20 * time.Minute)
+
+	var b batteryTracker
+	b.sysStatus(&mavlink.SysStatus{VoltageBattery: 25200, CurrentBattery: -1, BatteryRemaining: -1}, start)
+	b.batteryStatus(&mavlink.BatteryStatus{CurrentConsumed: 150, BatteryRemaining: 95})
+	b.arm()
+	b.sysStatus(&mavlink.SysStatus{VoltageBattery: 23000, CurrentBattery: 2000, BatteryRemaining: -1}, end)
+	// Batteries other than the first are not the drone's pack.
+	b.batteryStatus(&mavlink.BatteryStatus{ID: 1, CurrentConsumed: 9000, BatteryRemaining: 5})
+	b.batteryStatus(&mavlink.BatteryStatus{CurrentConsumed: 4150, BatteryRemaining: 55})
+
+	discharge, ok := b.disarm(start, end)
+	if !ok {
+		t.Fatal("disarm reported no discharge")
+	}
+	if discharge.ConsumedMah != 4000 || discharge.StartVoltage != 25.2 || discharge.EndVoltage != 23 ||
+		discharge.StartRemaining != 95 || discharge.EndRemaining != 55 || !discharge.EndedAt.Equal(end) {
+		t.Errorf("discharge = %+v", discharge)
+	}
+}
+
+func TestBatteryTrackerIntegratesCurrent(t *testing.T) {
+	start := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+
+	var b batteryTracker
+	// Current drawn before arming does not count.
+	b.sysStatus(&mavlink.SysStatus{VoltageBattery: 25200, CurrentBattery: 500, BatteryRemaining: 90}, start.Add(-time.Minute))
+	b.arm()
+	for i := 0; i <= 6; i++ {
+		// 30 A for 10 minutes each is 5000 mAh.
+		b.sysStatus(&mavlink.SysStatus{VoltageBattery: 24000, CurrentBattery: 3000, BatteryRemaining: 60}, start.Add(time.Duration(i)*10*time.Minute))
+	}
+
+	discharge, ok := b.disarm(start, start.Add(time.Hour))
+	if !ok {
+		t.Fatal("disarm reported no discharge")
+	}
+	if math.Abs(discharge.ConsumedMah-30000) > 0.01 || discharge.StartRemaining != 90 || discharge.EndRemaining != 60 {
+		t.Errorf("discharge = %+v, want 30000 mAh from 90%% to 60%%", discharge)
+	}
+
+	// Samples after disarming are not integrated into the next flight.
+	b.sysStatus(&mavlink.SysStatus{VoltageBattery: 24000, CurrentBattery: 3000, BatteryRemaining: 60}, start.Add(2*time.Hour))
+	b.arm()
+	if _, ok := b.disarm(start, start.Add(2*time.Hour)); ok {
+		t.Error("a flight without current samples reported a discharge")
+	}
+}
+
+func TestBatteryTrackerWithoutData(t *testing.T) {
+	start := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+
+	var b batteryTracker
+	b.arm()
+	if _, ok := b.disarm(start, start.Add(time.Minute)); ok {
+		t.Error("a flight without battery data reported a discharge")
+	}
+
+	// Consumption without a starting voltage or charge cannot be placed on the pack.
+	b = batteryTracker{}
+	b.batteryStatus(&mavlink.BatteryStatus{CurrentConsumed: 100, BatteryRemaining: -1})
+	b.arm()
+	b.batteryStatus(&mavlink.BatteryStatus{CurrentConsumed: 900, BatteryRemaining: -1})
+	if _, ok := b.disarm(start, start.Add(time.Minute)); ok {
+		t.Error("a flight without a starting voltage or charge reported a discharge")
+	}
+}
@@ -0,0 +1,87 @@
+package ingest
+
+import (
+	"sort"
+	"time"
+)
+
+// linkTimeout is how long an open link may go without a valid frame before it counts
+// as silent, several missed heartbeats.
+const linkTimeout = 10 * time.Second
+
+// LinkState is whether frames arrive on a telemetry link.
+type LinkState string
+
+const (
+	// LinkConnected links received a valid frame within the last 10 seconds.
+	LinkConnected LinkState = "connected"
+	// LinkSilent links are open but nothing valid arrived lately, e.g. because the
+	// drone is switched off or out of range.
+	LinkSilent LinkState = "silent"
+	// LinkClosed links failed or were never opened.
+	LinkClosed LinkState = "closed"
+)
+
+// LinkStatus describes a link the Listener reads from.
+type LinkStatus struct {
+	Name        string     `json:"name"`
+	State       LinkState  `json:"state"`
+	LastFrameAt *time.Time `json:"lastFrameAt,omitempty"`
+	Error       string     `json:"error,omitempty"`
+}
+
+type telemetryLink struct {
+	open        bool
+	lastFrameAt time.Time
+	err         error
+}
+
+// Links reports the state of the links passed to Listen, including those Listen has
+// returned for. It is safe to call while the Listener runs.
+func (l *Listener) Links() []LinkStatus {
+	l.linksMu.Lock()
+	defer l.linksMu.Unlock()
+
+	statuses := make([]LinkStatus, 0, len(l.links))
+	for name, lk := range l.links {
+		status := LinkStatus{Name: name, State: LinkClosed}
+		if !lk.lastFrameAt.IsZero() {
+			lastFrameAt := lk.lastFrameAt
+			status.LastFrameAt = &lastFrameAt
+		}
+		if lk.err != nil {
+			status.Error = lk.err.Error()
+		}
+		if lk.open {
+			status.State = LinkSilent
+			if time.Since(lk.lastFrameAt) <= linkTimeout {
+				status.State = LinkConnected
+			}
+		}
+		statuses = append(statuses, status)
+	}
+
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// linkOpened registers a link when Listen starts reading it.
+func (l *Listener) linkOpened(name string) {
+	l.linksMu.Lock()
+	defer l.linksMu.Unlock()
+	l.links[name] = &telemetryLink{open: true}
+}
+
+func (l *Listener) frameReceived(name string, now time.Time) {
+	l.linksMu.Lock()
+	defer l.linksMu.Unlock()
+	l.links[name].lastFrameAt = now
+}
+
+// linkClosed records why Listen stopped reading a link.
+func (l *Listener) linkClosed(name string, err error) {
+	l.linksMu.Lock()
+	defer l.linksMu.Unlock()
+	l.links[name].open = false
+	l.links[name].err = err
+}
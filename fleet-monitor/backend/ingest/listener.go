@@ -0,0 +1,300 @@
+// Package ingest applies the MAVLink messages received over a telemetry link to the
+// drones in the database.
+package ingest
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"fleet-monitor/backend/db"
+	"fleet-monitor/backend/mavlink"
+	"fleet-monitor/backend/metrics"
+	"fleet-monitor/backend/service"
+	"fleet-monitor/backend/utils"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Listener reads MAVLink frames, keeps the drones' autopilot details and live state up
+// to date and records a flight, and the discharge of the battery pack installed, each
+// time a drone is armed and disarmed again.
+// A drone is matched by its mavlink ID, which is the MAVLink system ID in decimal,
+// e.g. "1" for the default system ID of ArduPilot and PX4. Frames claiming to come
+// from a drone are first checked against its MAVLink 2 signing, and implausible
+// positions are quarantined instead of applied.
+type Listener struct {
+	drones      service.DroneService
+	maintenance service.MaintenanceService
+	batteries   service.BatteryService
+	security    service.SecurityService
+	logger      *utils.Logger
+
+	systems map[uint8]*system
+	// unknown holds the system IDs already reported as not registered.
+	unknown map[uint8]bool
+
+	verifier *mavlink.Verifier
+	signing  map[uint8]*signingState
+	alerted  map[alertKey]time.Time
+
+	// links is read by health checks while Listen runs.
+	linksMu sync.Mutex
+	links   map[string]*telemetryLink
+}
+
+// system is what the Listener knows about a connected drone.
+type system struct {
+	droneID uint
+	// info is the autopilot info last stored, so heartbeats only reach the
+	// database when something changed.
+	info service.AutopilotInfo
+	// armedAt is when the drone was armed, zero while it is disarmed.
+	armedAt time.Time
+	battery batteryTracker
+	live    liveState
+	// plausibility holds back implausible positions from live.
+	plausibility plausibility
+}
+
+// NewListener creates a Listener updating drones through the given services.
+// Example
+// listener := ingest.NewListener(droneService, maintenanceService, batteryService, securityService, output.Logger(utils.LogPrefixMavlink))
+// err := listener.Listen(ctx, "/dev/ttyUSB0", port)
+func NewListener(drones service.DroneService, maintenance service.MaintenanceService, batteries service.BatteryService, security service.SecurityService, logger *utils.Logger) *Listener {
+	return &Listener{
+		drones:      drones,
+		maintenance: maintenance,
+		batteries:   batteries,
+		security:    security,
+		logger:      logger,
+		systems:     map[uint8]*system{},
+		unknown:     map[uint8]bool{},
+		verifier:    mavlink.NewVerifier(),
+		signing:     map[uint8]*signingState{},
+		alerted:     map[alertKey]time.Time{},
+		links:       map[string]*telemetryLink{},
+	}
+}
+
+// Listen handles the frames read from r until r fails or ctx is done. If r is an
+// io.Closer it is closed when ctx is done so a blocked read returns. link names r
+// in the metrics and in Links, e.g. the serial device or UDP address.
+func (l *Listener) Listen(ctx context.Context, link string, r io.Reader) (err error) {
+	// The live state and quarantined samples are kept in the telemetry history and
+	// quarantine rather than the audit log.
+	ctx = service.WithTelemetryIngest(ctx)
+	l.linkOpened(link)
+	defer func() { l.linkClosed(link, err) }()
+
+	if closer, ok := r.(io.Closer); ok {
+		stop := make(chan struct{})
+		defer close(stop)
+		go func() {
+			select {
+			case <-ctx.Done():
+				closer.Close()
+			case <-stop:
+			}
+		}()
+	}
+
+	packets := metrics.LinkPackets.WithLabelValues(link)
+	crcErrors := metrics.LinkCRCErrors.WithLabelValues(link)
+	reader := mavlink.NewReader(&countingReader{r: r, bytes: metrics.LinkBytes.WithLabelValues(link)})
+	var badChecksums uint64
+	for {
+		frame, err := reader.ReadFrame()
+		if n := reader.BadChecksums(); n > badChecksums {
+			crcErrors.Add(float64(n - badChecksums))
+			badChecksums = n
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			return err
+		}
+
+		l.frameReceived(link, time.Now())
+		packets.Inc()
+		metrics.DroneMessages.WithLabelValues(strconv.Itoa(int(frame.SystemID)), mavlink.MessageName(frame.MessageID)).Inc()
+		if !l.verify(ctx, link, frame) {
+			continue
+		}
+		err = l.handle(ctx, frame)
+		metrics.TelemetryQueueDepth.Set(float64(l.pending()))
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			l.logger.Error("failed to handle message", "system", frame.SystemID, "message", frame.MessageID, "error", err)
+		}
+	}
+}
+
+func (l *Listener) handle(ctx context.Context, frame *mavlink.Frame) error {
+	message, err := frame.Decode()
+	if err != nil {
+		return err
+	}
+
+	switch m := message.(type) {
+	case *mavlink.Heartbeat:
+		// Ground stations and peripherals send heartbeats too.
+		if !m.Autopilot.IsVehicle() {
+			return nil
+		}
+		sys, err := l.apply(ctx, frame.SystemID, service.AutopilotInfo{AutopilotType: m.Autopilot.String()})
+		if err != nil || sys == nil {
+			return err
+		}
+		metrics.DroneSeen(strconv.FormatUint(uint64(sys.droneID), 10), time.Now())
+		sys.live.update(m)
+		if err := l.track(ctx, sys, m.Armed(), time.Now()); err != nil {
+			return err
+		}
+		return l.save(ctx, sys, time.Now())
+
+	case *mavlink.AutopilotVersion:
+		_, err := l.apply(ctx, frame.SystemID, service.AutopilotInfo{FirmwareVersion: m.FirmwareVersion()})
+		return err
+
+	case *mavlink.BatteryStatus:
+		if sys := l.systems[frame.SystemID]; sys != nil {
+			sys.battery.batteryStatus(m)
+		}
+		return nil
+
+	case *mavlink.RadioStatus:
+		if sys := l.radioSystem(frame.SystemID); sys != nil {
+			sys.live.update(m)
+			return l.save(ctx, sys, time.Now())
+		}
+		return nil
+	}
+
+	// The other messages are telemetry of a connected drone.
+	sys := l.systems[frame.SystemID]
+	if sys == nil {
+		return nil
+	}
+	switch m := message.(type) {
+	case *mavlink.SysStatus:
+		sys.battery.sysStatus(m, time.Now())
+	case *mavlink.GlobalPosition:
+		if !l.plausible(ctx, frame.SystemID, sys, m, time.Now()) {
+			return nil
+		}
+	}
+	if !sys.live.update(message) {
+		return nil
+	}
+	return l.save(ctx, sys, time.Now())
+}
+
+// pending counts the drones whose live state changed since it was last written.
+func (l *Listener) pending() int {
+	count := 0
+	for _, sys := range l.systems {
+		if sys.live.changed {
+			count++
+		}
+	}
+	return count
+}
+
+// countingReader adds the bytes read from a link to a counter.
+type countingReader struct {
+	r     io.Reader
+	bytes prometheus.Counter
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.bytes.Add(float64(n))
+	return n, err
+}
+
+// apply stores info on the drone of the given system unless it is already stored.
+// It returns nil if the system is not registered as a drone.
+func (l *Listener) apply(ctx context.Context, systemID uint8, info service.AutopilotInfo) (*system, error) {
+	sys := l.systems[systemID]
+	if sys != nil &&
+		(info.AutopilotType == "" || info.AutopilotType == sys.info.AutopilotType) &&
+		(info.FirmwareVersion == "" || info.FirmwareVersion == sys.info.FirmwareVersion) {
+		return sys, nil
+	}
+
+	mavlinkID := strconv.Itoa(int(systemID))
+	drone, err := l.drones.UpdateAutopilotInfo(ctx, mavlinkID, info)
+	if errors.Is(err, service.ErrNotFound) {
+		if !l.unknown[systemID] {
+			l.unknown[systemID] = true
+			l.logger.Warn("system is not registered as a drone, register it with its mavlink ID", "system", systemID, "mavlinkId", mavlinkID)
+		}
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if sys == nil || sys.droneID != drone.ID {
+		l.logger.Info("drone connected", "drone", drone.ID, "mavlinkId", mavlinkID,
+			"autopilot", drone.AutopilotType, "firmware", drone.FirmwareVersion)
+		sys = &system{droneID: drone.ID, plausibility: newPlausibility(drone.MaxSpeedMps, time.Now())}
+		l.systems[systemID] = sys
+	}
+	delete(l.unknown, systemID)
+	sys.info = service.AutopilotInfo{AutopilotType: drone.AutopilotType, FirmwareVersion: drone.FirmwareVersion}
+	return sys, nil
+}
+
+// track records a flight when a drone armed since an earlier heartbeat is disarmed.
+// A flight still armed when the link is lost is not recorded.
+func (l *Listener) track(ctx context.Context, sys *system, armed bool, now time.Time) error {
+	switch {
+	case armed && sys.armedAt.IsZero():
+		sys.armedAt = now
+		sys.battery.arm()
+
+	case !armed && !sys.armedAt.IsZero():
+		details := service.FlightDetails{StartedAt: sys.armedAt, EndedAt: now, Source: db.FlightSourceMavlink}
+		discharge, measured := sys.battery.disarm(sys.armedAt, now)
+		sys.armedAt = time.Time{}
+
+		flight, err := l.maintenance.RecordFlight(ctx, sys.droneID, details)
+		if err != nil {
+			return err
+		}
+		l.logger.Info("flight recorded", "drone", sys.droneID, "duration", flight.Duration().Round(time.Second))
+
+		if measured {
+			return l.recordDischarge(ctx, sys.droneID, discharge)
+		}
+	}
+
+	return nil
+}
+
+// recordDischarge adds the flight's discharge to the pack installed in the drone, if
+// any, and warns when the pack's health drops below the threshold.
+func (l *Listener) recordDischarge(ctx context.Context, droneID uint, details service.DischargeDetails) error {
+	result, err := l.batteries.RecordDischarge(ctx, droneID, details)
+	if errors.Is(err, service.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if pack := result.Pack; pack.LowHealth {
+		l.logger.Warn("battery pack health is low", "pack", pack.ID, "serialNumber", pack.SerialNumber, "drone", droneID,
+			"healthPercent", math.Round(*pack.HealthPercent), "threshold", service.DefaultBatteryHealthThreshold)
+	}
+	return nil
+}
@@ -0,0 +1,280 @@
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"fleet-monitor/backend/db"
+	"fleet-monitor/backend/mavlink"
+	"fleet-monitor/backend/metrics"
+)
+
+const (
+	// defaultMaxSpeed is the airframe speed in m/s position updates are checked
+	// against when the drone has no max_speed_mps, above what most multirotors fly.
+	defaultMaxSpeed = 50.0
+	// maxVerticalSpeed is the fastest climb or descent in m/s of any drone we fly.
+	maxVerticalSpeed = 20.0
+	// positionTolerance and altitudeTolerance in m allow for GPS noise between two
+	// updates close together.
+	positionTolerance = 10.0
+	altitudeTolerance = 5.0
+	// velocityTolerance in m/s allows for the reported velocity lagging the position.
+	velocityTolerance = 5.0
+	// minVelocityCheck is the shortest time between updates over which the distance
+	// covered says anything about the velocity.
+	minVelocityCheck = 500 * time.Millisecond
+	// recoverySamples is how many quarantined updates in a row, each plausible from
+	// the one before, replace the reference, so a drone whose reference was itself
+	// wrong recovers while a single spoofed jump never does.
+	recoverySamples = 10
+	// quarantineScore is the score from which an update is quarantined.
+	quarantineScore = 1.0
+	// maxSpeedRefresh is how long a drone's max_speed_mps is used before it is read
+	// again, like its signing.
+	maxSpeedRefresh = signingRefresh
+)
+
+// anomalyWeights is how much each failed check adds to the score of an update. A
+// velocity mismatch alone happens with a lagging estimator, so it is only counted.
+var anomalyWeights = map[db.AnomalyReason]float64{
+	db.AnomalyNullIsland:       1,
+	db.AnomalyPositionJump:     1,
+	db.AnomalyAltitudeSpike:    1,
+	db.AnomalyVelocityMismatch: 0.5,
+}
+
+// positionFix is an accepted position update.
+type positionFix struct {
+	latitude   float64
+	longitude  float64
+	altitude   float64
+	timeBootMs uint32
+	receivedAt time.Time
+}
+
+// plausibility scores a drone's GLOBAL_POSITION_INT updates against the last one it
+// accepted.
+type plausibility struct {
+	// maxSpeed is the fastest the drone flies in m/s, read from the drone at
+	// maxSpeedLoadedAt and again after maxSpeedRefresh.
+	maxSpeed         float64
+	maxSpeedLoadedAt time.Time
+	last             *positionFix
+	// candidate is the latest of the consistent quarantined updates counted in
+	// consistent, which replace last once there are recoverySamples of them.
+	candidate  *positionFix
+	consistent int
+	// quarantinedAt is when an update was last recorded as quarantined.
+	quarantinedAt time.Time
+}
+
+// verdict is the outcome of checking a position update.
+type verdict struct {
+	score   float64
+	reasons []db.AnomalyReason
+	details []string
+	// drop is set for updates without a position, which are neither applied nor
+	// quarantined.
+	drop bool
+	// recovered is set for the update that completed recoverySamples consistent
+	// quarantined updates. It is applied despite its score.
+	recovered bool
+}
+
+func (v *verdict) fail(reason db.AnomalyReason, format string, args ...interface{}) {
+	v.score += anomalyWeights[reason]
+	v.reasons = append(v.reasons, reason)
+	v.details = append(v.details, fmt.Sprintf(format, args...))
+}
+
+func (v *verdict) quarantined() bool {
+	return v.score >= quarantineScore && !v.recovered
+}
+
+// newPlausibility checks a drone's updates against maxSpeed, its max_speed_mps read at now.
+func newPlausibility(maxSpeed float64, now time.Time) plausibility {
+	var p plausibility
+	p.setMaxSpeed(maxSpeed, now)
+	return p
+}
+
+func (p *plausibility) setMaxSpeed(maxSpeed float64, now time.Time) {
+	if maxSpeed <= 0 {
+		maxSpeed = defaultMaxSpeed
+	}
+	p.maxSpeed = maxSpeed
+	p.maxSpeedLoadedAt = now
+}
+
+// check scores m, received at now while the drone reported fixType in GPS_RAW_INT.
+// Updates that are not quarantined become the reference for the next ones.
+func (p *plausibility) check(m *mavlink.GlobalPosition, fixType int, now time.Time) verdict {
+	var v verdict
+	fix := positionFix{
+		latitude:   float64(m.Lat) / 1e7,
+		longitude:  float64(m.Lon) / 1e7,
+		altitude:   float64(m.RelativeAlt) / 1000,
+		timeBootMs: m.TimeBootMs,
+		receivedAt: now,
+	}
+
+	// Autopilots send 0,0 until they have a position.
+	if m.Lat == 0 && m.Lon == 0 {
+		if fixType < 2 {
+			v.drop = true
+			return v
+		}
+		// Null island is never a reference, however often it is reported.
+		v.fail(db.AnomalyNullIsland, "position 0,0 with GPS fix type %d", fixType)
+		return v
+	}
+
+	if p.last != nil {
+		p.compare(&v, p.last, fix, m)
+	}
+	if !v.quarantined() {
+		p.last = &fix
+		p.candidate, p.consistent = nil, 0
+		return v
+	}
+
+	// A run of quarantined updates that agree with each other is where the drone
+	// really is, e.g. after its reference was a glitch or it was moved while the link
+	// was down.
+	var next verdict
+	if p.candidate != nil {
+		p.compare(&next, p.candidate, fix, m)
+	}
+	if p.candidate == nil || next.quarantined() {
+		p.consistent = 0
+	}
+	p.candidate = &fix
+	p.consistent++
+	if p.consistent >= recoverySamples {
+		p.last = &fix
+		p.candidate, p.consistent = nil, 0
+		v.recovered = true
+	}
+	return v
+}
+
+// compare scores fix, the position of m, against the earlier position ref.
+func (p *plausibility) compare(v *verdict, ref *positionFix, fix positionFix, m *mavlink.GlobalPosition) {
+	// The autopilot's boot time is more accurate than when the frames arrived, which
+	// radios deliver in bursts, unless the drone rebooted.
+	elapsed := fix.receivedAt.Sub(ref.receivedAt)
+	if fix.timeBootMs > ref.timeBootMs {
+		elapsed = time.Duration(fix.timeBootMs-ref.timeBootMs) * time.Millisecond
+	}
+	dt := elapsed.Seconds()
+
+	distance := haversine(ref.latitude, ref.longitude, fix.latitude, fix.longitude)
+	if limit := p.maxSpeed*dt + positionTolerance; distance > limit {
+		v.fail(db.AnomalyPositionJump, "moved %.0f m in %.1f s, at most %.0f m at %.0f m/s", distance, dt, limit, p.maxSpeed)
+	}
+
+	climb := math.Abs(fix.altitude - ref.altitude)
+	if limit := maxVerticalSpeed*dt + altitudeTolerance; climb > limit {
+		v.fail(db.AnomalyAltitudeSpike, "altitude changed %.0f m in %.1f s, at most %.0f m", climb, dt, limit)
+	}
+
+	if elapsed >= minVelocityCheck {
+		reported := math.Hypot(float64(m.VX), float64(m.VY)) / 100
+		covered := distance / dt
+		if diff := math.Abs(reported - covered); diff > velocityTolerance+positionTolerance/dt {
+			v.fail(db.AnomalyVelocityMismatch, "reported %.1f m/s but covered %.1f m/s", reported, covered)
+		}
+	}
+}
+
+// plausible checks a position update of sys and reports whether it may be applied to
+// the drone's live state. Every failed check is counted; quarantined updates are
+// recorded at most once per telemetryInterval, like the telemetry history.
+func (l *Listener) plausible(ctx context.Context, systemID uint8, sys *system, m *mavlink.GlobalPosition, now time.Time) bool {
+	l.refreshMaxSpeed(ctx, sys, now)
+	v := sys.plausibility.check(m, sys.live.state.GPS.FixType, now)
+	if v.drop {
+		return false
+	}
+
+	label := strconv.Itoa(int(systemID))
+	for _, reason := range v.reasons {
+		metrics.TelemetryAnomalies.WithLabelValues(label, string(reason)).Inc()
+	}
+	if v.recovered {
+		l.logger.Warn("position accepted as the new reference after consistent quarantined updates", "drone", sys.droneID,
+			"system", systemID, "updates", recoverySamples, "reasons", v.reasons)
+	}
+	if !v.quarantined() {
+		return true
+	}
+	metrics.TelemetryQuarantined.WithLabelValues(label).Inc()
+
+	p := &sys.plausibility
+	if now.Sub(p.quarantinedAt) < telemetryInterval {
+		return false
+	}
+	p.quarantinedAt = now
+
+	reasons := make([]string, len(v.reasons))
+	for i, reason := range v.reasons {
+		reasons[i] = string(reason)
+	}
+	sample := &db.QuarantinedSample{
+		DroneID:    sys.droneID,
+		ReceivedAt: now,
+		Latitude:   float64(m.Lat) / 1e7,
+		Longitude:  float64(m.Lon) / 1e7,
+		Altitude:   float64(m.RelativeAlt) / 1000,
+		Velocity:   db.Velocity{X: float64(m.VX) / 100, Y: float64(m.VY) / 100, Z: float64(m.VZ) / 100},
+		Score:      v.score,
+		Reasons:    strings.Join(reasons, ","),
+		Details:    strings.Join(v.details, "; "),
+	}
+	l.logger.Warn("implausible position quarantined", "drone", sys.droneID, "system", systemID,
+		"score", v.score, "reasons", sample.Reasons, "details", sample.Details)
+	if err := l.drones.QuarantineSample(ctx, sample); err != nil && ctx.Err() == nil {
+		l.logger.Error("failed to record quarantined sample", "drone", sys.droneID, "error", err)
+	}
+	return false
+}
+
+// refreshMaxSpeed reads the drone's max_speed_mps again once maxSpeedRefresh passed, so
+// changes made through the API apply without a restart. The speed last read is kept
+// when the drone cannot be read.
+func (l *Listener) refreshMaxSpeed(ctx context.Context, sys *system, now time.Time) {
+	p := &sys.plausibility
+	if now.Sub(p.maxSpeedLoadedAt) < maxSpeedRefresh {
+		return
+	}
+
+	drone, err := l.drones.GetDroneByID(ctx, int(sys.droneID))
+	if err != nil {
+		// Retry with the next refresh rather than with every update.
+		p.maxSpeedLoadedAt = now
+		if ctx.Err() == nil {
+			l.logger.Warn("failed to read the max speed of drone, keeping the last one", "drone", sys.droneID,
+				"maxSpeedMps", p.maxSpeed, "error", err)
+		}
+		return
+	}
+	p.setMaxSpeed(drone.MaxSpeedMps, now)
+}
+
+// earthRadius is the mean radius of the Earth in m.
+const earthRadius = 6371000.0
+
+// haversine returns the great circle distance in m between two positions in degrees.
+func haversine(lat1, lon1, lat2, lon2 float64) float64 {
+	toRadians := math.Pi / 180
+	dLat := (lat2 - lat1) * toRadians
+	dLon := (lon2 - lon1) * toRadians
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*toRadians)*math.Cos(lat2*toRadians)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(math.Min(a, 1)))
+}
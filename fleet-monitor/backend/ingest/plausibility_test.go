@@ -0,0 +1,209 @@
+package ingest
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"fleet-monitor/backend/db"
+	"fleet-monitor/backend/mavlink"
+)
+
+// metresPerDegree is the length of a degree of latitude, and of longitude at the
+// equator, for earthRadius.
+const metresPerDegree = earthRadius * math.Pi / 180
+
+// update is a GLOBAL_POSITION_INT received at an offset from the start of a test,
+// positioned in m north and east of 47°N 8°E.
+type update struct {
+	at     time.Duration
+	bootMs uint32
+	north  float64
+	east   float64
+	alt    float64
+	// speed is the reported ground speed in m/s, to the north.
+	speed float64
+	// fix is the GPS_RAW_INT fix type, 3 unless set.
+	fix        int
+	nullIsland bool
+
+	// want is "ok", "quarantined", "recovered" or "drop".
+	want    string
+	reasons []db.AnomalyReason
+}
+
+func (u update) message() *mavlink.GlobalPosition {
+	m := &mavlink.GlobalPosition{
+		TimeBootMs:  u.bootMs,
+		RelativeAlt: int32(u.alt * 1000),
+		VX:          int16(u.speed * 100),
+	}
+	if !u.nullIsland {
+		m.Lat = int32((47 + u.north/metresPerDegree) * 1e7)
+		m.Lon = int32((8 + u.east/(metresPerDegree*math.Cos(47*math.Pi/180))) * 1e7)
+	}
+	return m
+}
+
+// steady is n updates a second apart from at, flying north at 10 m/s from north.
+func steady(n int, at time.Duration, north float64, want string) []update {
+	updates := make([]update, n)
+	for i := range updates {
+		t := at + time.Duration(i)*time.Second
+		updates[i] = update{at: t, bootMs: uint32(t / time.Millisecond), north: north + 10*float64(i), alt: 50, speed: 10, want: want}
+	}
+	return updates
+}
+
+func TestPlausibilityCheck(t *testing.T) {
+	jump := []db.AnomalyReason{db.AnomalyPositionJump, db.AnomalyVelocityMismatch}
+
+	tests := []struct {
+		name    string
+		updates []update
+	}{
+		{
+			name:    "steady flight",
+			updates: steady(5, 0, 0, "ok"),
+		},
+		{
+			name: "no position yet",
+			updates: []update{
+				{nullIsland: true, fix: 1, want: "drop"},
+				{at: time.Second, bootMs: 1000, alt: 50, want: "ok"},
+			},
+		},
+		{
+			name: "null island with a fix",
+			updates: []update{
+				{bootMs: 0, alt: 50, want: "ok"},
+				{at: time.Second, bootMs: 1000, nullIsland: true, want: "quarantined", reasons: []db.AnomalyReason{db.AnomalyNullIsland}},
+				// The reference is still the first update.
+				{at: 2 * time.Second, bootMs: 2000, north: 20, alt: 50, speed: 10, want: "ok"},
+			},
+		},
+		{
+			name: "position jump",
+			updates: []update{
+				{alt: 50, want: "ok"},
+				{at: time.Second, bootMs: 1000, north: 1000, alt: 50, want: "quarantined", reasons: jump},
+			},
+		},
+		{
+			name: "altitude spike",
+			updates: []update{
+				{alt: 50, want: "ok"},
+				{at: time.Second, bootMs: 1000, alt: 80, want: "quarantined", reasons: []db.AnomalyReason{db.AnomalyAltitudeSpike}},
+				{at: 2 * time.Second, bootMs: 2000, alt: 70, want: "ok"},
+			},
+		},
+		{
+			name: "velocity mismatch alone",
+			updates: []update{
+				{alt: 50, want: "ok"},
+				{at: time.Second, bootMs: 1000, north: 10, alt: 50, speed: 30, want: "ok", reasons: []db.AnomalyReason{db.AnomalyVelocityMismatch}},
+			},
+		},
+		{
+			// Radios deliver frames in bursts; the boot clock says how far apart they are.
+			name: "timed by the boot clock",
+			updates: []update{
+				{alt: 50, want: "ok"},
+				{at: 100 * time.Millisecond, bootMs: 2000, north: 80, alt: 50, speed: 40, want: "ok"},
+			},
+		},
+		{
+			name: "reboot",
+			updates: []update{
+				{bootMs: 600000, alt: 50, want: "ok"},
+				// The boot clock started again, so the arrival times count.
+				{at: 3 * time.Second, bootMs: 500, north: 100, alt: 50, speed: 33, want: "ok"},
+				{at: 3500 * time.Millisecond, bootMs: 1000, north: 200, alt: 50, want: "quarantined", reasons: jump},
+			},
+		},
+		{
+			// A spoofed position must not become the reference just by waiting.
+			name: "spoofed jump held",
+			updates: []update{
+				{alt: 50, want: "ok"},
+				{at: time.Second, bootMs: 1000, north: 5000, alt: 50, want: "quarantined", reasons: jump},
+				{at: 30 * time.Second, bootMs: 30000, north: 5000, alt: 50, want: "quarantined", reasons: jump},
+			},
+		},
+		{
+			name: "recovery after consistent updates",
+			updates: append(append(append(
+				[]update{{alt: 50, want: "ok"}},
+				steady(recoverySamples-1, time.Second, 5000, "quarantined")...),
+				steady(1, recoverySamples*time.Second, 5000+10*float64(recoverySamples-1), "recovered")...),
+				steady(2, (recoverySamples+1)*time.Second, 5000+10*float64(recoverySamples), "ok")...),
+		},
+		{
+			name: "no recovery from inconsistent updates",
+			updates: func() []update {
+				updates := []update{{alt: 50, want: "ok"}}
+				for i := 1; i <= 2*recoverySamples; i++ {
+					north := 5000.0
+					if i%2 == 0 {
+						north = -5000
+					}
+					at := time.Duration(i) * time.Second
+					updates = append(updates, update{at: at, bootMs: uint32(at / time.Millisecond), north: north, alt: 50, want: "quarantined"})
+				}
+				return updates
+			}(),
+		},
+	}
+
+	start := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newPlausibility(0, start)
+			for i, u := range tt.updates {
+				fix := u.fix
+				if fix == 0 {
+					fix = 3
+				}
+				v := p.check(u.message(), fix, start.Add(u.at))
+
+				got := "ok"
+				switch {
+				case v.drop:
+					got = "drop"
+				case v.recovered:
+					got = "recovered"
+				case v.quarantined():
+					got = "quarantined"
+				}
+				if got != u.want {
+					t.Fatalf("update %d is %s, want %s: %v", i, got, u.want, v.details)
+				}
+				if u.reasons != nil && !reflect.DeepEqual(v.reasons, u.reasons) {
+					t.Errorf("update %d failed %v, want %v: %v", i, v.reasons, u.reasons, v.details)
+				}
+				if u.want == "ok" && u.reasons == nil && len(v.reasons) > 0 {
+					t.Errorf("update %d failed %v: %v", i, v.reasons, v.details)
+				}
+			}
+		})
+	}
+}
+
+func TestPlausibilityMaxSpeed(t *testing.T) {
+	start := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	first := update{alt: 50}
+	fast := update{at: time.Second, bootMs: 1000, north: 30, alt: 50, speed: 30}
+
+	slow := newPlausibility(15, start)
+	slow.check(first.message(), 3, start)
+	if v := slow.check(fast.message(), 3, start.Add(fast.at)); !v.quarantined() {
+		t.Errorf("30 m/s for a 15 m/s drone was not quarantined")
+	}
+
+	unset := newPlausibility(0, start)
+	unset.check(first.message(), 3, start)
+	if v := unset.check(fast.message(), 3, start.Add(fast.at)); v.quarantined() {
+		t.Errorf("30 m/s under the default max speed was quarantined: %v", v.details)
+	}
+}
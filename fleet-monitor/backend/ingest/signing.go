@@ -0,0 +1,124 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"fleet-monitor/backend/db"
+	"fleet-monitor/backend/mavlink"
+	"fleet-monitor/backend/metrics"
+	"fleet-monitor/backend/service"
+)
+
+const (
+	// signingRefresh is how long the signing of a system is used before it is read
+	// again, so policy and key changes apply without a restart.
+	signingRefresh = 30 * time.Second
+	// alertInterval is how often frames of one system failing the same check raise
+	// a security alert. Every frame is counted in the metrics.
+	alertInterval = time.Minute
+)
+
+// signingState is the signing of a system as last read. signing is nil for systems
+// that are not registered as drones, which are not checked, and for drones that could
+// not be read. err is set if the drone or its key could not be read.
+type signingState struct {
+	signing  *service.DroneSigning
+	err      error
+	loadedAt time.Time
+}
+
+// alertKey identifies the alerts limited by alertInterval.
+type alertKey struct {
+	systemID uint8
+	kind     db.SecurityAlertKind
+}
+
+// verify checks the frame against the signing of the drone it claims to come from
+// and reports whether it may be handled. Frames failing a check raise a security
+// alert.
+func (l *Listener) verify(ctx context.Context, link string, frame *mavlink.Frame) bool {
+	state := l.signingOf(ctx, frame.SystemID)
+	signing := state.signing
+	if state.err != nil {
+		// Fail closed for drones that reject unsigned frames or whose policy is
+		// unknown. The others are handled unchecked until their key can be read.
+		return signing != nil && signing.Policy != db.SigningPolicyReject
+	}
+	if signing == nil {
+		return true
+	}
+
+	// Without a key, signed frames cannot be checked any better than unsigned ones.
+	status := mavlink.SignatureUnsigned
+	if signing.Key != nil {
+		status = l.verifier.Check(frame, *signing.Key)
+	}
+
+	switch {
+	case status == mavlink.SignatureValid:
+		return true
+	case status != mavlink.SignatureUnsigned:
+		l.alert(ctx, link, frame, signing, status, true)
+		return false
+	case signing.Policy == db.SigningPolicyReject:
+		l.alert(ctx, link, frame, signing, status, true)
+		return false
+	case signing.Policy == db.SigningPolicyFlag:
+		l.alert(ctx, link, frame, signing, status, false)
+	}
+	return true
+}
+
+// signingOf returns the signing of a system, reading it again after signingRefresh.
+// A signing that cannot be read is logged, and read again after signingRefresh too.
+func (l *Listener) signingOf(ctx context.Context, systemID uint8) *signingState {
+	state := l.signing[systemID]
+	if state != nil && time.Since(state.loadedAt) < signingRefresh {
+		return state
+	}
+
+	signing, err := l.security.GetSigningByMavlinkID(ctx, strconv.Itoa(int(systemID)))
+	if errors.Is(err, service.ErrNotFound) {
+		signing, err = nil, nil
+	}
+	if err != nil && ctx.Err() == nil {
+		dropped := signing == nil || signing.Policy == db.SigningPolicyReject
+		l.logger.Error("failed to read the signing of system", "system", systemID, "droppingFrames", dropped, "error", err)
+	}
+	state = &signingState{signing: signing, err: err, loadedAt: time.Now()}
+	l.signing[systemID] = state
+	return state
+}
+
+// alert counts a frame failing the signing checks and records a security alert, at
+// most one per system and kind every alertInterval.
+func (l *Listener) alert(ctx context.Context, link string, frame *mavlink.Frame, signing *service.DroneSigning, status mavlink.SignatureStatus, rejected bool) {
+	metrics.SignatureFailures.WithLabelValues(strconv.Itoa(int(frame.SystemID)), string(status)).Inc()
+
+	key := alertKey{systemID: frame.SystemID, kind: db.SecurityAlertKind(status)}
+	now := time.Now()
+	if last, ok := l.alerted[key]; ok && now.Sub(last) < alertInterval {
+		return
+	}
+	l.alerted[key] = now
+
+	message := mavlink.MessageName(frame.MessageID)
+	l.logger.Warn("security alert: MAVLink frame failed signing checks", "drone", signing.DroneID, "system", frame.SystemID,
+		"link", link, "message", message, "reason", status, "policy", signing.Policy, "rejected", rejected)
+
+	alert := &db.SecurityAlert{
+		CreatedAt: now.UTC(),
+		DroneID:   signing.DroneID,
+		SystemID:  int(frame.SystemID),
+		Link:      link,
+		Message:   message,
+		Kind:      db.SecurityAlertKind(status),
+		Rejected:  rejected,
+	}
+	if err := l.security.RecordAlert(ctx, alert); err != nil && ctx.Err() == nil {
+		l.logger.Error("failed to record security alert", "drone", signing.DroneID, "error", err)
+	}
+}
@@ -0,0 +1,241 @@
+package ingest
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"fleet-monitor/backend/db"
+	"fleet-monitor/backend/mavlink"
+	"fleet-monitor/backend/repository"
+	"fleet-monitor/backend/secrets"
+	"fleet-monitor/backend/service"
+	"fleet-monitor/backend/utils"
+)
+
+// heartbeat encodes a MAVLink 2 HEARTBEAT of an ArduPilot quadcopter from systemID,
+// signed with key on link 0 at timestamp unless key is nil.
+func heartbeat(systemID uint8, key *mavlink.SigningKey, timestamp uint64) []byte {
+	payload := []byte{0, 0, 0, 0, 2, 3, 0, 3, 3}
+	flags := byte(0)
+	if key != nil {
+		flags = 1
+	}
+	frame := append([]byte{0xFD, byte(len(payload)), flags, 0, 0, systemID, 1, 0, 0, 0}, payload...)
+
+	// X.25 checksum over everything after the start byte and the HEARTBEAT CRC_EXTRA.
+	crc := uint16(0xFFFF)
+	for _, b := range append(frame[1:], 50) {
+		tmp := b ^ byte(crc)
+		tmp ^= tmp << 4
+		crc = crc>>8 ^ uint16(tmp)<<8 ^ uint16(tmp)<<3 ^ uint16(tmp)>>4
+	}
+	frame = append(frame, byte(crc), byte(crc>>8))
+	if key == nil {
+		return frame
+	}
+
+	var stamp [8]byte
+	binary.LittleEndian.PutUint64(stamp[:], timestamp)
+	tail := append([]byte{0}, stamp[:6]...)
+	h := sha256.New()
+	h.Write(key[:])
+	h.Write(frame)
+	h.Write(tail)
+	return append(append(frame, tail...), h.Sum(nil)[:6]...)
+}
+
+// signingTest is a Listener on services backed by store, with one drone per
+// registered system.
+type signingTest struct {
+	listener *Listener
+	drones   service.DroneService
+	security service.SecurityService
+}
+
+func newSigningTest(t *testing.T, store repository.Store, systems ...string) *signingTest {
+	t.Helper()
+	ctx := context.Background()
+	s := &signingTest{drones: service.NewDroneService(store), security: service.NewSecurityService(store)}
+	s.listener = NewListener(s.drones, service.NewMaintenanceService(store), service.NewBatteryService(store), s.security,
+		utils.NewConsoleLogger(utils.LogPrefixMavlink))
+
+	user, err := service.NewUserService(store).CreateUser(ctx, service.UserDetails{UserName: "alice"})
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	for _, mavlinkID := range systems {
+		if _, err := s.drones.CreateDrone(ctx, service.DroneDetails{Name: "drone " + mavlinkID, MavlinkID: mavlinkID, OwnerID: int(user.ID)}); err != nil {
+			t.Fatalf("CreateDrone: %v", err)
+		}
+	}
+	return s
+}
+
+// listen feeds frames to the listener as one link.
+func (s *signingTest) listen(t *testing.T, frames ...[]byte) {
+	t.Helper()
+	err := s.listener.Listen(context.Background(), "test", bytes.NewReader(bytes.Join(frames, nil)))
+	if !errors.Is(err, io.EOF) {
+		t.Fatalf("Listen: %v", err)
+	}
+}
+
+// handled reports whether a heartbeat of the drone got through, which stores its
+// autopilot type.
+func (s *signingTest) handled(t *testing.T, droneID uint) bool {
+	t.Helper()
+	drone, err := s.drones.GetDroneByID(context.Background(), int(droneID))
+	if err != nil {
+		t.Fatalf("GetDroneByID: %v", err)
+	}
+	return drone.AutopilotType == "ardupilot"
+}
+
+func TestListenerSigning(t *testing.T) {
+	key, _ := mavlink.ParseSigningKey("correct horse battery staple")
+	other, _ := mavlink.ParseSigningKey("another passphrase")
+	const start = 123456789012
+
+	tests := []struct {
+		name        string
+		policy      db.SigningPolicy
+		key         *mavlink.SigningKey
+		frames      [][]byte
+		wantHandled bool
+		// wantAlerts are the kinds of the alerts raised, and wantRejected whether they
+		// were rejected.
+		wantAlerts   []db.SecurityAlertKind
+		wantRejected bool
+	}{
+		{
+			name:        "accept unsigned without a key",
+			policy:      db.SigningPolicyAccept,
+			frames:      [][]byte{heartbeat(1, nil, 0)},
+			wantHandled: true,
+		},
+		{
+			name:        "accept unsigned with a key",
+			policy:      db.SigningPolicyAccept,
+			key:         &key,
+			frames:      [][]byte{heartbeat(1, nil, 0)},
+			wantHandled: true,
+		},
+		{
+			name:         "accept rejects a bad signature",
+			policy:       db.SigningPolicyAccept,
+			key:          &key,
+			frames:       [][]byte{heartbeat(1, &other, start)},
+			wantAlerts:   []db.SecurityAlertKind{db.SecurityAlertKind(mavlink.SignatureInvalid)},
+			wantRejected: true,
+		},
+		{
+			name:        "flag unsigned",
+			policy:      db.SigningPolicyFlag,
+			key:         &key,
+			frames:      [][]byte{heartbeat(1, nil, 0), heartbeat(1, nil, 0)},
+			wantHandled: true,
+			// One alert stands for both frames.
+			wantAlerts: []db.SecurityAlertKind{db.SecurityAlertKind(mavlink.SignatureUnsigned)},
+		},
+		{
+			name:         "reject unsigned",
+			policy:       db.SigningPolicyReject,
+			key:          &key,
+			frames:       [][]byte{heartbeat(1, nil, 0)},
+			wantAlerts:   []db.SecurityAlertKind{db.SecurityAlertKind(mavlink.SignatureUnsigned)},
+			wantRejected: true,
+		},
+		{
+			name:        "reject accepts signed",
+			policy:      db.SigningPolicyReject,
+			key:         &key,
+			frames:      [][]byte{heartbeat(1, &key, start), heartbeat(1, &key, start+1)},
+			wantHandled: true,
+		},
+		{
+			name:         "reject replayed",
+			policy:       db.SigningPolicyReject,
+			key:          &key,
+			frames:       [][]byte{heartbeat(1, &key, start), heartbeat(1, &key, start)},
+			wantHandled:  true,
+			wantAlerts:   []db.SecurityAlertKind{db.SecurityAlertKind(mavlink.SignatureReplayed)},
+			wantRejected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := newSigningTest(t, repository.NewMemoryStore(), "1")
+			settings := service.SigningSettings{Policy: tt.policy}
+			if tt.key != nil {
+				hex := tt.key.String()
+				settings.Key = &hex
+			}
+			if _, err := s.security.SetSigning(ctx, 1, settings); err != nil {
+				t.Fatalf("SetSigning: %v", err)
+			}
+
+			s.listen(t, tt.frames...)
+
+			if handled := s.handled(t, 1); handled != tt.wantHandled {
+				t.Errorf("heartbeat handled = %v, want %v", handled, tt.wantHandled)
+			}
+			alerts, err := s.security.GetAlerts(ctx, 1, time.Time{}, 0)
+			if err != nil {
+				t.Fatalf("GetAlerts: %v", err)
+			}
+			if len(alerts) != len(tt.wantAlerts) {
+				t.Fatalf("alerts = %+v, want %v", alerts, tt.wantAlerts)
+			}
+			for i, alert := range alerts {
+				if alert.Kind != tt.wantAlerts[i] || alert.Rejected != tt.wantRejected || alert.SystemID != 1 || alert.Message != "HEARTBEAT" {
+					t.Errorf("alert %d = %+v, want %s rejected %v", i, alert, tt.wantAlerts[i], tt.wantRejected)
+				}
+			}
+		})
+	}
+}
+
+func TestListenerSigningWithoutMasterKey(t *testing.T) {
+	ctx := context.Background()
+	conn, err := db.OpenDB("sqlite://" + filepath.Join(t.TempDir(), "fleet.db"))
+	if err != nil {
+		t.Fatalf("OpenDB: %v", err)
+	}
+	box, err := secrets.NewBox("fleet-monitor test master key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	secrets.Use(box)
+	t.Cleanup(func() { secrets.Use(nil) })
+
+	s := newSigningTest(t, repository.NewGormStore(conn), "1", "2", "3")
+	key := "correct horse battery staple"
+	for id, policy := range map[uint]db.SigningPolicy{1: db.SigningPolicyReject, 2: db.SigningPolicyAccept} {
+		if _, err := s.security.SetSigning(ctx, id, service.SigningSettings{Policy: policy, Key: &key}); err != nil {
+			t.Fatalf("SetSigning(%d): %v", id, err)
+		}
+	}
+
+	// Restarted without the master key, the signing keys cannot be read.
+	secrets.Use(nil)
+	s.listen(t, heartbeat(1, nil, 0), heartbeat(2, nil, 0), heartbeat(3, nil, 0))
+
+	if s.handled(t, 1) {
+		t.Error("a drone rejecting unsigned frames got one through without its key")
+	}
+	if !s.handled(t, 2) {
+		t.Error("a drone accepting unsigned frames was cut off by its unreadable key")
+	}
+	if !s.handled(t, 3) {
+		t.Error("a drone without a key was cut off")
+	}
+}
@@ -0,0 +1,170 @@
+package ingest
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"fleet-monitor/backend/db"
+	"fleet-monitor/backend/mavlink"
+	"fleet-monitor/backend/service"
+)
+
+// telemetryInterval is how often a drone's live state is written to the database.
+// Autopilots stream position and attitude at several Hz, more than the history needs.
+const telemetryInterval = time.Second
+
+// liveState is the drone's state assembled from the telemetry messages received
+// since it connected.
+type liveState struct {
+	state   service.RealTimeState
+	changed bool
+	savedAt time.Time
+
+	// What the flight phase is derived from.
+	armed     bool
+	returning bool
+	landed    mavlink.LandedState
+}
+
+// update applies a telemetry message to the state. It reports false for messages
+// that carry no telemetry.
+func (s *liveState) update(message interface{}) bool {
+	st := &s.state
+	switch m := message.(type) {
+	case *mavlink.Heartbeat:
+		s.armed = m.Armed()
+		s.returning = m.ReturningHome()
+		st.HealthStatus = healthStatus(m.SystemStatus)
+		st.FlightPhase = s.phase()
+
+	case *mavlink.ExtendedSysState:
+		s.landed = m.LandedState
+		st.FlightPhase = s.phase()
+
+	case *mavlink.GlobalPosition:
+		st.GPS.Latitude = float64(m.Lat) / 1e7
+		st.GPS.Longitude = float64(m.Lon) / 1e7
+		st.Altitude = float64(m.RelativeAlt) / 1000
+		st.Velocity.X = float64(m.VX) / 100
+		st.Velocity.Y = float64(m.VY) / 100
+		st.Velocity.Z = float64(m.VZ) / 100
+		if heading := m.Heading(); heading >= 0 {
+			st.Heading = heading
+		}
+
+	case *mavlink.GPSRawInt:
+		st.GPS.FixType = int(m.FixType)
+		st.GPS.Satellites = int(math.Max(float64(m.Satellites()), 0))
+		st.GPS.HDOP = math.Max(m.HDOP(), 0)
+
+	case *mavlink.Attitude:
+		st.Attitude.Roll = degrees(m.Roll)
+		st.Attitude.Pitch = degrees(m.Pitch)
+		st.Attitude.Yaw = degrees(m.Yaw)
+
+	case *mavlink.VFRHUD:
+		st.Airspeed = float64(m.Airspeed)
+		st.GroundSpeed = float64(m.Groundspeed)
+		st.Heading = float64(m.Heading)
+
+	case *mavlink.RadioStatus:
+		st.Radio.RSSI = int(m.RSSI)
+		st.Radio.Noise = int(m.Noise)
+		st.Radio.RemoteRSSI = int(m.RemRSSI)
+		st.Radio.RemoteNoise = int(m.RemNoise)
+
+	case *mavlink.SysStatus:
+		if m.BatteryRemaining < 0 {
+			return false
+		}
+		st.Battery = int(m.BatteryRemaining)
+
+	default:
+		return false
+	}
+
+	s.changed = true
+	return true
+}
+
+// save writes the state of the system's drone unless it was written less than
+// telemetryInterval ago or nothing changed since.
+func (l *Listener) save(ctx context.Context, sys *system, now time.Time) error {
+	live := &sys.live
+	if !live.changed || now.Sub(live.savedAt) < telemetryInterval {
+		return nil
+	}
+	live.changed = false
+	live.savedAt = now
+
+	drone, err := l.drones.GetDroneByID(ctx, int(sys.droneID))
+	if err != nil {
+		return err
+	}
+
+	// Until the first heartbeat the stored status and phase are kept.
+	state := live.state
+	if state.HealthStatus == "" {
+		state.HealthStatus = drone.HealthStatus
+	}
+	if state.FlightPhase == "" {
+		state.FlightPhase = drone.FlightPhase
+	}
+	return l.drones.UpdateDroneRealTime(ctx, drone, state)
+}
+
+// phase derives the flight phase from the armed flag and custom mode of the heartbeat
+// and the landed state of EXTENDED_SYS_STATE. Without a landed state, an armed drone
+// is only known to be returning when it is in its return to launch mode.
+func (s *liveState) phase() db.FlightPhase {
+	if !s.armed {
+		return db.FlightPhaseOnGround
+	}
+
+	switch s.landed {
+	case mavlink.LandedStateOnGround:
+		return db.FlightPhaseArmed
+	case mavlink.LandedStateTakeoff:
+		return db.FlightPhaseTakingOff
+	case mavlink.LandedStateLanding:
+		return db.FlightPhaseLanding
+	}
+
+	switch {
+	case s.returning:
+		return db.FlightPhaseReturning
+	case s.landed == mavlink.LandedStateInAir:
+		return db.FlightPhaseAirborne
+	}
+	return db.FlightPhaseArmed
+}
+
+// healthStatus maps the MAV_STATE of a heartbeat to the drone's health.
+func healthStatus(state uint8) db.HealthStatus {
+	switch state {
+	case mavlink.StateCritical, mavlink.StateEmergency, mavlink.StateFlightTermination:
+		return db.HealthStatusDamaged
+	case mavlink.StatePoweroff:
+		return db.HealthStatusOffline
+	}
+	return db.HealthStatusStable
+}
+
+// radioSystem returns the drone a RADIO_STATUS from systemID is about. SiK radios
+// send it with their own system ID, so on a link with a single drone it is that one.
+func (l *Listener) radioSystem(systemID uint8) *system {
+	if sys := l.systems[systemID]; sys != nil {
+		return sys
+	}
+	if len(l.systems) == 1 {
+		for _, sys := range l.systems {
+			return sys
+		}
+	}
+	return nil
+}
+
+func degrees(radians float32) float64 {
+	return float64(radians) * 180 / math.Pi
+}
@@ -0,0 +1,248 @@
+// Package mavlink reads MAVLink v1 and v2 frames from a telemetry link and decodes
+// the messages fleet-monitor uses. It only listens; nothing is ever sent to a drone.
+package mavlink
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	magicV1 = 0xFE
+	magicV2 = 0xFD
+
+	// incompatFlagSigned marks a MAVLink v2 frame followed by a 13 byte signature.
+	incompatFlagSigned = 0x01
+	signatureLen       = 13
+)
+
+// Frame is a MAVLink packet whose checksum has been verified.
+type Frame struct {
+	Version     int
+	Sequence    uint8
+	SystemID    uint8
+	ComponentID uint8
+	MessageID   uint32
+	Payload     []byte
+	// Signature is the raw signature of a signed MAVLink v2 frame, nil otherwise.
+	Signature []byte
+
+	// signed holds the bytes covered by the signature, from the start byte to the
+	// checksum.
+	signed []byte
+}
+
+// ErrUnsupportedMessage is returned by Decode for message IDs this package does not know.
+var ErrUnsupportedMessage = errors.New("mavlink: unsupported message")
+
+// Reader extracts frames from a byte stream such as a serial port or UDP socket.
+// Bytes that do not belong to a valid frame of a known message are skipped.
+type Reader struct {
+	r            *bufio.Reader
+	badChecksums uint64
+}
+
+// NewReader creates a Reader on top of r.
+// Example
+// reader := mavlink.NewReader(port)
+// frame, err := reader.ReadFrame()
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReaderSize(r, 4096)}
+}
+
+// ReadFrame returns the next frame of a known message whose checksum matches.
+// It only returns an error when the underlying reader does.
+func (r *Reader) ReadFrame() (*Frame, error) {
+	for {
+		magic, err := r.r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+
+		var frame *Frame
+		switch magic {
+		case magicV1:
+			frame, err = r.readV1()
+		case magicV2:
+			frame, err = r.readV2()
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if frame != nil {
+			return frame, nil
+		}
+	}
+}
+
+// BadChecksums returns how many frames of known messages were dropped so far because
+// their checksum did not match, a sign of a noisy link. A start byte found inside
+// a corrupted frame can count again.
+func (r *Reader) BadChecksums() uint64 {
+	return r.badChecksums
+}
+
+// readV1 reads the frame following a v1 start byte. It returns a nil frame, leaving
+// the bytes after the start byte unread, when they do not hold a valid frame.
+func (r *Reader) readV1() (*Frame, error) {
+	length, err := r.peekLength()
+	if err != nil || length < 0 {
+		return nil, err
+	}
+
+	// len, seq, sysid, compid, msgid, payload, checksum
+	raw, err := r.r.Peek(5 + length + 2)
+	if err != nil {
+		return nil, skipAtEOF(err)
+	}
+
+	messageID := uint32(raw[4])
+	header, payload := raw[:5], raw[5:5+length]
+	if !r.checksumMatches(messageID, header, payload, raw[5+length:]) {
+		return nil, nil
+	}
+
+	frame := &Frame{
+		Version:     1,
+		Sequence:    raw[1],
+		SystemID:    raw[2],
+		ComponentID: raw[3],
+		MessageID:   messageID,
+		Payload:     append([]byte(nil), payload...),
+	}
+	_, err = r.r.Discard(len(raw))
+	return frame, err
+}
+
+// readV2 reads the frame following a v2 start byte, see readV1.
+func (r *Reader) readV2() (*Frame, error) {
+	length, err := r.peekLength()
+	if err != nil || length < 0 {
+		return nil, err
+	}
+
+	// len, incompat flags, compat flags, seq, sysid, compid, msgid (3 bytes), payload, checksum
+	raw, err := r.r.Peek(9 + length + 2)
+	if err != nil {
+		return nil, skipAtEOF(err)
+	}
+
+	messageID := uint32(raw[6]) | uint32(raw[7])<<8 | uint32(raw[8])<<16
+	header, payload := raw[:9], raw[9:9+length]
+	if !r.checksumMatches(messageID, header, payload, raw[9+length:]) {
+		return nil, nil
+	}
+
+	frame := &Frame{
+		Version:     2,
+		Sequence:    raw[3],
+		SystemID:    raw[4],
+		ComponentID: raw[5],
+		MessageID:   messageID,
+		Payload:     append([]byte(nil), payload...),
+	}
+	signed := raw[1]&incompatFlagSigned != 0
+	if signed {
+		frame.signed = append([]byte{magicV2}, raw...)
+	}
+	if _, err := r.r.Discard(len(raw)); err != nil {
+		return nil, err
+	}
+
+	if signed {
+		signature := make([]byte, signatureLen)
+		if _, err := io.ReadFull(r.r, signature); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		frame.Signature = signature
+	}
+
+	return frame, nil
+}
+
+// peekLength returns the payload length following a start byte, or -1 if the stream
+// ends right after the start byte.
+func (r *Reader) peekLength() (int, error) {
+	b, err := r.r.Peek(1)
+	if err != nil {
+		return -1, skipAtEOF(err)
+	}
+	return int(b[0]), nil
+}
+
+// checksumMatches verifies the X.25 checksum of a frame, which covers every byte after
+// the start byte plus the CRC_EXTRA seed of the message. Unknown messages never match.
+func (r *Reader) checksumMatches(messageID uint32, header, payload, checksum []byte) bool {
+	def, ok := messages[messageID]
+	if !ok {
+		return false
+	}
+
+	crc := newCRC()
+	crc.write(header)
+	crc.write(payload)
+	crc.writeByte(def.crcExtra)
+	if uint16(crc) != binary.LittleEndian.Uint16(checksum) {
+		r.badChecksums++
+		return false
+	}
+	return true
+}
+
+// skipAtEOF ignores a stream that ends within what would be a frame, since the start
+// byte may have been part of something else. ReadFrame then scans the remaining bytes.
+func skipAtEOF(err error) error {
+	if err == io.EOF {
+		return nil
+	}
+	return err
+}
+
+// unexpectedEOF reports a stream that ended in the middle of a frame.
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// Decode decodes the frame's payload into the message type registered for its ID,
+// e.g. *Heartbeat or *AutopilotVersion.
+func (f *Frame) Decode() (interface{}, error) {
+	def, ok := messages[f.MessageID]
+	if !ok {
+		return nil, fmt.Errorf("%w %d", ErrUnsupportedMessage, f.MessageID)
+	}
+
+	// MAVLink v2 trims trailing zero bytes from the payload, so pad it back to
+	// the full message length before decoding.
+	payload := f.Payload
+	if len(payload) < def.length {
+		payload = append(append(make([]byte, 0, def.length), payload...), make([]byte, def.length-len(payload))...)
+	}
+	return def.decode(payload), nil
+}
+
+// crc is the X.25 (CRC-16/MCRF4XX) checksum used by MAVLink.
+type crc uint16
+
+func newCRC() crc {
+	return 0xFFFF
+}
+
+func (c *crc) writeByte(b byte) {
+	tmp := b ^ byte(*c&0xFF)
+	tmp ^= tmp << 4
+	*c = crc(uint16(*c)>>8 ^ uint16(tmp)<<8 ^ uint16(tmp)<<3 ^ uint16(tmp)>>4)
+}
+
+func (c *crc) write(p []byte) {
+	for _, b := range p {
+		c.writeByte(b)
+	}
+}
@@ -0,0 +1,140 @@
+package mavlink
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"io"
+	"testing"
+)
+
+// Known answers computed outside this package with Python's hashlib and the X.25
+// checksum of the MAVLink specification: a HEARTBEAT of an armed ArduPilot
+// quadcopter, sequence 3 from system 1 in MAVLink 1 and sequence 7 in MAVLink 2,
+// the latter signed on link 2 at timestamp 123456789012 with the key derived from
+// "correct horse battery staple".
+const (
+	katHeartbeatV1     = "fe0903010100040000000203810403a716"
+	katHeartbeatSigned = "fd090100070101000000040000000203810403a71c" + "02141a99be1c00" + "e93ce47679bc"
+	katPassphrase      = "correct horse battery staple"
+	katKey             = "c4bbcb1fbec99d65bf59d85c8cb62ee2db963f0fe106f483d9afa73bd4e39a8a"
+)
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// encode builds a frame of a known message with a valid checksum.
+func encode(version int, seq, systemID uint8, messageID uint32, payload []byte) []byte {
+	var frame []byte
+	if version == 1 {
+		frame = []byte{magicV1, byte(len(payload)), seq, systemID, 1, byte(messageID)}
+	} else {
+		frame = []byte{magicV2, byte(len(payload)), 0, 0, seq, systemID, 1, byte(messageID), byte(messageID >> 8), byte(messageID >> 16)}
+	}
+	frame = append(frame, payload...)
+	return appendChecksum(frame, messageID)
+}
+
+// appendChecksum appends the checksum of frame, which must not have one yet.
+func appendChecksum(frame []byte, messageID uint32) []byte {
+	crc := newCRC()
+	crc.write(frame[1:])
+	crc.writeByte(messages[messageID].crcExtra)
+	return append(frame, byte(crc), byte(crc>>8))
+}
+
+// readAll returns the frames read from stream and the error that ended the reading.
+func readAll(stream []byte) ([]*Frame, uint64, error) {
+	reader := NewReader(bytes.NewReader(stream))
+	var frames []*Frame
+	for {
+		frame, err := reader.ReadFrame()
+		if err != nil {
+			return frames, reader.BadChecksums(), err
+		}
+		frames = append(frames, frame)
+	}
+}
+
+func TestReadFrame(t *testing.T) {
+	v1 := mustHex(t, katHeartbeatV1)
+	signed := mustHex(t, katHeartbeatSigned)
+	// VFR_HUD with its trailing zero bytes trimmed, as MAVLink 2 sends it.
+	trimmed := encode(2, 9, 4, MessageIDVFRHUD, []byte{0, 0, 0x80, 0x3f})
+	unknown := encode(2, 1, 1, 12345, []byte{1, 2, 3})
+	corrupted := append([]byte(nil), v1...)
+	corrupted[8]++
+
+	tests := []struct {
+		name         string
+		stream       []byte
+		wantSystems  []uint8
+		wantBadCount uint64
+		wantErr      error
+	}{
+		{name: "v1", stream: v1, wantSystems: []uint8{1}, wantErr: io.EOF},
+		{name: "v2 signed", stream: signed, wantSystems: []uint8{1}, wantErr: io.EOF},
+		{name: "v2 trimmed payload", stream: trimmed, wantSystems: []uint8{4}, wantErr: io.EOF},
+		{name: "garbage around frames", stream: bytes.Join([][]byte{{0x00, 0xFD, 0xFE}, v1, {0x55, 0xAA}, trimmed, {0xFE}}, nil),
+			wantSystems: []uint8{1, 4}, wantErr: io.EOF},
+		{name: "bad checksum", stream: append(corrupted, trimmed...), wantSystems: []uint8{4}, wantBadCount: 1, wantErr: io.EOF},
+		{name: "unknown message", stream: append(unknown, v1...), wantSystems: []uint8{1}, wantErr: io.EOF},
+		{name: "truncated frame", stream: append(trimmed, v1[:10]...), wantSystems: []uint8{4}, wantErr: io.EOF},
+		{name: "truncated signature", stream: signed[:len(signed)-3], wantErr: io.ErrUnexpectedEOF},
+		{name: "empty", wantErr: io.EOF},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frames, bad, err := readAll(tt.stream)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+			if len(frames) != len(tt.wantSystems) {
+				t.Fatalf("read %d frames, want %d", len(frames), len(tt.wantSystems))
+			}
+			for i, frame := range frames {
+				if frame.SystemID != tt.wantSystems[i] {
+					t.Errorf("frame %d from system %d, want %d", i, frame.SystemID, tt.wantSystems[i])
+				}
+			}
+			if bad != tt.wantBadCount {
+				t.Errorf("bad checksums = %d, want %d", bad, tt.wantBadCount)
+			}
+		})
+	}
+}
+
+func TestDecodeHeartbeat(t *testing.T) {
+	for _, kat := range []string{katHeartbeatV1, katHeartbeatSigned} {
+		frames, _, _ := readAll(mustHex(t, kat))
+		if len(frames) != 1 {
+			t.Fatalf("%s: read %d frames", kat, len(frames))
+		}
+		message, err := frames[0].Decode()
+		if err != nil {
+			t.Fatalf("Decode: %v", err)
+		}
+		heartbeat, ok := message.(*Heartbeat)
+		if !ok || heartbeat.CustomMode != 4 || heartbeat.Type != 2 || heartbeat.Autopilot != AutopilotArduPilotMega ||
+			!heartbeat.Armed() || heartbeat.SystemStatus != StateActive {
+			t.Errorf("heartbeat = %+v", message)
+		}
+	}
+
+	frames, _, _ := readAll(encode(2, 0, 1, MessageIDVFRHUD, []byte{0, 0, 0x80, 0x3f}))
+	message, err := frames[0].Decode()
+	if hud, ok := message.(*VFRHUD); err != nil || !ok || hud.Airspeed != 1 || hud.Climb != 0 {
+		t.Errorf("trimmed VFR_HUD = %+v, %v", message, err)
+	}
+
+	if _, err := (&Frame{MessageID: 12345}).Decode(); !errors.Is(err, ErrUnsupportedMessage) {
+		t.Errorf("Decode of an unknown message: %v", err)
+	}
+}
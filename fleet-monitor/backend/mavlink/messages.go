@@ -0,0 +1,491 @@
+package mavlink
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Message IDs of the messages this package decodes.
+const (
+	MessageIDHeartbeat        uint32 = 0
+	MessageIDSysStatus        uint32 = 1
+	MessageIDGPSRawInt        uint32 = 24
+	MessageIDAttitude         uint32 = 30
+	MessageIDGlobalPosition   uint32 = 33
+	MessageIDVFRHUD           uint32 = 74
+	MessageIDRadioStatus      uint32 = 109
+	MessageIDBatteryStatus    uint32 = 147
+	MessageIDAutopilotVersion uint32 = 148
+	MessageIDExtendedSysState uint32 = 245
+)
+
+// messageDef describes how to check and decode a message. length is the size of the
+// payload without MAVLink v2 extension fields.
+type messageDef struct {
+	name     string
+	crcExtra byte
+	length   int
+	decode   func(payload []byte) interface{}
+}
+
+var messages = map[uint32]messageDef{
+	MessageIDHeartbeat:        {name: "HEARTBEAT", crcExtra: 50, length: 9, decode: decodeHeartbeat},
+	MessageIDSysStatus:        {name: "SYS_STATUS", crcExtra: 124, length: 31, decode: decodeSysStatus},
+	MessageIDGPSRawInt:        {name: "GPS_RAW_INT", crcExtra: 24, length: 30, decode: decodeGPSRawInt},
+	MessageIDAttitude:         {name: "ATTITUDE", crcExtra: 39, length: 28, decode: decodeAttitude},
+	MessageIDGlobalPosition:   {name: "GLOBAL_POSITION_INT", crcExtra: 104, length: 28, decode: decodeGlobalPosition},
+	MessageIDVFRHUD:           {name: "VFR_HUD", crcExtra: 20, length: 20, decode: decodeVFRHUD},
+	MessageIDRadioStatus:      {name: "RADIO_STATUS", crcExtra: 185, length: 9, decode: decodeRadioStatus},
+	MessageIDBatteryStatus:    {name: "BATTERY_STATUS", crcExtra: 154, length: 36, decode: decodeBatteryStatus},
+	MessageIDAutopilotVersion: {name: "AUTOPILOT_VERSION", crcExtra: 178, length: 60, decode: decodeAutopilotVersion},
+	MessageIDExtendedSysState: {name: "EXTENDED_SYS_STATE", crcExtra: 130, length: 2, decode: decodeExtendedSysState},
+}
+
+// MessageName returns the name of a message in the MAVLink common set, e.g.
+// "HEARTBEAT", or its ID for messages this package does not know.
+func MessageName(messageID uint32) string {
+	if def, ok := messages[messageID]; ok {
+		return def.name
+	}
+	return strconv.FormatUint(uint64(messageID), 10)
+}
+
+// Heartbeat is the HEARTBEAT message every MAVLink component sends about once a second.
+type Heartbeat struct {
+	CustomMode     uint32
+	Type           uint8
+	Autopilot      Autopilot
+	BaseMode       uint8
+	SystemStatus   uint8
+	MavlinkVersion uint8
+}
+
+// modeFlagSafetyArmed is the MAV_MODE_FLAG bit of BaseMode set while the motors are armed.
+const modeFlagSafetyArmed = 0x80
+
+// Armed reports whether the vehicle's motors are armed.
+func (m *Heartbeat) Armed() bool {
+	return m.BaseMode&modeFlagSafetyArmed != 0
+}
+
+// MAV_STATE values of Heartbeat.SystemStatus.
+const (
+	StateUninit            uint8 = 0
+	StateBoot              uint8 = 1
+	StateCalibrating       uint8 = 2
+	StateStandby           uint8 = 3
+	StateActive            uint8 = 4
+	StateCritical          uint8 = 5
+	StateEmergency         uint8 = 6
+	StatePoweroff          uint8 = 7
+	StateFlightTermination uint8 = 8
+)
+
+// MAV_TYPE values of Heartbeat.Type that change how the custom mode is read.
+const (
+	TypeFixedWing   uint8 = 1
+	TypeGroundRover uint8 = 10
+	TypeSurfaceBoat uint8 = 11
+)
+
+// ReturningHome reports whether the autopilot is in a return to launch mode. Only
+// ArduPilot and PX4 custom modes are known; other autopilots never return true.
+func (m *Heartbeat) ReturningHome() bool {
+	switch m.Autopilot {
+	case AutopilotArduPilotMega:
+		switch {
+		case m.Type == TypeFixedWing || (m.Type >= 19 && m.Type <= 25): // plane and VTOL: RTL, QRTL
+			return m.CustomMode == 11 || m.CustomMode == 21
+		case m.Type == TypeGroundRover || m.Type == TypeSurfaceBoat: // RTL, SMART_RTL
+			return m.CustomMode == 11 || m.CustomMode == 12
+		default: // copter: RTL, SMART_RTL
+			return m.CustomMode == 6 || m.CustomMode == 21
+		}
+	case AutopilotPX4:
+		// The main mode is in the third byte and the sub mode in the fourth: AUTO and RTL.
+		return m.CustomMode>>16&0xFF == 4 && m.CustomMode>>24 == 5
+	}
+	return false
+}
+
+func decodeHeartbeat(p []byte) interface{} {
+	return &Heartbeat{
+		CustomMode:     binary.LittleEndian.Uint32(p[0:]),
+		Type:           p[4],
+		Autopilot:      Autopilot(p[5]),
+		BaseMode:       p[6],
+		SystemStatus:   p[7],
+		MavlinkVersion: p[8],
+	}
+}
+
+// SysStatus is the SYS_STATUS message with the general system state, including the
+// main battery. Unknown battery values are UINT16_MAX volts and -1 current or remaining.
+type SysStatus struct {
+	SensorsPresent   uint32
+	SensorsEnabled   uint32
+	SensorsHealth    uint32
+	Load             uint16
+	VoltageBattery   uint16 // mV
+	CurrentBattery   int16  // cA
+	DropRateComm     uint16
+	ErrorsComm       uint16
+	ErrorsCount      [4]uint16
+	BatteryRemaining int8 // %
+}
+
+func decodeSysStatus(p []byte) interface{} {
+	m := &SysStatus{
+		SensorsPresent:   binary.LittleEndian.Uint32(p[0:]),
+		SensorsEnabled:   binary.LittleEndian.Uint32(p[4:]),
+		SensorsHealth:    binary.LittleEndian.Uint32(p[8:]),
+		Load:             binary.LittleEndian.Uint16(p[12:]),
+		VoltageBattery:   binary.LittleEndian.Uint16(p[14:]),
+		CurrentBattery:   int16(binary.LittleEndian.Uint16(p[16:])),
+		DropRateComm:     binary.LittleEndian.Uint16(p[18:]),
+		ErrorsComm:       binary.LittleEndian.Uint16(p[20:]),
+		BatteryRemaining: int8(p[30]),
+	}
+	for i := range m.ErrorsCount {
+		m.ErrorsCount[i] = binary.LittleEndian.Uint16(p[22+2*i:])
+	}
+	return m
+}
+
+// Volts returns the battery voltage, or -1 if it is unknown.
+func (m *SysStatus) Volts() float64 {
+	if m.VoltageBattery == 0xFFFF {
+		return -1
+	}
+	return float64(m.VoltageBattery) / 1000
+}
+
+// Amps returns the battery current, or -1 if it is unknown.
+func (m *SysStatus) Amps() float64 {
+	if m.CurrentBattery < 0 {
+		return -1
+	}
+	return float64(m.CurrentBattery) / 100
+}
+
+// GPSRawInt is the GPS_RAW_INT message with the raw GPS fix. Unknown values are
+// UINT16_MAX, or 255 for the satellites.
+type GPSRawInt struct {
+	TimeUsec          uint64
+	Lat               int32  // degE7
+	Lon               int32  // degE7
+	Alt               int32  // mm above MSL
+	EPH               uint16 // HDOP * 100
+	EPV               uint16 // VDOP * 100
+	Vel               uint16 // cm/s
+	COG               uint16 // cdeg
+	FixType           uint8
+	SatellitesVisible uint8
+}
+
+func decodeGPSRawInt(p []byte) interface{} {
+	return &GPSRawInt{
+		TimeUsec:          binary.LittleEndian.Uint64(p[0:]),
+		Lat:               int32(binary.LittleEndian.Uint32(p[8:])),
+		Lon:               int32(binary.LittleEndian.Uint32(p[12:])),
+		Alt:               int32(binary.LittleEndian.Uint32(p[16:])),
+		EPH:               binary.LittleEndian.Uint16(p[20:]),
+		EPV:               binary.LittleEndian.Uint16(p[22:]),
+		Vel:               binary.LittleEndian.Uint16(p[24:]),
+		COG:               binary.LittleEndian.Uint16(p[26:]),
+		FixType:           p[28],
+		SatellitesVisible: p[29],
+	}
+}
+
+// HDOP returns the horizontal dilution of precision, or -1 if it is unknown.
+func (m *GPSRawInt) HDOP() float64 {
+	if m.EPH == 0xFFFF {
+		return -1
+	}
+	return float64(m.EPH) / 100
+}
+
+// Satellites returns the number of satellites visible, or -1 if it is unknown.
+func (m *GPSRawInt) Satellites() int {
+	if m.SatellitesVisible == 0xFF {
+		return -1
+	}
+	return int(m.SatellitesVisible)
+}
+
+// Attitude is the ATTITUDE message with the vehicle's orientation in radians.
+type Attitude struct {
+	TimeBootMs uint32
+	Roll       float32
+	Pitch      float32
+	Yaw        float32
+	RollSpeed  float32
+	PitchSpeed float32
+	YawSpeed   float32
+}
+
+func decodeAttitude(p []byte) interface{} {
+	return &Attitude{
+		TimeBootMs: binary.LittleEndian.Uint32(p[0:]),
+		Roll:       float32At(p, 4),
+		Pitch:      float32At(p, 8),
+		Yaw:        float32At(p, 12),
+		RollSpeed:  float32At(p, 16),
+		PitchSpeed: float32At(p, 20),
+		YawSpeed:   float32At(p, 24),
+	}
+}
+
+// GlobalPosition is the GLOBAL_POSITION_INT message with the filtered position
+// estimate. Velocities are north, east and down.
+type GlobalPosition struct {
+	TimeBootMs  uint32
+	Lat         int32  // degE7
+	Lon         int32  // degE7
+	Alt         int32  // mm above MSL
+	RelativeAlt int32  // mm above home
+	VX          int16  // cm/s
+	VY          int16  // cm/s
+	VZ          int16  // cm/s
+	Hdg         uint16 // cdeg, UINT16_MAX if unknown
+}
+
+func decodeGlobalPosition(p []byte) interface{} {
+	return &GlobalPosition{
+		TimeBootMs:  binary.LittleEndian.Uint32(p[0:]),
+		Lat:         int32(binary.LittleEndian.Uint32(p[4:])),
+		Lon:         int32(binary.LittleEndian.Uint32(p[8:])),
+		Alt:         int32(binary.LittleEndian.Uint32(p[12:])),
+		RelativeAlt: int32(binary.LittleEndian.Uint32(p[16:])),
+		VX:          int16(binary.LittleEndian.Uint16(p[20:])),
+		VY:          int16(binary.LittleEndian.Uint16(p[22:])),
+		VZ:          int16(binary.LittleEndian.Uint16(p[24:])),
+		Hdg:         binary.LittleEndian.Uint16(p[26:]),
+	}
+}
+
+// Heading returns the heading in degrees, or -1 if it is unknown.
+func (m *GlobalPosition) Heading() float64 {
+	if m.Hdg == 0xFFFF {
+		return -1
+	}
+	return float64(m.Hdg) / 100
+}
+
+// VFRHUD is the VFR_HUD message with the metrics shown on a fixed-wing HUD.
+type VFRHUD struct {
+	Airspeed    float32 // m/s
+	Groundspeed float32 // m/s
+	Alt         float32 // m above MSL
+	Climb       float32 // m/s
+	Heading     int16   // deg
+	Throttle    uint16  // %
+}
+
+func decodeVFRHUD(p []byte) interface{} {
+	return &VFRHUD{
+		Airspeed:    float32At(p, 0),
+		Groundspeed: float32At(p, 4),
+		Alt:         float32At(p, 8),
+		Climb:       float32At(p, 12),
+		Heading:     int16(binary.LittleEndian.Uint16(p[16:])),
+		Throttle:    binary.LittleEndian.Uint16(p[18:]),
+	}
+}
+
+// RadioStatus is the RADIO_STATUS message a telemetry radio sends about the link.
+// Signal and noise are in the radio's own units, 255 if unknown.
+type RadioStatus struct {
+	RxErrors uint16
+	Fixed    uint16
+	RSSI     uint8
+	RemRSSI  uint8
+	TxBuf    uint8
+	Noise    uint8
+	RemNoise uint8
+}
+
+func decodeRadioStatus(p []byte) interface{} {
+	return &RadioStatus{
+		RxErrors: binary.LittleEndian.Uint16(p[0:]),
+		Fixed:    binary.LittleEndian.Uint16(p[2:]),
+		RSSI:     p[4],
+		RemRSSI:  p[5],
+		TxBuf:    p[6],
+		Noise:    p[7],
+		RemNoise: p[8],
+	}
+}
+
+func float32At(p []byte, offset int) float32 {
+	return math.Float32frombits(binary.LittleEndian.Uint32(p[offset:]))
+}
+
+// BatteryStatus is the BATTERY_STATUS message describing one battery.
+// Unused cell voltages are UINT16_MAX and unknown values are -1.
+type BatteryStatus struct {
+	CurrentConsumed  int32      // mAh
+	EnergyConsumed   int32      // hJ
+	Temperature      int16      // cdegC
+	Voltages         [10]uint16 // mV
+	CurrentBattery   int16      // cA
+	ID               uint8
+	BatteryFunction  uint8
+	Type             uint8
+	BatteryRemaining int8 // %
+}
+
+func decodeBatteryStatus(p []byte) interface{} {
+	m := &BatteryStatus{
+		CurrentConsumed:  int32(binary.LittleEndian.Uint32(p[0:])),
+		EnergyConsumed:   int32(binary.LittleEndian.Uint32(p[4:])),
+		Temperature:      int16(binary.LittleEndian.Uint16(p[8:])),
+		CurrentBattery:   int16(binary.LittleEndian.Uint16(p[30:])),
+		ID:               p[32],
+		BatteryFunction:  p[33],
+		Type:             p[34],
+		BatteryRemaining: int8(p[35]),
+	}
+	for i := range m.Voltages {
+		m.Voltages[i] = binary.LittleEndian.Uint16(p[10+2*i:])
+	}
+	return m
+}
+
+// ExtendedSysState is the EXTENDED_SYS_STATE message with the landed state.
+type ExtendedSysState struct {
+	VTOLState   uint8
+	LandedState LandedState
+}
+
+func decodeExtendedSysState(p []byte) interface{} {
+	return &ExtendedSysState{VTOLState: p[0], LandedState: LandedState(p[1])}
+}
+
+// LandedState is the MAV_LANDED_STATE of an EXTENDED_SYS_STATE.
+type LandedState uint8
+
+const (
+	LandedStateUndefined LandedState = 0
+	LandedStateOnGround  LandedState = 1
+	LandedStateInAir     LandedState = 2
+	LandedStateTakeoff   LandedState = 3
+	LandedStateLanding   LandedState = 4
+)
+
+// AutopilotVersion is the AUTOPILOT_VERSION message describing the flight stack.
+type AutopilotVersion struct {
+	Capabilities            uint64
+	UID                     uint64
+	FlightSWVersion         uint32
+	MiddlewareSWVersion     uint32
+	OSSWVersion             uint32
+	BoardVersion            uint32
+	VendorID                uint16
+	ProductID               uint16
+	FlightCustomVersion     [8]byte
+	MiddlewareCustomVersion [8]byte
+	OSCustomVersion         [8]byte
+}
+
+func decodeAutopilotVersion(p []byte) interface{} {
+	m := &AutopilotVersion{
+		Capabilities:        binary.LittleEndian.Uint64(p[0:]),
+		UID:                 binary.LittleEndian.Uint64(p[8:]),
+		FlightSWVersion:     binary.LittleEndian.Uint32(p[16:]),
+		MiddlewareSWVersion: binary.LittleEndian.Uint32(p[20:]),
+		OSSWVersion:         binary.LittleEndian.Uint32(p[24:]),
+		BoardVersion:        binary.LittleEndian.Uint32(p[28:]),
+		VendorID:            binary.LittleEndian.Uint16(p[32:]),
+		ProductID:           binary.LittleEndian.Uint16(p[34:]),
+	}
+	copy(m.FlightCustomVersion[:], p[36:44])
+	copy(m.MiddlewareCustomVersion[:], p[44:52])
+	copy(m.OSCustomVersion[:], p[52:60])
+	return m
+}
+
+// FirmwareVersion formats the flight software version as major.minor.patch, followed
+// by the release type unless it is an official release, e.g. "4.5.1" or "1.15.0-beta".
+// It returns "" if the autopilot did not report a version.
+func (m *AutopilotVersion) FirmwareVersion() string {
+	if m.FlightSWVersion == 0 {
+		return ""
+	}
+
+	v := m.FlightSWVersion
+	version := fmt.Sprintf("%d.%d.%d", v>>24, v>>16&0xFF, v>>8&0xFF)
+
+	// The low byte is a FIRMWARE_VERSION_TYPE.
+	switch typ := v & 0xFF; {
+	case typ >= 255:
+	case typ >= 192:
+		version += "-rc"
+	case typ >= 128:
+		version += "-beta"
+	case typ >= 64:
+		version += "-alpha"
+	default:
+		version += "-dev"
+	}
+	return version
+}
+
+// Autopilot is the MAV_AUTOPILOT value of a heartbeat.
+type Autopilot uint8
+
+const (
+	AutopilotGeneric       Autopilot = 0
+	AutopilotSLUGS         Autopilot = 2
+	AutopilotArduPilotMega Autopilot = 3
+	AutopilotOpenPilot     Autopilot = 4
+	AutopilotInvalid       Autopilot = 8
+	AutopilotPPZ           Autopilot = 9
+	AutopilotUDB           Autopilot = 10
+	AutopilotFlexiPilot    Autopilot = 11
+	AutopilotPX4           Autopilot = 12
+	AutopilotSMACCMPilot   Autopilot = 13
+	AutopilotAutoQuad      Autopilot = 14
+	AutopilotArmazila      Autopilot = 15
+	AutopilotAerob         Autopilot = 16
+	AutopilotASLUAV        Autopilot = 17
+	AutopilotSmartAP       Autopilot = 18
+	AutopilotAirRails      Autopilot = 19
+	AutopilotReflex        Autopilot = 20
+)
+
+var autopilotNames = map[Autopilot]string{
+	AutopilotGeneric:       "generic",
+	AutopilotSLUGS:         "slugs",
+	AutopilotArduPilotMega: "ardupilot",
+	AutopilotOpenPilot:     "openpilot",
+	AutopilotInvalid:       "invalid",
+	AutopilotPPZ:           "paparazzi",
+	AutopilotUDB:           "udb",
+	AutopilotFlexiPilot:    "flexipilot",
+	AutopilotPX4:           "px4",
+	AutopilotSMACCMPilot:   "smaccmpilot",
+	AutopilotAutoQuad:      "autoquad",
+	AutopilotArmazila:      "armazila",
+	AutopilotAerob:         "aerob",
+	AutopilotASLUAV:        "asluav",
+	AutopilotSmartAP:       "smartap",
+	AutopilotAirRails:      "airrails",
+	AutopilotReflex:        "reflex",
+}
+
+// IsVehicle reports whether a heartbeat with this autopilot comes from a flight
+// controller. Ground stations, gimbals and other components send MAV_AUTOPILOT_INVALID.
+func (a Autopilot) IsVehicle() bool {
+	return a != AutopilotInvalid
+}
+
+func (a Autopilot) String() string {
+	if name, ok := autopilotNames[a]; ok {
+		return name
+	}
+	return fmt.Sprintf("autopilot(%d)", uint8(a))
+}
@@ -0,0 +1,164 @@
+package mavlink
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"testing"
+)
+
+// signedFrame reads the signed known-answer heartbeat after change has altered its bytes.
+func signedFrame(t *testing.T, change func(raw []byte) []byte) *Frame {
+	t.Helper()
+	raw := mustHex(t, katHeartbeatSigned)
+	if change != nil {
+		raw = change(raw)
+	}
+	frames, _, _ := readAll(raw)
+	if len(frames) != 1 {
+		t.Fatalf("read %d frames, want 1", len(frames))
+	}
+	return frames[0]
+}
+
+// resign signs the known-answer heartbeat again with key on link at timestamp.
+func resign(key SigningKey, link uint8, timestamp uint64) func(raw []byte) []byte {
+	return func(raw []byte) []byte {
+		unsigned := raw[:len(raw)-signatureLen]
+		var stamp [8]byte
+		binary.LittleEndian.PutUint64(stamp[:], timestamp)
+		tail := append([]byte{link}, stamp[:6]...)
+
+		h := sha256.New()
+		h.Write(key[:])
+		h.Write(unsigned)
+		h.Write(tail)
+		return append(append(append([]byte(nil), unsigned...), tail...), h.Sum(nil)[:6]...)
+	}
+}
+
+func TestParseSigningKey(t *testing.T) {
+	key, err := ParseSigningKey(katPassphrase)
+	if err != nil || key.String() != katKey {
+		t.Fatalf("ParseSigningKey(passphrase) = %s, %v, want %s", key, err, katKey)
+	}
+	if again, err := ParseSigningKey(" " + katKey + "\n"); err != nil || again != key {
+		t.Errorf("ParseSigningKey(hex) = %s, %v, want the same key", again, err)
+	}
+	if _, err := ParseSigningKey("  "); err == nil {
+		t.Error("ParseSigningKey accepted an empty key")
+	}
+}
+
+func TestSignatureKnownAnswer(t *testing.T) {
+	key, _ := ParseSigningKey(katPassphrase)
+	frame := signedFrame(t, nil)
+
+	if !frame.Signed() || frame.SignatureLinkID() != 2 || frame.SignatureTimestamp() != 123456789012 {
+		t.Fatalf("signature = link %d, timestamp %d", frame.SignatureLinkID(), frame.SignatureTimestamp())
+	}
+	if got := frame.SignatureTime().Format("2006-01-02 15:04:05"); got != "2015-01-15 06:56:07" {
+		t.Errorf("SignatureTime = %s", got)
+	}
+	if !frame.SignatureMatches(key) {
+		t.Error("the known-answer signature does not match")
+	}
+	if status := NewVerifier().Check(frame, key); status != SignatureValid {
+		t.Errorf("Check = %s, want valid", status)
+	}
+}
+
+func TestVerifierCheck(t *testing.T) {
+	key, _ := ParseSigningKey(katPassphrase)
+	other, _ := ParseSigningKey("another passphrase")
+
+	tests := []struct {
+		name   string
+		change func(raw []byte) []byte
+		key    SigningKey
+		want   SignatureStatus
+	}{
+		{name: "valid", key: key, want: SignatureValid},
+		{name: "other key", key: other, want: SignatureInvalid},
+		{
+			// The checksum is fixed up so only the signature can tell.
+			name: "tampered payload",
+			change: func(raw []byte) []byte {
+				raw[10+2] ^= 0x80
+				fixed := appendChecksum(append([]byte(nil), raw[:10+9]...), MessageIDHeartbeat)
+				return append(fixed, raw[10+9+2:]...)
+			},
+			key:  key,
+			want: SignatureInvalid,
+		},
+		{
+			name:   "tampered link ID",
+			change: func(raw []byte) []byte { raw[len(raw)-signatureLen] = 3; return raw },
+			key:    key,
+			want:   SignatureInvalid,
+		},
+		{
+			name:   "tampered timestamp",
+			change: func(raw []byte) []byte { raw[len(raw)-signatureLen+1]++; return raw },
+			key:    key,
+			want:   SignatureInvalid,
+		},
+		{
+			name: "unsigned",
+			change: func(raw []byte) []byte {
+				raw[2] = 0
+				unsigned := appendChecksum(append([]byte(nil), raw[:10+9]...), MessageIDHeartbeat)
+				return unsigned
+			},
+			key:  key,
+			want: SignatureUnsigned,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frame := signedFrame(t, tt.change)
+			if status := NewVerifier().Check(frame, tt.key); status != tt.want {
+				t.Errorf("Check = %s, want %s", status, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifierReplay(t *testing.T) {
+	key, _ := ParseSigningKey(katPassphrase)
+	const start = 123456789012
+	const minute = 60 * 100000
+
+	steps := []struct {
+		name      string
+		link      uint8
+		timestamp uint64
+		want      SignatureStatus
+	}{
+		{"first frame", 2, start, SignatureValid},
+		{"replayed", 2, start, SignatureReplayed},
+		{"newer", 2, start + 1, SignatureValid},
+		{"older on its stream", 2, start, SignatureReplayed},
+		{"new stream within a minute", 4, start + 1 - minute, SignatureValid},
+		{"new stream over a minute behind", 5, start - minute, SignatureReplayed},
+		{"rejected stream later", 5, start + 2, SignatureValid},
+	}
+
+	verifier := NewVerifier()
+	for _, step := range steps {
+		frame := signedFrame(t, resign(key, step.link, step.timestamp))
+		if status := verifier.Check(frame, key); status != step.want {
+			t.Errorf("%s: Check = %s, want %s", step.name, status, step.want)
+		}
+	}
+
+	// A frame failing the signature does not advance its stream.
+	other, _ := ParseSigningKey("another passphrase")
+	forged := signedFrame(t, resign(other, 2, start+100))
+	if status := verifier.Check(forged, key); status != SignatureInvalid {
+		t.Fatalf("forged frame = %s, want bad_signature", status)
+	}
+	if status := verifier.Check(signedFrame(t, resign(key, 2, start+50)), key); status != SignatureValid {
+		t.Errorf("frame after a forged one = %s, want valid", status)
+	}
+}
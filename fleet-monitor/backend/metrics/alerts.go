@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// alertsTimeout bounds the queries run for a scrape.
+const alertsTimeout = 5 * time.Second
+
+var activeAlertsDesc = prometheus.NewDesc(
+	prometheus.BuildFQName(namespace, "", "active_alerts"),
+	"Conditions that need attention, by alert.",
+	[]string{"alert"}, nil,
+)
+
+// AlertCounter counts the active alerts by name, e.g. {"maintenance_overdue": 2}.
+type AlertCounter func(ctx context.Context) (map[string]int, error)
+
+// alertsCollector runs an AlertCounter on each scrape, so the gauges never go stale.
+type alertsCollector struct {
+	count AlertCounter
+	names []string
+}
+
+// RegisterAlerts exposes fleet_monitor_active_alerts, counted by fn on each scrape.
+// The alerts in names are always exposed, as 0 when fn does not report them, so
+// alert rules don't see them disappear. A failing fn fails the scrape.
+// Example
+// metrics.RegisterAlerts(countAlerts, "maintenance_overdue", "battery_low_health")
+func RegisterAlerts(fn AlertCounter, names ...string) error {
+	return Registry.Register(&alertsCollector{count: fn, names: names})
+}
+
+// Describe implements prometheus.Collector
+func (c *alertsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- activeAlertsDesc
+}
+
+// Collect implements prometheus.Collector
+func (c *alertsCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), alertsTimeout)
+	defer cancel()
+
+	counts, err := c.count(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(activeAlertsDesc, err)
+		return
+	}
+
+	for _, name := range c.names {
+		if _, ok := counts[name]; !ok {
+			ch <- prometheus.MustNewConstMetric(activeAlertsDesc, prometheus.GaugeValue, 0, name)
+		}
+	}
+	for name, count := range counts {
+		ch <- prometheus.MustNewConstMetric(activeAlertsDesc, prometheus.GaugeValue, float64(count), name)
+	}
+}
@@ -0,0 +1,141 @@
+// Package metrics holds the Prometheus metrics of fleet-monitor. The names below are
+// stable; dashboards and alert rules rely on them, so rename nothing without a
+// deprecation period.
+//
+//	fleet_monitor_link_bytes_total{link}                    bytes read from a telemetry link
+//	fleet_monitor_link_packets_total{link}                  MAVLink frames with a valid checksum
+//	fleet_monitor_link_crc_errors_total{link}               frames dropped for a bad checksum
+//	fleet_monitor_drone_messages_total{system,message}      decoded messages per MAVLink system
+//	fleet_monitor_signature_failures_total{system,reason}   frames failing the drone's signing checks
+//	fleet_monitor_telemetry_anomalies_total{system,reason}  position updates failing a plausibility check
+//	fleet_monitor_telemetry_quarantined_total{system}       position updates quarantined instead of applied
+//	fleet_monitor_drones_live                               drones heard from in the last 5s
+//	fleet_monitor_telemetry_queue_depth                     drones with telemetry waiting to be written
+//	fleet_monitor_http_request_duration_seconds{method,route,status}
+//	fleet_monitor_db_query_duration_seconds{operation,table}
+//	fleet_monitor_db_query_errors_total{operation,table}
+//	fleet_monitor_active_alerts{alert}                      see RegisterAlerts
+//
+// Go runtime and process metrics are exposed too, under go_ and process_.
+package metrics
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "fleet_monitor"
+
+// LiveTimeout is how long a drone counts as live after its last heartbeat.
+const LiveTimeout = 5 * time.Second
+
+// Registry holds every metric of the process. It is not the Prometheus default
+// registry, so libraries cannot add metrics behind our back.
+var Registry = prometheus.NewRegistry()
+
+var factory = func() *prometheusFactory {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return &prometheusFactory{Registry}
+}()
+
+var (
+	LinkBytes = factory.counterVec("link_bytes_total",
+		"Bytes read from a telemetry link.", "link")
+	LinkPackets = factory.counterVec("link_packets_total",
+		"MAVLink frames with a valid checksum read from a telemetry link.", "link")
+	LinkCRCErrors = factory.counterVec("link_crc_errors_total",
+		"MAVLink frames of known messages dropped because their checksum did not match.", "link")
+	DroneMessages = factory.counterVec("drone_messages_total",
+		"MAVLink messages decoded per system ID and message name.", "system", "message")
+	SignatureFailures = factory.counterVec("signature_failures_total",
+		"MAVLink frames with a bad signature, a replayed timestamp or no signature against the drone's signing policy.", "system", "reason")
+	TelemetryAnomalies = factory.counterVec("telemetry_anomalies_total",
+		"Position updates failing a plausibility check, by check. An update may fail several.", "system", "reason")
+	TelemetryQuarantined = factory.counterVec("telemetry_quarantined_total",
+		"Position updates quarantined instead of applied to the drone's live state.", "system")
+	TelemetryQueueDepth = factory.gauge("telemetry_queue_depth",
+		"Drones whose latest telemetry is waiting to be written to the database.")
+	HTTPRequestDuration = factory.histogramVec("http_request_duration_seconds",
+		"Time to handle HTTP requests by route pattern.", prometheus.DefBuckets, "method", "route", "status")
+	DBQueryDuration = factory.histogramVec("db_query_duration_seconds",
+		"Time spent in database queries.", dbBuckets, "operation", "table")
+	DBQueryErrors = factory.counterVec("db_query_errors_total",
+		"Database queries that failed, not counting lookups finding no record.", "operation", "table")
+)
+
+// dbBuckets suit SQLite and a nearby PostgreSQL, from sub-millisecond lookups to
+// the slow query threshold of the database logger.
+var dbBuckets = []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5}
+
+// live tracks when each drone was last heard from for fleet_monitor_drones_live.
+var live = struct {
+	sync.Mutex
+	seen map[string]time.Time
+}{seen: map[string]time.Time{}}
+
+func init() {
+	factory.gaugeFunc("drones_live", "Drones heard from within the last 5 seconds.", func() float64 {
+		live.Lock()
+		defer live.Unlock()
+
+		count := 0
+		for id, at := range live.seen {
+			if time.Since(at) > LiveTimeout {
+				delete(live.seen, id)
+				continue
+			}
+			count++
+		}
+		return float64(count)
+	})
+}
+
+// DroneSeen marks a drone as live, e.g. on each of its heartbeats.
+func DroneSeen(droneID string, at time.Time) {
+	live.Lock()
+	defer live.Unlock()
+	live.seen[droneID] = at
+}
+
+// Handler serves the metrics in the Prometheus text format.
+// Example
+// http.Handle("/metrics", metrics.Handler())
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// prometheusFactory creates metrics under the fleet_monitor namespace and registers
+// them on the registry.
+type prometheusFactory struct {
+	registry prometheus.Registerer
+}
+
+func (f *prometheusFactory) counterVec(name, help string, labels ...string) *prometheus.CounterVec {
+	c := prometheus.NewCounterVec(prometheus.CounterOpts{Namespace: namespace, Name: name, Help: help}, labels)
+	f.registry.MustRegister(c)
+	return c
+}
+
+func (f *prometheusFactory) gauge(name, help string) prometheus.Gauge {
+	g := prometheus.NewGauge(prometheus.GaugeOpts{Namespace: namespace, Name: name, Help: help})
+	f.registry.MustRegister(g)
+	return g
+}
+
+func (f *prometheusFactory) gaugeFunc(name, help string, fn func() float64) {
+	f.registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{Namespace: namespace, Name: name, Help: help}, fn))
+}
+
+func (f *prometheusFactory) histogramVec(name, help string, buckets []float64, labels ...string) *prometheus.HistogramVec {
+	h := prometheus.NewHistogramVec(prometheus.HistogramOpts{Namespace: namespace, Name: name, Help: help, Buckets: buckets}, labels)
+	f.registry.MustRegister(h)
+	return h
+}
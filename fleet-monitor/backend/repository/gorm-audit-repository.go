@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+
+	"fleet-monitor/backend/db"
+
+	"gorm.io/gorm"
+)
+
+type gormAuditRepository struct {
+	db *gorm.DB
+}
+
+// Add implements AuditRepository
+func (r *gormAuditRepository) Add(ctx context.Context, entry *db.AuditEntry) error {
+	return translate(r.db.WithContext(ctx).Create(entry).Error)
+}
+
+// Find implements AuditRepository
+func (r *gormAuditRepository) Find(ctx context.Context, filter AuditFilter) ([]db.AuditEntry, error) {
+	var entries []db.AuditEntry
+
+	query := r.db.WithContext(ctx)
+	if filter.Actor != "" {
+		query = query.Where("actor = ?", filter.Actor)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.Entity != "" {
+		query = query.Where("entity = ?", filter.Entity)
+	}
+	if filter.EntityID != 0 {
+		query = query.Where("entity_id = ?", filter.EntityID)
+	}
+	if filter.RequestID != "" {
+		query = query.Where("request_id = ?", filter.RequestID)
+	}
+	if !filter.Since.IsZero() {
+		query = query.Where("created_at >= ?", filter.Since)
+	}
+	if !filter.Until.IsZero() {
+		query = query.Where("created_at <= ?", filter.Until)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	if err := query.Order("created_at DESC, id DESC").Find(&entries).Error; err != nil {
+		return nil, translate(err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+
+	"fleet-monitor/backend/db"
+
+	"gorm.io/gorm"
+)
+
+type gormBatteryRepository struct {
+	db *gorm.DB
+}
+
+// Create implements BatteryRepository
+func (r *gormBatteryRepository) Create(ctx context.Context, pack *db.BatteryPack) error {
+	return translate(r.db.WithContext(ctx).Create(pack).Error)
+}
+
+// Save implements BatteryRepository
+func (r *gormBatteryRepository) Save(ctx context.Context, pack *db.BatteryPack) error {
+	return translate(r.db.WithContext(ctx).Save(pack).Error)
+}
+
+// FindByID implements BatteryRepository
+func (r *gormBatteryRepository) FindByID(ctx context.Context, id uint) (*db.BatteryPack, error) {
+	var pack db.BatteryPack
+	if err := r.db.WithContext(ctx).First(&pack, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &pack, nil
+}
+
+// FindBySerialNumber implements BatteryRepository
+func (r *gormBatteryRepository) FindBySerialNumber(ctx context.Context, serialNumber string) (*db.BatteryPack, error) {
+	return r.findOne(ctx, "serial_number = ?", serialNumber)
+}
+
+// FindAll implements BatteryRepository
+func (r *gormBatteryRepository) FindAll(ctx context.Context) ([]db.BatteryPack, error) {
+	var packs []db.BatteryPack
+	if err := r.db.WithContext(ctx).Order("id").Find(&packs).Error; err != nil {
+		return nil, translate(err)
+	}
+	return packs, nil
+}
+
+// FindByDrone implements BatteryRepository
+func (r *gormBatteryRepository) FindByDrone(ctx context.Context, droneID uint) (*db.BatteryPack, error) {
+	return r.findOne(ctx, "drone_id = ?", droneID)
+}
+
+func (r *gormBatteryRepository) findOne(ctx context.Context, query string, arg interface{}) (*db.BatteryPack, error) {
+	var pack db.BatteryPack
+	result := r.db.WithContext(ctx).Where(query, arg).Limit(1).Find(&pack)
+	if result.Error != nil {
+		return nil, translate(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrNotFound
+	}
+	return &pack, nil
+}
+
+// AddDischarge implements BatteryRepository
+func (r *gormBatteryRepository) AddDischarge(ctx context.Context, discharge *db.BatteryDischarge) error {
+	return translate(r.db.WithContext(ctx).Create(discharge).Error)
+}
+
+// FindDischarges implements BatteryRepository
+func (r *gormBatteryRepository) FindDischarges(ctx context.Context, packID uint, limit int) ([]db.BatteryDischarge, error) {
+	var discharges []db.BatteryDischarge
+
+	query := r.db.WithContext(ctx).Where("pack_id = ?", packID)
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	if err := query.Order("started_at DESC, id DESC").Find(&discharges).Error; err != nil {
+		return nil, translate(err)
+	}
+	return discharges, nil
+}
@@ -0,0 +1,219 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"fleet-monitor/backend/db"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type gormDroneRepository struct {
+	db *gorm.DB
+}
+
+// Create implements DroneRepository
+func (r *gormDroneRepository) Create(ctx context.Context, drone *db.Drone) error {
+	return translate(r.db.WithContext(ctx).Create(drone).Error)
+}
+
+// Save implements DroneRepository
+func (r *gormDroneRepository) Save(ctx context.Context, drone *db.Drone) error {
+	return translate(r.db.WithContext(ctx).Save(drone).Error)
+}
+
+// Update implements DroneRepository
+func (r *gormDroneRepository) Update(ctx context.Context, drone *db.Drone, fields ...string) error {
+	columns, err := columnsOf(r.db, drone, fields)
+	if err != nil {
+		return err
+	}
+
+	result := r.db.WithContext(ctx).Model(drone).Select(columns).Updates(drone)
+	if result.Error != nil {
+		return translate(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// FindByID implements DroneRepository
+func (r *gormDroneRepository) FindByID(ctx context.Context, id uint) (*db.Drone, error) {
+	var drone db.Drone
+	if err := r.db.WithContext(ctx).First(&drone, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &drone, nil
+}
+
+// FindByMavlinkID implements DroneRepository
+func (r *gormDroneRepository) FindByMavlinkID(ctx context.Context, mavlinkID string) (*db.Drone, error) {
+	var drone db.Drone
+	result := r.db.WithContext(ctx).Where("mavlink_id = ?", mavlinkID).Limit(1).Find(&drone)
+	if result.Error != nil {
+		return nil, translate(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrNotFound
+	}
+	return &drone, nil
+}
+
+// FindAll implements DroneRepository
+func (r *gormDroneRepository) FindAll(ctx context.Context) ([]db.Drone, error) {
+	var drones []db.Drone
+	if err := r.db.WithContext(ctx).Order("id").Find(&drones).Error; err != nil {
+		return nil, translate(err)
+	}
+	return drones, nil
+}
+
+// FindByIDs implements DroneRepository
+func (r *gormDroneRepository) FindByIDs(ctx context.Context, ids []uint) ([]db.Drone, error) {
+	drones := []db.Drone{}
+	if len(ids) == 0 {
+		return drones, nil
+	}
+	if err := r.db.WithContext(ctx).Where("id IN ?", ids).Order("id").Find(&drones).Error; err != nil {
+		return nil, translate(err)
+	}
+	return drones, nil
+}
+
+// FindByOwner implements DroneRepository
+func (r *gormDroneRepository) FindByOwner(ctx context.Context, ownerID uint) ([]db.Drone, error) {
+	var drones []db.Drone
+	if err := r.db.WithContext(ctx).Where("owner_id = ?", ownerID).Order("id").Find(&drones).Error; err != nil {
+		return nil, translate(err)
+	}
+	return drones, nil
+}
+
+// FindByHealthStatus implements DroneRepository
+func (r *gormDroneRepository) FindByHealthStatus(ctx context.Context, status db.HealthStatus) ([]db.Drone, error) {
+	var drones []db.Drone
+	if err := r.db.WithContext(ctx).Where("health_status = ?", status).Order("id").Find(&drones).Error; err != nil {
+		return nil, translate(err)
+	}
+	return drones, nil
+}
+
+// FindByFlightPhase implements DroneRepository
+func (r *gormDroneRepository) FindByFlightPhase(ctx context.Context, phase db.FlightPhase) ([]db.Drone, error) {
+	var drones []db.Drone
+	if err := r.db.WithContext(ctx).Where("flight_phase = ?", phase).Order("id").Find(&drones).Error; err != nil {
+		return nil, translate(err)
+	}
+	return drones, nil
+}
+
+// Exists implements DroneRepository
+func (r *gormDroneRepository) Exists(ctx context.Context, id uint) (bool, error) {
+	return exists(r.db.WithContext(ctx), &db.Drone{}, id)
+}
+
+// Delete implements DroneRepository
+func (r *gormDroneRepository) Delete(ctx context.Context, id uint) error {
+	result := r.db.WithContext(ctx).Delete(&db.Drone{}, id)
+	if result.Error != nil {
+		return translate(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// AddTelemetry implements DroneRepository
+func (r *gormDroneRepository) AddTelemetry(ctx context.Context, sample *db.Telemetry) error {
+	return translate(r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(sample).Error)
+}
+
+// FindTelemetry implements DroneRepository
+func (r *gormDroneRepository) FindTelemetry(ctx context.Context, droneID uint, since, until time.Time, limit int) ([]db.Telemetry, error) {
+	var samples []db.Telemetry
+
+	query := r.db.WithContext(ctx).Where("drone_id = ?", droneID)
+	if !since.IsZero() {
+		query = query.Where("recorded_at >= ?", since.UTC())
+	}
+	if !until.IsZero() {
+		query = query.Where("recorded_at <= ?", until.UTC())
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	if err := query.Order("recorded_at DESC").Find(&samples).Error; err != nil {
+		return nil, translate(err)
+	}
+	return samples, nil
+}
+
+// AddQuarantined implements DroneRepository
+func (r *gormDroneRepository) AddQuarantined(ctx context.Context, sample *db.QuarantinedSample) error {
+	return translate(r.db.WithContext(ctx).Create(sample).Error)
+}
+
+// FindQuarantined implements DroneRepository
+func (r *gormDroneRepository) FindQuarantined(ctx context.Context, droneID uint, since, until time.Time, limit int) ([]db.QuarantinedSample, error) {
+	var samples []db.QuarantinedSample
+
+	query := r.db.WithContext(ctx).Where("drone_id = ?", droneID)
+	if !since.IsZero() {
+		query = query.Where("received_at >= ?", since.UTC())
+	}
+	if !until.IsZero() {
+		query = query.Where("received_at <= ?", until.UTC())
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	if err := query.Order("received_at DESC, id DESC").Find(&samples).Error; err != nil {
+		return nil, translate(err)
+	}
+	return samples, nil
+}
+
+// FindDeleted implements DroneRepository
+func (r *gormDroneRepository) FindDeleted(ctx context.Context) ([]db.Drone, error) {
+	var drones []db.Drone
+	if err := findDeleted(r.db.WithContext(ctx), &drones); err != nil {
+		return nil, err
+	}
+	return drones, nil
+}
+
+// FindDeletedByID implements DroneRepository
+func (r *gormDroneRepository) FindDeletedByID(ctx context.Context, id uint) (*db.Drone, error) {
+	var drone db.Drone
+	if err := findDeletedByID(r.db.WithContext(ctx), &drone, id); err != nil {
+		return nil, err
+	}
+	return &drone, nil
+}
+
+// Restore implements DroneRepository
+func (r *gormDroneRepository) Restore(ctx context.Context, id uint) error {
+	return restore(r.db.WithContext(ctx), &db.Drone{}, id)
+}
+
+// Purge implements DroneRepository
+func (r *gormDroneRepository) Purge(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, model := range []interface{}{&db.Telemetry{}, &db.QuarantinedSample{}, &db.Flight{}, &db.ServiceInterval{}, &db.MaintenanceLog{}} {
+			if err := tx.Where("drone_id = ?", id).Delete(model).Error; err != nil {
+				return translate(err)
+			}
+		}
+		if err := tx.Model(&db.BatteryPack{}).Where("drone_id = ?", id).Update("drone_id", 0).Error; err != nil {
+			return translate(err)
+		}
+		return purge(tx, &db.Drone{}, id)
+	})
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+
+	"fleet-monitor/backend/db"
+
+	"gorm.io/gorm"
+)
+
+type gormMaintenanceRepository struct {
+	db *gorm.DB
+}
+
+// AddFlight implements MaintenanceRepository
+func (r *gormMaintenanceRepository) AddFlight(ctx context.Context, flight *db.Flight) error {
+	return translate(r.db.WithContext(ctx).Create(flight).Error)
+}
+
+// FindFlights implements MaintenanceRepository
+func (r *gormMaintenanceRepository) FindFlights(ctx context.Context, droneID uint) ([]db.Flight, error) {
+	var flights []db.Flight
+	if err := r.db.WithContext(ctx).Where("drone_id = ?", droneID).Order("started_at DESC, id DESC").Find(&flights).Error; err != nil {
+		return nil, translate(err)
+	}
+	return flights, nil
+}
+
+// CreateInterval implements MaintenanceRepository
+func (r *gormMaintenanceRepository) CreateInterval(ctx context.Context, interval *db.ServiceInterval) error {
+	return translate(r.db.WithContext(ctx).Create(interval).Error)
+}
+
+// SaveInterval implements MaintenanceRepository
+func (r *gormMaintenanceRepository) SaveInterval(ctx context.Context, interval *db.ServiceInterval) error {
+	return translate(r.db.WithContext(ctx).Save(interval).Error)
+}
+
+// FindInterval implements MaintenanceRepository
+func (r *gormMaintenanceRepository) FindInterval(ctx context.Context, droneID, id uint) (*db.ServiceInterval, error) {
+	var interval db.ServiceInterval
+	if err := r.db.WithContext(ctx).Where("drone_id = ?", droneID).First(&interval, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &interval, nil
+}
+
+// FindIntervals implements MaintenanceRepository
+func (r *gormMaintenanceRepository) FindIntervals(ctx context.Context, droneID uint) ([]db.ServiceInterval, error) {
+	var intervals []db.ServiceInterval
+	if err := r.db.WithContext(ctx).Where("drone_id = ?", droneID).Order("id").Find(&intervals).Error; err != nil {
+		return nil, translate(err)
+	}
+	return intervals, nil
+}
+
+// DeleteInterval implements MaintenanceRepository
+func (r *gormMaintenanceRepository) DeleteInterval(ctx context.Context, droneID, id uint) error {
+	result := r.db.WithContext(ctx).Where("drone_id = ?", droneID).Delete(&db.ServiceInterval{}, id)
+	if result.Error != nil {
+		return translate(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// AddLog implements MaintenanceRepository
+func (r *gormMaintenanceRepository) AddLog(ctx context.Context, entry *db.MaintenanceLog) error {
+	return translate(r.db.WithContext(ctx).Create(entry).Error)
+}
+
+// FindLogs implements MaintenanceRepository
+func (r *gormMaintenanceRepository) FindLogs(ctx context.Context, droneID uint) ([]db.MaintenanceLog, error) {
+	var entries []db.MaintenanceLog
+	if err := r.db.WithContext(ctx).Where("drone_id = ?", droneID).Order("performed_at DESC, id DESC").Find(&entries).Error; err != nil {
+		return nil, translate(err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+
+	"fleet-monitor/backend/db"
+
+	"gorm.io/gorm"
+)
+
+type gormSecretRepository struct {
+	db *gorm.DB
+}
+
+// Save implements SecretRepository
+func (r *gormSecretRepository) Save(ctx context.Context, secret *db.Secret) error {
+	return translate(r.db.WithContext(ctx).Save(secret).Error)
+}
+
+// FindByName implements SecretRepository
+func (r *gormSecretRepository) FindByName(ctx context.Context, name string) (*db.Secret, error) {
+	var secret db.Secret
+	result := r.db.WithContext(ctx).Where("name = ?", name).Limit(1).Find(&secret)
+	if result.Error != nil {
+		return nil, translate(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrNotFound
+	}
+	return &secret, nil
+}
+
+// FindAll implements SecretRepository
+func (r *gormSecretRepository) FindAll(ctx context.Context) ([]db.Secret, error) {
+	var secrets []db.Secret
+	if err := r.db.WithContext(ctx).Order("name").Find(&secrets).Error; err != nil {
+		return nil, translate(err)
+	}
+	return secrets, nil
+}
+
+// DeleteByName implements SecretRepository
+func (r *gormSecretRepository) DeleteByName(ctx context.Context, name string) error {
+	result := r.db.WithContext(ctx).Where("name = ?", name).Delete(&db.Secret{})
+	if result.Error != nil {
+		return translate(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"fleet-monitor/backend/db"
+
+	"gorm.io/gorm"
+)
+
+type gormSecurityAlertRepository struct {
+	db *gorm.DB
+}
+
+// Add implements SecurityAlertRepository
+func (r *gormSecurityAlertRepository) Add(ctx context.Context, alert *db.SecurityAlert) error {
+	return translate(r.db.WithContext(ctx).Create(alert).Error)
+}
+
+// Find implements SecurityAlertRepository
+func (r *gormSecurityAlertRepository) Find(ctx context.Context, droneID uint, since time.Time, limit int) ([]db.SecurityAlert, error) {
+	var alerts []db.SecurityAlert
+
+	query := r.db.WithContext(ctx)
+	if droneID != 0 {
+		query = query.Where("drone_id = ?", droneID)
+	}
+	if !since.IsZero() {
+		query = query.Where("created_at >= ?", since)
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	if err := query.Order("created_at DESC, id DESC").Find(&alerts).Error; err != nil {
+		return nil, translate(err)
+	}
+	return alerts, nil
+}
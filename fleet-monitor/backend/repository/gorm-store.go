@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+type gormStore struct {
+	db *gorm.DB
+}
+
+// NewGormStore creates a Store backed by the given gorm connection.
+// Example
+// store := repository.NewGormStore(db)
+func NewGormStore(db *gorm.DB) Store {
+	return &gormStore{db: db}
+}
+
+// Drones implements Store
+func (s *gormStore) Drones() DroneRepository {
+	return &gormDroneRepository{db: s.db}
+}
+
+// Tasks implements Store
+func (s *gormStore) Tasks() TaskRepository {
+	return &gormTaskRepository{db: s.db}
+}
+
+// Users implements Store
+func (s *gormStore) Users() UserRepository {
+	return &gormUserRepository{db: s.db}
+}
+
+// Maintenance implements Store
+func (s *gormStore) Maintenance() MaintenanceRepository {
+	return &gormMaintenanceRepository{db: s.db}
+}
+
+// Batteries implements Store
+func (s *gormStore) Batteries() BatteryRepository {
+	return &gormBatteryRepository{db: s.db}
+}
+
+// Secrets implements Store
+func (s *gormStore) Secrets() SecretRepository {
+	return &gormSecretRepository{db: s.db}
+}
+
+// SecurityAlerts implements Store
+func (s *gormStore) SecurityAlerts() SecurityAlertRepository {
+	return &gormSecurityAlertRepository{db: s.db}
+}
+
+// Audit implements Store
+func (s *gormStore) Audit() AuditRepository {
+	return &gormAuditRepository{db: s.db}
+}
+
+// Transaction implements Store
+func (s *gormStore) Transaction(ctx context.Context, fn func(tx Store) error) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&gormStore{db: tx})
+	})
+}
+
+// translate maps gorm errors onto the repository errors.
+// The connection must be opened with gorm.Config.TranslateError for constraint errors.
+func translate(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return ErrNotFound
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return ErrDuplicatedKey
+	case errors.Is(err, gorm.ErrForeignKeyViolated):
+		return ErrForeignKeyViolated
+	}
+	return err
+}
+
+// columnsOf returns the columns holding the named fields of model, with every column
+// of an embedded struct for its field name.
+func columnsOf(tx *gorm.DB, model interface{}, fields []string) ([]string, error) {
+	stmt := &gorm.Statement{DB: tx}
+	if err := stmt.Parse(model); err != nil {
+		return nil, err
+	}
+
+	var columns []string
+	for _, name := range fields {
+		found := false
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName != "" && field.BindNames[0] == name {
+				columns = append(columns, field.DBName)
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%s has no field %s", stmt.Schema.Name, name)
+		}
+	}
+	return columns, nil
+}
+
+// exists reports whether a row of model with the given ID exists.
+func exists(tx *gorm.DB, model interface{}, id uint) (bool, error) {
+	var count int64
+	if err := tx.Model(model).Where("id = ?", id).Count(&count).Error; err != nil {
+		return false, translate(err)
+	}
+	return count > 0, nil
+}
+
+// findDeleted loads the soft-deleted rows of dest's model, ordered by ID.
+func findDeleted(tx *gorm.DB, dest interface{}) error {
+	return translate(tx.Unscoped().Where("deleted_at IS NOT NULL").Order("id").Find(dest).Error)
+}
+
+// findDeletedByID loads the soft-deleted row with the given ID into dest.
+func findDeletedByID(tx *gorm.DB, dest interface{}, id uint) error {
+	result := tx.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).Limit(1).Find(dest)
+	if result.Error != nil {
+		return translate(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// restore clears deleted_at of the soft-deleted row of model with the given ID.
+func restore(tx *gorm.DB, model interface{}, id uint) error {
+	result := tx.Unscoped().Model(model).Where("id = ? AND deleted_at IS NOT NULL", id).Update("deleted_at", nil)
+	if result.Error != nil {
+		return translate(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// purge permanently deletes the soft-deleted row of model with the given ID.
+func purge(tx *gorm.DB, model interface{}, id uint) error {
+	result := tx.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).Delete(model)
+	if result.Error != nil {
+		return translate(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
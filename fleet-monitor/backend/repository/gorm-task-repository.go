@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+
+	"fleet-monitor/backend/db"
+
+	"gorm.io/gorm"
+)
+
+type gormTaskRepository struct {
+	db *gorm.DB
+}
+
+// Create implements TaskRepository
+func (r *gormTaskRepository) Create(ctx context.Context, task *db.Task) error {
+	return translate(r.db.WithContext(ctx).Create(task).Error)
+}
+
+// Save implements TaskRepository
+func (r *gormTaskRepository) Save(ctx context.Context, task *db.Task) error {
+	return translate(r.db.WithContext(ctx).Save(task).Error)
+}
+
+// FindByID implements TaskRepository
+func (r *gormTaskRepository) FindByID(ctx context.Context, id uint) (*db.Task, error) {
+	var task db.Task
+	if err := r.db.WithContext(ctx).First(&task, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &task, nil
+}
+
+// FindAll implements TaskRepository
+func (r *gormTaskRepository) FindAll(ctx context.Context) ([]db.Task, error) {
+	var tasks []db.Task
+	if err := r.db.WithContext(ctx).Order("id").Find(&tasks).Error; err != nil {
+		return nil, translate(err)
+	}
+	return tasks, nil
+}
+
+// FindByStatus implements TaskRepository
+func (r *gormTaskRepository) FindByStatus(ctx context.Context, status db.TaskStatus) ([]db.Task, error) {
+	var tasks []db.Task
+	if err := r.db.WithContext(ctx).Where("status = ?", status).Order("id").Find(&tasks).Error; err != nil {
+		return nil, translate(err)
+	}
+	return tasks, nil
+}
+
+// FindByUser implements TaskRepository
+func (r *gormTaskRepository) FindByUser(ctx context.Context, userID uint) ([]db.Task, error) {
+	var tasks []db.Task
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("id").Find(&tasks).Error; err != nil {
+		return nil, translate(err)
+	}
+	return tasks, nil
+}
+
+// FindByDrone implements TaskRepository
+func (r *gormTaskRepository) FindByDrone(ctx context.Context, droneID uint) ([]db.Task, error) {
+	var tasks []db.Task
+	if err := r.db.WithContext(ctx).Where("drone_id = ?", droneID).Order("id").Find(&tasks).Error; err != nil {
+		return nil, translate(err)
+	}
+	return tasks, nil
+}
+
+// Exists implements TaskRepository
+func (r *gormTaskRepository) Exists(ctx context.Context, id uint) (bool, error) {
+	return exists(r.db.WithContext(ctx), &db.Task{}, id)
+}
+
+// Delete implements TaskRepository
+func (r *gormTaskRepository) Delete(ctx context.Context, id uint) error {
+	result := r.db.WithContext(ctx).Delete(&db.Task{}, id)
+	if result.Error != nil {
+		return translate(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// FindDeleted implements TaskRepository
+func (r *gormTaskRepository) FindDeleted(ctx context.Context) ([]db.Task, error) {
+	var tasks []db.Task
+	if err := findDeleted(r.db.WithContext(ctx), &tasks); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// FindDeletedByID implements TaskRepository
+func (r *gormTaskRepository) FindDeletedByID(ctx context.Context, id uint) (*db.Task, error) {
+	var task db.Task
+	if err := findDeletedByID(r.db.WithContext(ctx), &task, id); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// Restore implements TaskRepository
+func (r *gormTaskRepository) Restore(ctx context.Context, id uint) error {
+	return restore(r.db.WithContext(ctx), &db.Task{}, id)
+}
+
+// Purge implements TaskRepository
+func (r *gormTaskRepository) Purge(ctx context.Context, id uint) error {
+	return purge(r.db.WithContext(ctx), &db.Task{}, id)
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+
+	"fleet-monitor/backend/db"
+
+	"gorm.io/gorm"
+)
+
+type gormUserRepository struct {
+	db *gorm.DB
+}
+
+// Create implements UserRepository
+func (r *gormUserRepository) Create(ctx context.Context, user *db.User) error {
+	return translate(r.db.WithContext(ctx).Create(user).Error)
+}
+
+// Save implements UserRepository
+func (r *gormUserRepository) Save(ctx context.Context, user *db.User) error {
+	return translate(r.db.WithContext(ctx).Save(user).Error)
+}
+
+// FindByID implements UserRepository
+func (r *gormUserRepository) FindByID(ctx context.Context, id uint) (*db.User, error) {
+	var user db.User
+	if err := r.db.WithContext(ctx).First(&user, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &user, nil
+}
+
+// FindByUserName implements UserRepository
+func (r *gormUserRepository) FindByUserName(ctx context.Context, userName string) (*db.User, error) {
+	var user db.User
+	result := r.db.WithContext(ctx).Where("user_name = ?", userName).Limit(1).Find(&user)
+	if result.Error != nil {
+		return nil, translate(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrNotFound
+	}
+	return &user, nil
+}
+
+// FindAll implements UserRepository
+func (r *gormUserRepository) FindAll(ctx context.Context) ([]db.User, error) {
+	var users []db.User
+	if err := r.db.WithContext(ctx).Order("id").Find(&users).Error; err != nil {
+		return nil, translate(err)
+	}
+	return users, nil
+}
+
+// UserNames implements UserRepository
+func (r *gormUserRepository) UserNames(ctx context.Context) ([]string, error) {
+	var userNames []string
+	if err := r.db.WithContext(ctx).Model(&db.User{}).Order("id").Pluck("user_name", &userNames).Error; err != nil {
+		return nil, translate(err)
+	}
+	return userNames, nil
+}
+
+// Exists implements UserRepository
+func (r *gormUserRepository) Exists(ctx context.Context, id uint) (bool, error) {
+	return exists(r.db.WithContext(ctx), &db.User{}, id)
+}
+
+// Delete implements UserRepository
+func (r *gormUserRepository) Delete(ctx context.Context, id uint) error {
+	result := r.db.WithContext(ctx).Delete(&db.User{}, id)
+	if result.Error != nil {
+		return translate(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// FindDeleted implements UserRepository
+func (r *gormUserRepository) FindDeleted(ctx context.Context) ([]db.User, error) {
+	var users []db.User
+	if err := findDeleted(r.db.WithContext(ctx), &users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// FindDeletedByID implements UserRepository
+func (r *gormUserRepository) FindDeletedByID(ctx context.Context, id uint) (*db.User, error) {
+	var user db.User
+	if err := findDeletedByID(r.db.WithContext(ctx), &user, id); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Restore implements UserRepository
+func (r *gormUserRepository) Restore(ctx context.Context, id uint) error {
+	return restore(r.db.WithContext(ctx), &db.User{}, id)
+}
+
+// Purge implements UserRepository
+func (r *gormUserRepository) Purge(ctx context.Context, id uint) error {
+	return purge(r.db.WithContext(ctx), &db.User{}, id)
+}
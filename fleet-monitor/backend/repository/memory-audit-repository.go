@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"fleet-monitor/backend/db"
+)
+
+type memoryAuditRepository struct {
+	store *memoryStore
+}
+
+// Add implements AuditRepository
+func (r *memoryAuditRepository) Add(ctx context.Context, entry *db.AuditEntry) error {
+	return r.store.view(ctx, func(d *memoryData) error {
+		d.lastID["audit_log"]++
+		entry.ID = d.lastID["audit_log"]
+		if entry.CreatedAt.IsZero() {
+			entry.CreatedAt = time.Now()
+		}
+		d.audit = append(d.audit, *entry)
+		return nil
+	})
+}
+
+// Find implements AuditRepository
+func (r *memoryAuditRepository) Find(ctx context.Context, filter AuditFilter) ([]db.AuditEntry, error) {
+	var entries []db.AuditEntry
+	err := r.store.view(ctx, func(d *memoryData) error {
+		for _, entry := range d.audit {
+			switch {
+			case filter.Actor != "" && entry.Actor != filter.Actor,
+				filter.Action != "" && entry.Action != filter.Action,
+				filter.Entity != "" && entry.Entity != filter.Entity,
+				filter.EntityID != 0 && entry.EntityID != filter.EntityID,
+				filter.RequestID != "" && entry.RequestID != filter.RequestID,
+				!filter.Since.IsZero() && entry.CreatedAt.Before(filter.Since),
+				!filter.Until.IsZero() && entry.CreatedAt.After(filter.Until):
+				continue
+			}
+			entries = append(entries, entry)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].CreatedAt.Equal(entries[j].CreatedAt) {
+			return entries[i].CreatedAt.After(entries[j].CreatedAt)
+		}
+		return entries[i].ID > entries[j].ID
+	})
+	if filter.Limit > 0 && len(entries) > filter.Limit {
+		entries = entries[:filter.Limit]
+	}
+	return entries, nil
+}
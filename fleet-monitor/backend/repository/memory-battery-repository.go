@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"fleet-monitor/backend/db"
+)
+
+type memoryBatteryRepository struct {
+	store *memoryStore
+}
+
+// serialNumberTaken reports whether a pack other than exceptID uses serialNumber.
+func serialNumberTaken(d *memoryData, serialNumber string, exceptID uint) bool {
+	for id, pack := range d.batteries {
+		if id != exceptID && pack.SerialNumber == serialNumber {
+			return true
+		}
+	}
+	return false
+}
+
+// Create implements BatteryRepository
+func (r *memoryBatteryRepository) Create(ctx context.Context, pack *db.BatteryPack) error {
+	return r.store.view(ctx, func(d *memoryData) error {
+		if serialNumberTaken(d, pack.SerialNumber, 0) {
+			return ErrDuplicatedKey
+		}
+		d.lastID["battery_packs"]++
+		pack.ID = d.lastID["battery_packs"]
+		pack.CreatedAt = time.Now()
+		pack.UpdatedAt = pack.CreatedAt
+		d.batteries[pack.ID] = *pack
+		return nil
+	})
+}
+
+// Save implements BatteryRepository
+func (r *memoryBatteryRepository) Save(ctx context.Context, pack *db.BatteryPack) error {
+	if pack.ID == 0 {
+		return r.Create(ctx, pack)
+	}
+	return r.store.view(ctx, func(d *memoryData) error {
+		if serialNumberTaken(d, pack.SerialNumber, pack.ID) {
+			return ErrDuplicatedKey
+		}
+		pack.UpdatedAt = time.Now()
+		d.batteries[pack.ID] = *pack
+		return nil
+	})
+}
+
+// FindByID implements BatteryRepository
+func (r *memoryBatteryRepository) FindByID(ctx context.Context, id uint) (*db.BatteryPack, error) {
+	return r.findOne(ctx, func(pack db.BatteryPack) bool { return pack.ID == id })
+}
+
+// FindBySerialNumber implements BatteryRepository
+func (r *memoryBatteryRepository) FindBySerialNumber(ctx context.Context, serialNumber string) (*db.BatteryPack, error) {
+	return r.findOne(ctx, func(pack db.BatteryPack) bool { return pack.SerialNumber == serialNumber })
+}
+
+// FindAll implements BatteryRepository
+func (r *memoryBatteryRepository) FindAll(ctx context.Context) ([]db.BatteryPack, error) {
+	var packs []db.BatteryPack
+	err := r.store.view(ctx, func(d *memoryData) error {
+		for _, pack := range d.batteries {
+			packs = append(packs, pack)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(packs, func(i, j int) bool { return packs[i].ID < packs[j].ID })
+	return packs, nil
+}
+
+// FindByDrone implements BatteryRepository
+func (r *memoryBatteryRepository) FindByDrone(ctx context.Context, droneID uint) (*db.BatteryPack, error) {
+	return r.findOne(ctx, func(pack db.BatteryPack) bool { return pack.DroneID == droneID })
+}
+
+// findOne returns the pack with the lowest ID that matches, or ErrNotFound.
+func (r *memoryBatteryRepository) findOne(ctx context.Context, match func(db.BatteryPack) bool) (*db.BatteryPack, error) {
+	packs, err := r.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range packs {
+		if match(packs[i]) {
+			return &packs[i], nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// AddDischarge implements BatteryRepository
+func (r *memoryBatteryRepository) AddDischarge(ctx context.Context, discharge *db.BatteryDischarge) error {
+	return r.store.view(ctx, func(d *memoryData) error {
+		d.lastID["battery_discharges"]++
+		discharge.ID = d.lastID["battery_discharges"]
+		d.discharges = append(d.discharges, *discharge)
+		return nil
+	})
+}
+
+// FindDischarges implements BatteryRepository
+func (r *memoryBatteryRepository) FindDischarges(ctx context.Context, packID uint, limit int) ([]db.BatteryDischarge, error) {
+	var discharges []db.BatteryDischarge
+	err := r.store.view(ctx, func(d *memoryData) error {
+		for _, discharge := range d.discharges {
+			if discharge.PackID == packID {
+				discharges = append(discharges, discharge)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(discharges, func(i, j int) bool {
+		if !discharges[i].StartedAt.Equal(discharges[j].StartedAt) {
+			return discharges[i].StartedAt.After(discharges[j].StartedAt)
+		}
+		return discharges[i].ID > discharges[j].ID
+	})
+	if limit > 0 && len(discharges) > limit {
+		discharges = discharges[:limit]
+	}
+	return discharges, nil
+}
@@ -0,0 +1,368 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"fleet-monitor/backend/db"
+
+	"gorm.io/gorm"
+)
+
+type memoryDroneRepository struct {
+	store *memoryStore
+}
+
+// mavlinkIDTaken reports whether a live drone other than exceptID uses mavlinkID.
+func mavlinkIDTaken(d *memoryData, mavlinkID string, exceptID uint) bool {
+	for id, drone := range d.drones {
+		if id != exceptID && live(drone.Model) && drone.MavlinkID == mavlinkID {
+			return true
+		}
+	}
+	return false
+}
+
+// Create implements DroneRepository
+func (r *memoryDroneRepository) Create(ctx context.Context, drone *db.Drone) error {
+	return r.store.view(ctx, func(d *memoryData) error {
+		if mavlinkIDTaken(d, drone.MavlinkID, 0) {
+			return ErrDuplicatedKey
+		}
+		d.nextModel("drones", &drone.Model)
+		d.drones[drone.ID] = *drone
+		return nil
+	})
+}
+
+// Save implements DroneRepository
+func (r *memoryDroneRepository) Save(ctx context.Context, drone *db.Drone) error {
+	if drone.ID == 0 {
+		return r.Create(ctx, drone)
+	}
+	return r.store.view(ctx, func(d *memoryData) error {
+		if mavlinkIDTaken(d, drone.MavlinkID, drone.ID) {
+			return ErrDuplicatedKey
+		}
+		drone.UpdatedAt = time.Now()
+		d.drones[drone.ID] = *drone
+		return nil
+	})
+}
+
+// Update implements DroneRepository
+func (r *memoryDroneRepository) Update(ctx context.Context, drone *db.Drone, fields ...string) error {
+	return r.store.view(ctx, func(d *memoryData) error {
+		stored, ok := d.drones[drone.ID]
+		if !ok || !live(stored.Model) {
+			return ErrNotFound
+		}
+
+		from, to := reflect.ValueOf(drone).Elem(), reflect.ValueOf(&stored).Elem()
+		for _, name := range fields {
+			field := from.FieldByName(name)
+			if !field.IsValid() {
+				return fmt.Errorf("drone has no field %s", name)
+			}
+			to.FieldByName(name).Set(field)
+		}
+		if mavlinkIDTaken(d, stored.MavlinkID, stored.ID) {
+			return ErrDuplicatedKey
+		}
+
+		stored.UpdatedAt = time.Now()
+		drone.UpdatedAt = stored.UpdatedAt
+		d.drones[drone.ID] = stored
+		return nil
+	})
+}
+
+// FindByID implements DroneRepository
+func (r *memoryDroneRepository) FindByID(ctx context.Context, id uint) (*db.Drone, error) {
+	var found db.Drone
+	err := r.store.view(ctx, func(d *memoryData) error {
+		drone, ok := d.drones[id]
+		if !ok || !live(drone.Model) {
+			return ErrNotFound
+		}
+		found = drone
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &found, nil
+}
+
+// FindByMavlinkID implements DroneRepository
+func (r *memoryDroneRepository) FindByMavlinkID(ctx context.Context, mavlinkID string) (*db.Drone, error) {
+	drones, err := r.filter(ctx, func(drone db.Drone) bool { return drone.MavlinkID == mavlinkID })
+	if err != nil {
+		return nil, err
+	}
+	if len(drones) == 0 {
+		return nil, ErrNotFound
+	}
+	return &drones[0], nil
+}
+
+// FindAll implements DroneRepository
+func (r *memoryDroneRepository) FindAll(ctx context.Context) ([]db.Drone, error) {
+	return r.filter(ctx, func(db.Drone) bool { return true })
+}
+
+// FindByIDs implements DroneRepository
+func (r *memoryDroneRepository) FindByIDs(ctx context.Context, ids []uint) ([]db.Drone, error) {
+	wanted := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+	return r.filter(ctx, func(drone db.Drone) bool { return wanted[drone.ID] })
+}
+
+// FindByOwner implements DroneRepository
+func (r *memoryDroneRepository) FindByOwner(ctx context.Context, ownerID uint) ([]db.Drone, error) {
+	return r.filter(ctx, func(drone db.Drone) bool { return drone.OwnerID == int(ownerID) })
+}
+
+// FindByHealthStatus implements DroneRepository
+func (r *memoryDroneRepository) FindByHealthStatus(ctx context.Context, status db.HealthStatus) ([]db.Drone, error) {
+	return r.filter(ctx, func(drone db.Drone) bool { return drone.HealthStatus == status })
+}
+
+// FindByFlightPhase implements DroneRepository
+func (r *memoryDroneRepository) FindByFlightPhase(ctx context.Context, phase db.FlightPhase) ([]db.Drone, error) {
+	return r.filter(ctx, func(drone db.Drone) bool { return drone.FlightPhase == phase })
+}
+
+// Exists implements DroneRepository
+func (r *memoryDroneRepository) Exists(ctx context.Context, id uint) (bool, error) {
+	var found bool
+	err := r.store.view(ctx, func(d *memoryData) error {
+		drone, ok := d.drones[id]
+		found = ok && live(drone.Model)
+		return nil
+	})
+	return found, err
+}
+
+// Delete implements DroneRepository
+func (r *memoryDroneRepository) Delete(ctx context.Context, id uint) error {
+	return r.store.view(ctx, func(d *memoryData) error {
+		drone, ok := d.drones[id]
+		if !ok || !live(drone.Model) {
+			return ErrNotFound
+		}
+		drone.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+		d.drones[id] = drone
+		return nil
+	})
+}
+
+// AddTelemetry implements DroneRepository
+func (r *memoryDroneRepository) AddTelemetry(ctx context.Context, sample *db.Telemetry) error {
+	return r.store.view(ctx, func(d *memoryData) error {
+		for _, existing := range d.telemetry {
+			if existing.DroneID == sample.DroneID && existing.RecordedAt.Equal(sample.RecordedAt) {
+				return nil
+			}
+		}
+		d.telemetry = append(d.telemetry, *sample)
+		return nil
+	})
+}
+
+// FindTelemetry implements DroneRepository
+func (r *memoryDroneRepository) FindTelemetry(ctx context.Context, droneID uint, since, until time.Time, limit int) ([]db.Telemetry, error) {
+	var samples []db.Telemetry
+	err := r.store.view(ctx, func(d *memoryData) error {
+		for _, sample := range d.telemetry {
+			if sample.DroneID != droneID {
+				continue
+			}
+			if !since.IsZero() && sample.RecordedAt.Before(since) {
+				continue
+			}
+			if !until.IsZero() && sample.RecordedAt.After(until) {
+				continue
+			}
+			samples = append(samples, sample)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(samples, func(i, j int) bool { return samples[i].RecordedAt.After(samples[j].RecordedAt) })
+	if limit > 0 && len(samples) > limit {
+		samples = samples[:limit]
+	}
+	return samples, nil
+}
+
+// AddQuarantined implements DroneRepository
+func (r *memoryDroneRepository) AddQuarantined(ctx context.Context, sample *db.QuarantinedSample) error {
+	return r.store.view(ctx, func(d *memoryData) error {
+		d.lastID["quarantined_samples"]++
+		sample.ID = d.lastID["quarantined_samples"]
+		d.quarantine = append(d.quarantine, *sample)
+		return nil
+	})
+}
+
+// FindQuarantined implements DroneRepository
+func (r *memoryDroneRepository) FindQuarantined(ctx context.Context, droneID uint, since, until time.Time, limit int) ([]db.QuarantinedSample, error) {
+	var samples []db.QuarantinedSample
+	err := r.store.view(ctx, func(d *memoryData) error {
+		for _, sample := range d.quarantine {
+			if sample.DroneID != droneID {
+				continue
+			}
+			if !since.IsZero() && sample.ReceivedAt.Before(since) {
+				continue
+			}
+			if !until.IsZero() && sample.ReceivedAt.After(until) {
+				continue
+			}
+			samples = append(samples, sample)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(samples, func(i, j int) bool {
+		if !samples[i].ReceivedAt.Equal(samples[j].ReceivedAt) {
+			return samples[i].ReceivedAt.After(samples[j].ReceivedAt)
+		}
+		return samples[i].ID > samples[j].ID
+	})
+	if limit > 0 && len(samples) > limit {
+		samples = samples[:limit]
+	}
+	return samples, nil
+}
+
+// filter returns the live drones matching keep, ordered by ID.
+func (r *memoryDroneRepository) filter(ctx context.Context, keep func(db.Drone) bool) ([]db.Drone, error) {
+	drones := []db.Drone{}
+	err := r.store.view(ctx, func(d *memoryData) error {
+		for _, drone := range d.drones {
+			if live(drone.Model) && keep(drone) {
+				drones = append(drones, drone)
+			}
+		}
+		return nil
+	})
+	return sortDrones(drones), err
+}
+
+// FindDeleted implements DroneRepository
+func (r *memoryDroneRepository) FindDeleted(ctx context.Context) ([]db.Drone, error) {
+	drones := []db.Drone{}
+	err := r.store.view(ctx, func(d *memoryData) error {
+		for _, drone := range d.drones {
+			if !live(drone.Model) {
+				drones = append(drones, drone)
+			}
+		}
+		return nil
+	})
+	return sortDrones(drones), err
+}
+
+// FindDeletedByID implements DroneRepository
+func (r *memoryDroneRepository) FindDeletedByID(ctx context.Context, id uint) (*db.Drone, error) {
+	var found db.Drone
+	err := r.store.view(ctx, func(d *memoryData) error {
+		drone, ok := d.drones[id]
+		if !ok || live(drone.Model) {
+			return ErrNotFound
+		}
+		found = drone
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &found, nil
+}
+
+// Restore implements DroneRepository
+func (r *memoryDroneRepository) Restore(ctx context.Context, id uint) error {
+	return r.store.view(ctx, func(d *memoryData) error {
+		drone, ok := d.drones[id]
+		if !ok || live(drone.Model) {
+			return ErrNotFound
+		}
+		if mavlinkIDTaken(d, drone.MavlinkID, id) {
+			return ErrDuplicatedKey
+		}
+		drone.DeletedAt = gorm.DeletedAt{}
+		drone.UpdatedAt = time.Now()
+		d.drones[id] = drone
+		return nil
+	})
+}
+
+// Purge implements DroneRepository
+func (r *memoryDroneRepository) Purge(ctx context.Context, id uint) error {
+	return r.store.view(ctx, func(d *memoryData) error {
+		drone, ok := d.drones[id]
+		if !ok || live(drone.Model) {
+			return ErrNotFound
+		}
+		delete(d.drones, id)
+
+		kept := d.telemetry[:0]
+		for _, sample := range d.telemetry {
+			if sample.DroneID != id {
+				kept = append(kept, sample)
+			}
+		}
+		d.telemetry = kept
+
+		quarantine := d.quarantine[:0]
+		for _, sample := range d.quarantine {
+			if sample.DroneID != id {
+				quarantine = append(quarantine, sample)
+			}
+		}
+		d.quarantine = quarantine
+
+		flights := d.flights[:0]
+		for _, flight := range d.flights {
+			if flight.DroneID != id {
+				flights = append(flights, flight)
+			}
+		}
+		d.flights = flights
+
+		for intervalID, interval := range d.intervals {
+			if interval.DroneID == id {
+				delete(d.intervals, intervalID)
+			}
+		}
+
+		logs := d.logs[:0]
+		for _, entry := range d.logs {
+			if entry.DroneID != id {
+				logs = append(logs, entry)
+			}
+		}
+		d.logs = logs
+
+		for packID, pack := range d.batteries {
+			if pack.DroneID == id {
+				pack.DroneID = 0
+				d.batteries[packID] = pack
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"context"
+	"sort"
+
+	"fleet-monitor/backend/db"
+)
+
+type memoryMaintenanceRepository struct {
+	store *memoryStore
+}
+
+// AddFlight implements MaintenanceRepository
+func (r *memoryMaintenanceRepository) AddFlight(ctx context.Context, flight *db.Flight) error {
+	return r.store.view(ctx, func(d *memoryData) error {
+		d.lastID["flights"]++
+		flight.ID = d.lastID["flights"]
+		d.flights = append(d.flights, *flight)
+		return nil
+	})
+}
+
+// FindFlights implements MaintenanceRepository
+func (r *memoryMaintenanceRepository) FindFlights(ctx context.Context, droneID uint) ([]db.Flight, error) {
+	var flights []db.Flight
+	err := r.store.view(ctx, func(d *memoryData) error {
+		for _, flight := range d.flights {
+			if flight.DroneID == droneID {
+				flights = append(flights, flight)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(flights, func(i, j int) bool {
+		if !flights[i].StartedAt.Equal(flights[j].StartedAt) {
+			return flights[i].StartedAt.After(flights[j].StartedAt)
+		}
+		return flights[i].ID > flights[j].ID
+	})
+	return flights, nil
+}
+
+// CreateInterval implements MaintenanceRepository
+func (r *memoryMaintenanceRepository) CreateInterval(ctx context.Context, interval *db.ServiceInterval) error {
+	return r.store.view(ctx, func(d *memoryData) error {
+		d.lastID["service_intervals"]++
+		interval.ID = d.lastID["service_intervals"]
+		d.intervals[interval.ID] = *interval
+		return nil
+	})
+}
+
+// SaveInterval implements MaintenanceRepository
+func (r *memoryMaintenanceRepository) SaveInterval(ctx context.Context, interval *db.ServiceInterval) error {
+	if interval.ID == 0 {
+		return r.CreateInterval(ctx, interval)
+	}
+	return r.store.view(ctx, func(d *memoryData) error {
+		d.intervals[interval.ID] = *interval
+		return nil
+	})
+}
+
+// FindInterval implements MaintenanceRepository
+func (r *memoryMaintenanceRepository) FindInterval(ctx context.Context, droneID, id uint) (*db.ServiceInterval, error) {
+	var interval db.ServiceInterval
+	err := r.store.view(ctx, func(d *memoryData) error {
+		found, ok := d.intervals[id]
+		if !ok || found.DroneID != droneID {
+			return ErrNotFound
+		}
+		interval = found
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &interval, nil
+}
+
+// FindIntervals implements MaintenanceRepository
+func (r *memoryMaintenanceRepository) FindIntervals(ctx context.Context, droneID uint) ([]db.ServiceInterval, error) {
+	var intervals []db.ServiceInterval
+	err := r.store.view(ctx, func(d *memoryData) error {
+		for _, interval := range d.intervals {
+			if interval.DroneID == droneID {
+				intervals = append(intervals, interval)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(intervals, func(i, j int) bool { return intervals[i].ID < intervals[j].ID })
+	return intervals, nil
+}
+
+// DeleteInterval implements MaintenanceRepository
+func (r *memoryMaintenanceRepository) DeleteInterval(ctx context.Context, droneID, id uint) error {
+	return r.store.view(ctx, func(d *memoryData) error {
+		interval, ok := d.intervals[id]
+		if !ok || interval.DroneID != droneID {
+			return ErrNotFound
+		}
+		delete(d.intervals, id)
+		return nil
+	})
+}
+
+// AddLog implements MaintenanceRepository
+func (r *memoryMaintenanceRepository) AddLog(ctx context.Context, entry *db.MaintenanceLog) error {
+	return r.store.view(ctx, func(d *memoryData) error {
+		d.lastID["maintenance_logs"]++
+		entry.ID = d.lastID["maintenance_logs"]
+		d.logs = append(d.logs, *entry)
+		return nil
+	})
+}
+
+// FindLogs implements MaintenanceRepository
+func (r *memoryMaintenanceRepository) FindLogs(ctx context.Context, droneID uint) ([]db.MaintenanceLog, error) {
+	var entries []db.MaintenanceLog
+	err := r.store.view(ctx, func(d *memoryData) error {
+		for _, entry := range d.logs {
+			if entry.DroneID == droneID {
+				entries = append(entries, entry)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].PerformedAt.Equal(entries[j].PerformedAt) {
+			return entries[i].PerformedAt.After(entries[j].PerformedAt)
+		}
+		return entries[i].ID > entries[j].ID
+	})
+	return entries, nil
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"fleet-monitor/backend/db"
+)
+
+type memorySecretRepository struct {
+	store *memoryStore
+}
+
+// Save implements SecretRepository
+func (r *memorySecretRepository) Save(ctx context.Context, secret *db.Secret) error {
+	return r.store.view(ctx, func(d *memoryData) error {
+		for id, other := range d.secrets {
+			if id != secret.ID && other.Name == secret.Name {
+				return ErrDuplicatedKey
+			}
+		}
+
+		now := time.Now()
+		if secret.ID == 0 {
+			d.lastID["secrets"]++
+			secret.ID = d.lastID["secrets"]
+			secret.CreatedAt = now
+		}
+		secret.UpdatedAt = now
+		d.secrets[secret.ID] = *secret
+		return nil
+	})
+}
+
+// FindByName implements SecretRepository
+func (r *memorySecretRepository) FindByName(ctx context.Context, name string) (*db.Secret, error) {
+	secrets, err := r.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range secrets {
+		if secrets[i].Name == name {
+			return &secrets[i], nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// FindAll implements SecretRepository
+func (r *memorySecretRepository) FindAll(ctx context.Context) ([]db.Secret, error) {
+	var secrets []db.Secret
+	err := r.store.view(ctx, func(d *memoryData) error {
+		for _, secret := range d.secrets {
+			secrets = append(secrets, secret)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(secrets, func(i, j int) bool { return secrets[i].Name < secrets[j].Name })
+	return secrets, nil
+}
+
+// DeleteByName implements SecretRepository
+func (r *memorySecretRepository) DeleteByName(ctx context.Context, name string) error {
+	return r.store.view(ctx, func(d *memoryData) error {
+		for id, secret := range d.secrets {
+			if secret.Name == name {
+				delete(d.secrets, id)
+				return nil
+			}
+		}
+		return ErrNotFound
+	})
+}
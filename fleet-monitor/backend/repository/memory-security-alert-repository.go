@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"fleet-monitor/backend/db"
+)
+
+type memorySecurityAlertRepository struct {
+	store *memoryStore
+}
+
+// Add implements SecurityAlertRepository
+func (r *memorySecurityAlertRepository) Add(ctx context.Context, alert *db.SecurityAlert) error {
+	return r.store.view(ctx, func(d *memoryData) error {
+		d.lastID["security_alerts"]++
+		alert.ID = d.lastID["security_alerts"]
+		if alert.CreatedAt.IsZero() {
+			alert.CreatedAt = time.Now()
+		}
+		d.alerts = append(d.alerts, *alert)
+		return nil
+	})
+}
+
+// Find implements SecurityAlertRepository
+func (r *memorySecurityAlertRepository) Find(ctx context.Context, droneID uint, since time.Time, limit int) ([]db.SecurityAlert, error) {
+	var alerts []db.SecurityAlert
+	err := r.store.view(ctx, func(d *memoryData) error {
+		for _, alert := range d.alerts {
+			if droneID != 0 && alert.DroneID != droneID {
+				continue
+			}
+			if !since.IsZero() && alert.CreatedAt.Before(since) {
+				continue
+			}
+			alerts = append(alerts, alert)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(alerts, func(i, j int) bool {
+		if !alerts[i].CreatedAt.Equal(alerts[j].CreatedAt) {
+			return alerts[i].CreatedAt.After(alerts[j].CreatedAt)
+		}
+		return alerts[i].ID > alerts[j].ID
+	})
+	if limit > 0 && len(alerts) > limit {
+		alerts = alerts[:limit]
+	}
+	return alerts, nil
+}
@@ -0,0 +1,197 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"fleet-monitor/backend/db"
+
+	"gorm.io/gorm"
+)
+
+// memoryData holds the rows of a memoryStore. Rows are stored by value so
+// callers never share memory with the store.
+type memoryData struct {
+	users      map[uint]db.User
+	drones     map[uint]db.Drone
+	tasks      map[uint]db.Task
+	telemetry  []db.Telemetry
+	quarantine []db.QuarantinedSample
+	flights    []db.Flight
+	intervals  map[uint]db.ServiceInterval
+	logs       []db.MaintenanceLog
+	batteries  map[uint]db.BatteryPack
+	discharges []db.BatteryDischarge
+	secrets    map[uint]db.Secret
+	alerts     []db.SecurityAlert
+	audit      []db.AuditEntry
+	lastID     map[string]uint
+}
+
+func (d *memoryData) clone() *memoryData {
+	c := &memoryData{
+		users:      make(map[uint]db.User, len(d.users)),
+		drones:     make(map[uint]db.Drone, len(d.drones)),
+		tasks:      make(map[uint]db.Task, len(d.tasks)),
+		telemetry:  append([]db.Telemetry(nil), d.telemetry...),
+		quarantine: append([]db.QuarantinedSample(nil), d.quarantine...),
+		flights:    append([]db.Flight(nil), d.flights...),
+		intervals:  make(map[uint]db.ServiceInterval, len(d.intervals)),
+		logs:       append([]db.MaintenanceLog(nil), d.logs...),
+		batteries:  make(map[uint]db.BatteryPack, len(d.batteries)),
+		discharges: append([]db.BatteryDischarge(nil), d.discharges...),
+		secrets:    make(map[uint]db.Secret, len(d.secrets)),
+		alerts:     append([]db.SecurityAlert(nil), d.alerts...),
+		audit:      append([]db.AuditEntry(nil), d.audit...),
+		lastID:     make(map[string]uint, len(d.lastID)),
+	}
+	for id, user := range d.users {
+		c.users[id] = user
+	}
+	for id, drone := range d.drones {
+		c.drones[id] = drone
+	}
+	for id, task := range d.tasks {
+		c.tasks[id] = task
+	}
+	for id, interval := range d.intervals {
+		c.intervals[id] = interval
+	}
+	for id, pack := range d.batteries {
+		c.batteries[id] = pack
+	}
+	for id, secret := range d.secrets {
+		c.secrets[id] = secret
+	}
+	for table, id := range d.lastID {
+		c.lastID[table] = id
+	}
+	return c
+}
+
+// nextModel assigns the next ID and timestamps of table to model.
+func (d *memoryData) nextModel(table string, model *gorm.Model) {
+	d.lastID[table]++
+	now := time.Now()
+	model.ID = d.lastID[table]
+	model.CreatedAt = now
+	model.UpdatedAt = now
+}
+
+type memoryStore struct {
+	mu   sync.Mutex // guards data
+	txMu sync.Mutex // serializes transactions
+	data *memoryData
+}
+
+// NewMemoryStore creates an empty Store kept in memory, for tests and fakes.
+// It enforces the unique MavlinkID, username, battery serial number and secret name constraints but not foreign keys,
+// and its transactions roll back by restoring a snapshot.
+// Example
+// droneService := service.NewDroneService(repository.NewMemoryStore())
+func NewMemoryStore() Store {
+	return &memoryStore{data: &memoryData{
+		users:     map[uint]db.User{},
+		drones:    map[uint]db.Drone{},
+		tasks:     map[uint]db.Task{},
+		intervals: map[uint]db.ServiceInterval{},
+		batteries: map[uint]db.BatteryPack{},
+		secrets:   map[uint]db.Secret{},
+		lastID:    map[string]uint{},
+	}}
+}
+
+// Drones implements Store
+func (s *memoryStore) Drones() DroneRepository {
+	return &memoryDroneRepository{store: s}
+}
+
+// Tasks implements Store
+func (s *memoryStore) Tasks() TaskRepository {
+	return &memoryTaskRepository{store: s}
+}
+
+// Users implements Store
+func (s *memoryStore) Users() UserRepository {
+	return &memoryUserRepository{store: s}
+}
+
+// Maintenance implements Store
+func (s *memoryStore) Maintenance() MaintenanceRepository {
+	return &memoryMaintenanceRepository{store: s}
+}
+
+// Batteries implements Store
+func (s *memoryStore) Batteries() BatteryRepository {
+	return &memoryBatteryRepository{store: s}
+}
+
+// Secrets implements Store
+func (s *memoryStore) Secrets() SecretRepository {
+	return &memorySecretRepository{store: s}
+}
+
+// SecurityAlerts implements Store
+func (s *memoryStore) SecurityAlerts() SecurityAlertRepository {
+	return &memorySecurityAlertRepository{store: s}
+}
+
+// Audit implements Store
+func (s *memoryStore) Audit() AuditRepository {
+	return &memoryAuditRepository{store: s}
+}
+
+// Transaction implements Store
+func (s *memoryStore) Transaction(ctx context.Context, fn func(tx Store) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+
+	s.mu.Lock()
+	snapshot := s.data.clone()
+	s.mu.Unlock()
+
+	if err := fn(s); err != nil {
+		s.mu.Lock()
+		s.data = snapshot
+		s.mu.Unlock()
+		return err
+	}
+
+	return nil
+}
+
+// view runs fn with the data locked, unless ctx is already done.
+func (s *memoryStore) view(ctx context.Context, fn func(d *memoryData) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return fn(s.data)
+}
+
+func live(model gorm.Model) bool {
+	return !model.DeletedAt.Valid
+}
+
+func sortDrones(drones []db.Drone) []db.Drone {
+	sort.Slice(drones, func(i, j int) bool { return drones[i].ID < drones[j].ID })
+	return drones
+}
+
+func sortTasks(tasks []db.Task) []db.Task {
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID < tasks[j].ID })
+	return tasks
+}
+
+func sortUsers(users []db.User) []db.User {
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	return users
+}
@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"fleet-monitor/backend/db"
+
+	"gorm.io/gorm"
+)
+
+type memoryTaskRepository struct {
+	store *memoryStore
+}
+
+// Create implements TaskRepository
+func (r *memoryTaskRepository) Create(ctx context.Context, task *db.Task) error {
+	return r.store.view(ctx, func(d *memoryData) error {
+		d.nextModel("tasks", &task.Model)
+		d.tasks[task.ID] = *task
+		return nil
+	})
+}
+
+// Save implements TaskRepository
+func (r *memoryTaskRepository) Save(ctx context.Context, task *db.Task) error {
+	if task.ID == 0 {
+		return r.Create(ctx, task)
+	}
+	return r.store.view(ctx, func(d *memoryData) error {
+		task.UpdatedAt = time.Now()
+		d.tasks[task.ID] = *task
+		return nil
+	})
+}
+
+// FindByID implements TaskRepository
+func (r *memoryTaskRepository) FindByID(ctx context.Context, id uint) (*db.Task, error) {
+	var found db.Task
+	err := r.store.view(ctx, func(d *memoryData) error {
+		task, ok := d.tasks[id]
+		if !ok || !live(task.Model) {
+			return ErrNotFound
+		}
+		found = task
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &found, nil
+}
+
+// FindAll implements TaskRepository
+func (r *memoryTaskRepository) FindAll(ctx context.Context) ([]db.Task, error) {
+	return r.filter(ctx, func(db.Task) bool { return true })
+}
+
+// FindByStatus implements TaskRepository
+func (r *memoryTaskRepository) FindByStatus(ctx context.Context, status db.TaskStatus) ([]db.Task, error) {
+	return r.filter(ctx, func(task db.Task) bool { return task.Status == status })
+}
+
+// filter returns the live tasks matching keep, ordered by ID.
+func (r *memoryTaskRepository) filter(ctx context.Context, keep func(db.Task) bool) ([]db.Task, error) {
+	tasks := []db.Task{}
+	err := r.store.view(ctx, func(d *memoryData) error {
+		for _, task := range d.tasks {
+			if live(task.Model) && keep(task) {
+				tasks = append(tasks, task)
+			}
+		}
+		return nil
+	})
+	return sortTasks(tasks), err
+}
+
+// FindByUser implements TaskRepository
+func (r *memoryTaskRepository) FindByUser(ctx context.Context, userID uint) ([]db.Task, error) {
+	return r.filter(ctx, func(task db.Task) bool { return task.UserID == int(userID) })
+}
+
+// FindByDrone implements TaskRepository
+func (r *memoryTaskRepository) FindByDrone(ctx context.Context, droneID uint) ([]db.Task, error) {
+	return r.filter(ctx, func(task db.Task) bool { return task.DroneID == int(droneID) })
+}
+
+// Exists implements TaskRepository
+func (r *memoryTaskRepository) Exists(ctx context.Context, id uint) (bool, error) {
+	var found bool
+	err := r.store.view(ctx, func(d *memoryData) error {
+		task, ok := d.tasks[id]
+		found = ok && live(task.Model)
+		return nil
+	})
+	return found, err
+}
+
+// Delete implements TaskRepository
+func (r *memoryTaskRepository) Delete(ctx context.Context, id uint) error {
+	return r.store.view(ctx, func(d *memoryData) error {
+		task, ok := d.tasks[id]
+		if !ok || !live(task.Model) {
+			return ErrNotFound
+		}
+		task.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+		d.tasks[id] = task
+		return nil
+	})
+}
+
+// FindDeleted implements TaskRepository
+func (r *memoryTaskRepository) FindDeleted(ctx context.Context) ([]db.Task, error) {
+	tasks := []db.Task{}
+	err := r.store.view(ctx, func(d *memoryData) error {
+		for _, task := range d.tasks {
+			if !live(task.Model) {
+				tasks = append(tasks, task)
+			}
+		}
+		return nil
+	})
+	return sortTasks(tasks), err
+}
+
+// FindDeletedByID implements TaskRepository
+func (r *memoryTaskRepository) FindDeletedByID(ctx context.Context, id uint) (*db.Task, error) {
+	var found db.Task
+	err := r.store.view(ctx, func(d *memoryData) error {
+		task, ok := d.tasks[id]
+		if !ok || live(task.Model) {
+			return ErrNotFound
+		}
+		found = task
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &found, nil
+}
+
+// Restore implements TaskRepository
+func (r *memoryTaskRepository) Restore(ctx context.Context, id uint) error {
+	return r.store.view(ctx, func(d *memoryData) error {
+		task, ok := d.tasks[id]
+		if !ok || live(task.Model) {
+			return ErrNotFound
+		}
+		task.DeletedAt = gorm.DeletedAt{}
+		task.UpdatedAt = time.Now()
+		d.tasks[id] = task
+		return nil
+	})
+}
+
+// Purge implements TaskRepository
+func (r *memoryTaskRepository) Purge(ctx context.Context, id uint) error {
+	return r.store.view(ctx, func(d *memoryData) error {
+		task, ok := d.tasks[id]
+		if !ok || live(task.Model) {
+			return ErrNotFound
+		}
+		delete(d.tasks, id)
+		return nil
+	})
+}
@@ -0,0 +1,196 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"fleet-monitor/backend/db"
+
+	"gorm.io/gorm"
+)
+
+type memoryUserRepository struct {
+	store *memoryStore
+}
+
+// userNameTaken reports whether a live user other than exceptID uses userName.
+func userNameTaken(d *memoryData, userName string, exceptID uint) bool {
+	for id, user := range d.users {
+		if id != exceptID && live(user.Model) && user.UserName == userName {
+			return true
+		}
+	}
+	return false
+}
+
+// Create implements UserRepository
+func (r *memoryUserRepository) Create(ctx context.Context, user *db.User) error {
+	return r.store.view(ctx, func(d *memoryData) error {
+		if userNameTaken(d, user.UserName, 0) {
+			return ErrDuplicatedKey
+		}
+		d.nextModel("users", &user.Model)
+		d.users[user.ID] = *user
+		return nil
+	})
+}
+
+// Save implements UserRepository
+func (r *memoryUserRepository) Save(ctx context.Context, user *db.User) error {
+	if user.ID == 0 {
+		return r.Create(ctx, user)
+	}
+	return r.store.view(ctx, func(d *memoryData) error {
+		if userNameTaken(d, user.UserName, user.ID) {
+			return ErrDuplicatedKey
+		}
+		user.UpdatedAt = time.Now()
+		d.users[user.ID] = *user
+		return nil
+	})
+}
+
+// FindByID implements UserRepository
+func (r *memoryUserRepository) FindByID(ctx context.Context, id uint) (*db.User, error) {
+	var found db.User
+	err := r.store.view(ctx, func(d *memoryData) error {
+		user, ok := d.users[id]
+		if !ok || !live(user.Model) {
+			return ErrNotFound
+		}
+		found = user
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &found, nil
+}
+
+// FindByUserName implements UserRepository
+func (r *memoryUserRepository) FindByUserName(ctx context.Context, userName string) (*db.User, error) {
+	users, err := r.filter(ctx, func(user db.User) bool { return user.UserName == userName })
+	if err != nil {
+		return nil, err
+	}
+	if len(users) == 0 {
+		return nil, ErrNotFound
+	}
+	return &users[0], nil
+}
+
+// FindAll implements UserRepository
+func (r *memoryUserRepository) FindAll(ctx context.Context) ([]db.User, error) {
+	return r.filter(ctx, func(db.User) bool { return true })
+}
+
+// UserNames implements UserRepository
+func (r *memoryUserRepository) UserNames(ctx context.Context) ([]string, error) {
+	users, err := r.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	userNames := make([]string, 0, len(users))
+	for _, user := range users {
+		userNames = append(userNames, user.UserName)
+	}
+	return userNames, nil
+}
+
+// Exists implements UserRepository
+func (r *memoryUserRepository) Exists(ctx context.Context, id uint) (bool, error) {
+	var found bool
+	err := r.store.view(ctx, func(d *memoryData) error {
+		user, ok := d.users[id]
+		found = ok && live(user.Model)
+		return nil
+	})
+	return found, err
+}
+
+// Delete implements UserRepository
+func (r *memoryUserRepository) Delete(ctx context.Context, id uint) error {
+	return r.store.view(ctx, func(d *memoryData) error {
+		user, ok := d.users[id]
+		if !ok || !live(user.Model) {
+			return ErrNotFound
+		}
+		user.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+		d.users[id] = user
+		return nil
+	})
+}
+
+// filter returns the live users matching keep, ordered by ID.
+func (r *memoryUserRepository) filter(ctx context.Context, keep func(db.User) bool) ([]db.User, error) {
+	users := []db.User{}
+	err := r.store.view(ctx, func(d *memoryData) error {
+		for _, user := range d.users {
+			if live(user.Model) && keep(user) {
+				users = append(users, user)
+			}
+		}
+		return nil
+	})
+	return sortUsers(users), err
+}
+
+// FindDeleted implements UserRepository
+func (r *memoryUserRepository) FindDeleted(ctx context.Context) ([]db.User, error) {
+	users := []db.User{}
+	err := r.store.view(ctx, func(d *memoryData) error {
+		for _, user := range d.users {
+			if !live(user.Model) {
+				users = append(users, user)
+			}
+		}
+		return nil
+	})
+	return sortUsers(users), err
+}
+
+// FindDeletedByID implements UserRepository
+func (r *memoryUserRepository) FindDeletedByID(ctx context.Context, id uint) (*db.User, error) {
+	var found db.User
+	err := r.store.view(ctx, func(d *memoryData) error {
+		user, ok := d.users[id]
+		if !ok || live(user.Model) {
+			return ErrNotFound
+		}
+		found = user
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &found, nil
+}
+
+// Restore implements UserRepository
+func (r *memoryUserRepository) Restore(ctx context.Context, id uint) error {
+	return r.store.view(ctx, func(d *memoryData) error {
+		user, ok := d.users[id]
+		if !ok || live(user.Model) {
+			return ErrNotFound
+		}
+		if userNameTaken(d, user.UserName, id) {
+			return ErrDuplicatedKey
+		}
+		user.DeletedAt = gorm.DeletedAt{}
+		user.UpdatedAt = time.Now()
+		d.users[id] = user
+		return nil
+	})
+}
+
+// Purge implements UserRepository
+func (r *memoryUserRepository) Purge(ctx context.Context, id uint) error {
+	return r.store.view(ctx, func(d *memoryData) error {
+		user, ok := d.users[id]
+		if !ok || live(user.Model) {
+			return ErrNotFound
+		}
+		delete(d.users, id)
+		return nil
+	})
+}
@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"fleet-monitor/backend/db"
+)
+
+// Errors returned by every repository implementation, so services do not depend on
+// how a store reports missing rows or constraint violations.
+var (
+	ErrNotFound           = errors.New("record not found")
+	ErrDuplicatedKey      = errors.New("duplicated key")
+	ErrForeignKeyViolated = errors.New("foreign key violated")
+)
+
+// Store gives access to the repositories and runs work atomically.
+type Store interface {
+	Drones() DroneRepository
+	Tasks() TaskRepository
+	Users() UserRepository
+	Maintenance() MaintenanceRepository
+	Batteries() BatteryRepository
+	Secrets() SecretRepository
+	SecurityAlerts() SecurityAlertRepository
+	Audit() AuditRepository
+	// Transaction runs fn with a Store whose changes are committed if fn returns nil
+	// and rolled back otherwise. Transactions must not be nested.
+	Transaction(ctx context.Context, fn func(tx Store) error) error
+}
+
+// DroneRepository stores drones and their telemetry history.
+// Soft-deleted drones are invisible to every method except the *Deleted*, Restore and Purge ones.
+type DroneRepository interface {
+	Create(ctx context.Context, drone *db.Drone) error
+	Save(ctx context.Context, drone *db.Drone) error
+	// Update writes only the named fields of drone and its update time, leaving the
+	// rest of the stored drone as it is. Fields are named as in db.Drone; a struct
+	// such as GPS stands for all of its columns.
+	Update(ctx context.Context, drone *db.Drone, fields ...string) error
+	FindByID(ctx context.Context, id uint) (*db.Drone, error)
+	FindByMavlinkID(ctx context.Context, mavlinkID string) (*db.Drone, error)
+	FindAll(ctx context.Context) ([]db.Drone, error)
+	FindByIDs(ctx context.Context, ids []uint) ([]db.Drone, error)
+	FindByOwner(ctx context.Context, ownerID uint) ([]db.Drone, error)
+	FindByHealthStatus(ctx context.Context, status db.HealthStatus) ([]db.Drone, error)
+	FindByFlightPhase(ctx context.Context, phase db.FlightPhase) ([]db.Drone, error)
+	Exists(ctx context.Context, id uint) (bool, error)
+	Delete(ctx context.Context, id uint) error
+
+	// FindDeleted returns the soft-deleted drones, ordered by ID.
+	FindDeleted(ctx context.Context) ([]db.Drone, error)
+	FindDeletedByID(ctx context.Context, id uint) (*db.Drone, error)
+	// Restore clears the deletion mark of a soft-deleted drone.
+	Restore(ctx context.Context, id uint) error
+	// Purge permanently removes a soft-deleted drone together with its telemetry history,
+	// quarantined samples, flights, service intervals and maintenance log. Battery packs installed in it are removed from it.
+	Purge(ctx context.Context, id uint) error
+
+	// AddTelemetry stores sample. A sample for the same drone and time as a stored one
+	// is dropped rather than failing, so simultaneous updates never conflict.
+	AddTelemetry(ctx context.Context, sample *db.Telemetry) error
+	// FindTelemetry returns samples newest first; zero times leave the range open
+	// and limit <= 0 means no limit.
+	FindTelemetry(ctx context.Context, droneID uint, since, until time.Time, limit int) ([]db.Telemetry, error)
+
+	AddQuarantined(ctx context.Context, sample *db.QuarantinedSample) error
+	// FindQuarantined returns quarantined samples newest first, filtered like FindTelemetry.
+	FindQuarantined(ctx context.Context, droneID uint, since, until time.Time, limit int) ([]db.QuarantinedSample, error)
+}
+
+// TaskRepository stores tasks.
+// Soft-deleted tasks are invisible to every method except the *Deleted*, Restore and Purge ones.
+type TaskRepository interface {
+	Create(ctx context.Context, task *db.Task) error
+	Save(ctx context.Context, task *db.Task) error
+	FindByID(ctx context.Context, id uint) (*db.Task, error)
+	FindAll(ctx context.Context) ([]db.Task, error)
+	FindByStatus(ctx context.Context, status db.TaskStatus) ([]db.Task, error)
+	FindByUser(ctx context.Context, userID uint) ([]db.Task, error)
+	FindByDrone(ctx context.Context, droneID uint) ([]db.Task, error)
+	Exists(ctx context.Context, id uint) (bool, error)
+	Delete(ctx context.Context, id uint) error
+
+	// FindDeleted returns the soft-deleted tasks, ordered by ID.
+	FindDeleted(ctx context.Context) ([]db.Task, error)
+	FindDeletedByID(ctx context.Context, id uint) (*db.Task, error)
+	// Restore clears the deletion mark of a soft-deleted task.
+	Restore(ctx context.Context, id uint) error
+	// Purge permanently removes a soft-deleted task.
+	Purge(ctx context.Context, id uint) error
+}
+
+// UserRepository stores users.
+// Soft-deleted users are invisible to every method except the *Deleted*, Restore and Purge ones.
+type UserRepository interface {
+	Create(ctx context.Context, user *db.User) error
+	Save(ctx context.Context, user *db.User) error
+	FindByID(ctx context.Context, id uint) (*db.User, error)
+	FindByUserName(ctx context.Context, userName string) (*db.User, error)
+	FindAll(ctx context.Context) ([]db.User, error)
+	UserNames(ctx context.Context) ([]string, error)
+	Exists(ctx context.Context, id uint) (bool, error)
+	Delete(ctx context.Context, id uint) error
+
+	// FindDeleted returns the soft-deleted users, ordered by ID.
+	FindDeleted(ctx context.Context) ([]db.User, error)
+	FindDeletedByID(ctx context.Context, id uint) (*db.User, error)
+	// Restore clears the deletion mark of a soft-deleted user.
+	Restore(ctx context.Context, id uint) error
+	// Purge permanently removes a soft-deleted user.
+	Purge(ctx context.Context, id uint) error
+}
+
+// MaintenanceRepository stores the flights, service intervals and maintenance log of drones.
+// Lists are ordered newest first, except intervals which are ordered by ID.
+type MaintenanceRepository interface {
+	AddFlight(ctx context.Context, flight *db.Flight) error
+	FindFlights(ctx context.Context, droneID uint) ([]db.Flight, error)
+
+	CreateInterval(ctx context.Context, interval *db.ServiceInterval) error
+	SaveInterval(ctx context.Context, interval *db.ServiceInterval) error
+	// FindInterval returns ErrNotFound unless the interval belongs to the given drone.
+	FindInterval(ctx context.Context, droneID, id uint) (*db.ServiceInterval, error)
+	FindIntervals(ctx context.Context, droneID uint) ([]db.ServiceInterval, error)
+	DeleteInterval(ctx context.Context, droneID, id uint) error
+
+	AddLog(ctx context.Context, entry *db.MaintenanceLog) error
+	FindLogs(ctx context.Context, droneID uint) ([]db.MaintenanceLog, error)
+}
+
+// BatteryRepository stores battery packs and their discharge history.
+type BatteryRepository interface {
+	Create(ctx context.Context, pack *db.BatteryPack) error
+	Save(ctx context.Context, pack *db.BatteryPack) error
+	FindByID(ctx context.Context, id uint) (*db.BatteryPack, error)
+	FindBySerialNumber(ctx context.Context, serialNumber string) (*db.BatteryPack, error)
+	FindAll(ctx context.Context) ([]db.BatteryPack, error)
+	// FindByDrone returns the pack installed in the drone, or ErrNotFound.
+	FindByDrone(ctx context.Context, droneID uint) (*db.BatteryPack, error)
+
+	AddDischarge(ctx context.Context, discharge *db.BatteryDischarge) error
+	// FindDischarges returns the pack's discharges newest first; limit <= 0 means no limit.
+	FindDischarges(ctx context.Context, packID uint, limit int) ([]db.BatteryDischarge, error)
+}
+
+// SecretRepository stores named secrets. Values are encrypted by the gorm store and
+// kept as they are by the memory store.
+type SecretRepository interface {
+	Save(ctx context.Context, secret *db.Secret) error
+	FindByName(ctx context.Context, name string) (*db.Secret, error)
+	// FindAll returns the secrets ordered by name.
+	FindAll(ctx context.Context) ([]db.Secret, error)
+	// DeleteByName removes a secret without decrypting it, so one encrypted with a
+	// lost key can still be removed.
+	DeleteByName(ctx context.Context, name string) error
+}
+
+// SecurityAlertRepository stores the security alerts raised by MAVLink frames.
+type SecurityAlertRepository interface {
+	Add(ctx context.Context, alert *db.SecurityAlert) error
+	// Find returns alerts newest first. A zero droneID or since leaves that filter out
+	// and limit <= 0 means no limit.
+	Find(ctx context.Context, droneID uint, since time.Time, limit int) ([]db.SecurityAlert, error)
+}
+
+// AuditRepository stores the audit log. It is append-only: entries are never changed
+// or removed.
+type AuditRepository interface {
+	Add(ctx context.Context, entry *db.AuditEntry) error
+	// Find returns the entries matching filter newest first.
+	Find(ctx context.Context, filter AuditFilter) ([]db.AuditEntry, error)
+}
+
+// AuditFilter selects audit entries. Zero fields leave that filter out and a Limit
+// <= 0 means no limit.
+type AuditFilter struct {
+	Actor     string
+	Action    db.AuditAction
+	Entity    string
+	EntityID  uint
+	RequestID string
+	Since     time.Time
+	Until     time.Time
+	Limit     int
+}
@@ -0,0 +1,90 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+
+	"fleet-monitor/backend/utils"
+
+	"gorm.io/gorm"
+)
+
+// Column is a database column holding values encrypted with the master key. Its
+// table must have an id primary key.
+type Column struct {
+	Table  string
+	Column string
+}
+
+// Rotate re-encrypts every value of columns from one master key to another and
+// returns how many values it changed. Values of the legacy format are upgraded on the
+// way. Run it in a transaction: a value that does not decrypt with from fails the
+// rotation, which must then leave every value as it was.
+// Example
+// err := conn.Transaction(func(tx *gorm.DB) error { n, err = secrets.Rotate(ctx, tx, db.EncryptedColumns, oldBox, newBox); return err })
+func Rotate(ctx context.Context, tx *gorm.DB, columns []Column, from, to *Box) (int, error) {
+	return reencrypt(ctx, tx, columns, func(value string) (string, bool, error) {
+		plaintext, err := from.Decrypt(value)
+		if err != nil {
+			return "", false, err
+		}
+		ciphertext, err := to.Encrypt(plaintext)
+		return ciphertext, err == nil, err
+	})
+}
+
+// Upgrade re-encrypts the values of columns still in the legacy AesWithSalt format
+// with the active Box and returns how many it changed. Without legacy values it does
+// not need a master key; with some it fails with ErrNoMasterKey if there is none.
+// Run it in a transaction, like Rotate.
+// Example
+// err := conn.Transaction(func(tx *gorm.DB) error { n, err = secrets.Upgrade(ctx, tx, db.EncryptedColumns); return err })
+func Upgrade(ctx context.Context, tx *gorm.DB, columns []Column) (int, error) {
+	return reencrypt(ctx, tx, columns, func(value string) (string, bool, error) {
+		if !utils.IsLegacyCiphertext(value) {
+			return value, false, nil
+		}
+		box, err := activeBox()
+		if err != nil {
+			return "", false, err
+		}
+		return box.Upgrade(value)
+	})
+}
+
+// reencrypt replaces the non-empty values of columns for which fn reports a change.
+func reencrypt(ctx context.Context, tx *gorm.DB, columns []Column, fn func(value string) (string, bool, error)) (int, error) {
+	changed := 0
+	for _, column := range columns {
+		var rows []struct {
+			ID    uint
+			Value string
+		}
+		name := tx.Statement.Quote(column.Column)
+		err := tx.WithContext(ctx).Table(column.Table).
+			Select("id, " + name + " AS value").
+			Where(name + " <> ''").
+			Scan(&rows).Error
+		if err != nil {
+			return changed, err
+		}
+
+		for _, row := range rows {
+			ciphertext, ok, err := fn(row.Value)
+			if err != nil {
+				return changed, fmt.Errorf("%s.%s of id %d: %w", column.Table, column.Column, row.ID, err)
+			}
+			if !ok {
+				continue
+			}
+
+			err = tx.WithContext(ctx).Table(column.Table).Where("id = ?", row.ID).
+				UpdateColumn(column.Column, ciphertext).Error
+			if err != nil {
+				return changed, err
+			}
+			changed++
+		}
+	}
+	return changed, nil
+}
@@ -0,0 +1,171 @@
+// Package secrets encrypts sensitive values at rest under a master key: config values
+// written as "enc:<ciphertext>" and database columns tagged `gorm:"serializer:encrypted"`.
+// The master key comes from FLEET_MONITOR_MASTER_KEY_FILE or FLEET_MONITOR_MASTER_KEY
+// and never reaches the database.
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"fleet-monitor/backend/utils"
+)
+
+const (
+	// MasterKeyEnv holds the master key itself.
+	MasterKeyEnv = "FLEET_MONITOR_MASTER_KEY"
+	// MasterKeyFileEnv holds the path of a file containing the master key, which is
+	// preferred over MasterKeyEnv so the key does not show in the environment.
+	MasterKeyFileEnv = "FLEET_MONITOR_MASTER_KEY_FILE"
+
+	// ValuePrefix marks an encrypted config value.
+	ValuePrefix = "enc:"
+
+	minKeyLength = 16
+)
+
+// ErrNoMasterKey is returned when a value has to be encrypted or decrypted but no
+// master key is configured.
+var ErrNoMasterKey = errors.New("no master key, set " + MasterKeyEnv + " or " + MasterKeyFileEnv)
+
+// LoadMasterKey reads the master key from keyFile, or else from the file named by
+// FLEET_MONITOR_MASTER_KEY_FILE, or else from FLEET_MONITOR_MASTER_KEY. Surrounding
+// whitespace, such as the newline ending a key file, is ignored.
+// Example
+// key, err := secrets.LoadMasterKey("")
+func LoadMasterKey(keyFile string) (string, error) {
+	if keyFile == "" {
+		keyFile = os.Getenv(MasterKeyFileEnv)
+	}
+
+	key := os.Getenv(MasterKeyEnv)
+	if keyFile != "" {
+		data, err := os.ReadFile(keyFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read master key: %w", err)
+		}
+		key = string(data)
+	}
+
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return "", ErrNoMasterKey
+	}
+	return key, nil
+}
+
+// Box encrypts and decrypts values under one master key. Values are sealed in a
+// utils.Envelope; those encrypted with AesWithSalt before the envelope existed still
+// decrypt, and Upgrade rewrites them.
+type Box struct {
+	cipher *utils.Envelope
+}
+
+// NewBox creates a Box for masterKey, which must be at least 16 characters long.
+// Example
+// box, err := secrets.NewBox(key)
+// ciphertext, err := box.Encrypt("hunter2")
+func NewBox(masterKey string) (*Box, error) {
+	if len(masterKey) < minKeyLength {
+		return nil, fmt.Errorf("master key must be at least %d characters long", minKeyLength)
+	}
+	return &Box{cipher: utils.NewEnvelope(masterKey)}, nil
+}
+
+// Encrypt encrypts plaintext with a fresh nonce, so equal values never look alike.
+func (b *Box) Encrypt(plaintext string) (string, error) {
+	return b.cipher.Encrypt(plaintext)
+}
+
+// Decrypt decrypts a value returned by Encrypt, in the current or the legacy format.
+func (b *Box) Decrypt(ciphertext string) (string, error) {
+	plaintext, err := b.cipher.Decrypt(ciphertext)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt, wrong master key or corrupted value: %w", err)
+	}
+	return plaintext, nil
+}
+
+// Upgrade re-encrypts a value of the legacy format in the current one and reports
+// whether it did. Values in the current format are returned unchanged.
+func (b *Box) Upgrade(ciphertext string) (string, bool, error) {
+	upgraded, ok, err := b.cipher.Upgrade(ciphertext)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to decrypt, wrong master key or corrupted value: %w", err)
+	}
+	return upgraded, ok, nil
+}
+
+// active is the Box used by the encrypted column serializer and Reveal.
+var active struct {
+	sync.RWMutex
+	box *Box
+}
+
+// Use makes box the one that encrypts database columns and decrypts config values.
+// Call it once at startup, before the database is used.
+func Use(box *Box) {
+	active.Lock()
+	defer active.Unlock()
+	active.box = box
+}
+
+// UseMasterKey loads the master key as LoadMasterKey does and uses it. Having no
+// master key is not an error; encrypted values then fail with ErrNoMasterKey.
+func UseMasterKey(keyFile string) error {
+	key, err := LoadMasterKey(keyFile)
+	if errors.Is(err, ErrNoMasterKey) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	box, err := NewBox(key)
+	if err != nil {
+		return err
+	}
+	Use(box)
+	return nil
+}
+
+func activeBox() (*Box, error) {
+	active.RLock()
+	defer active.RUnlock()
+	if active.box == nil {
+		return nil, ErrNoMasterKey
+	}
+	return active.box, nil
+}
+
+// EncryptValue encrypts a config value, e.g. a database URL with a password, into the
+// "enc:..." form Reveal accepts.
+func EncryptValue(plaintext string) (string, error) {
+	box, err := activeBox()
+	if err != nil {
+		return "", err
+	}
+	ciphertext, err := box.Encrypt(plaintext)
+	if err != nil {
+		return "", err
+	}
+	return ValuePrefix + ciphertext, nil
+}
+
+// Reveal decrypts value if it is an encrypted config value and returns it unchanged
+// otherwise.
+// Example
+// dsn, err := secrets.Reveal(*dsnFlag)
+func Reveal(value string) (string, error) {
+	if !strings.HasPrefix(value, ValuePrefix) {
+		return value, nil
+	}
+	box, err := activeBox()
+	if err != nil {
+		return "", err
+	}
+	return box.Decrypt(strings.TrimPrefix(value, ValuePrefix))
+}
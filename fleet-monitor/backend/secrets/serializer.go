@@ -0,0 +1,66 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm/schema"
+)
+
+// SerializerName is the gorm serializer that encrypts string columns with the active
+// Box. An empty string is stored as is, so unset values stay recognisable.
+// Example
+// APIKey string `gorm:"serializer:encrypted"`
+const SerializerName = "encrypted"
+
+func init() {
+	schema.RegisterSerializer(SerializerName, encryptedSerializer{})
+}
+
+type encryptedSerializer struct{}
+
+// Scan implements schema.SerializerInterface
+func (encryptedSerializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	var ciphertext string
+	switch v := dbValue.(type) {
+	case nil:
+	case string:
+		ciphertext = v
+	case []byte:
+		ciphertext = string(v)
+	default:
+		return fmt.Errorf("encrypted column %s holds %T, expected a string", field.DBName, dbValue)
+	}
+
+	plaintext := ""
+	if ciphertext != "" {
+		box, err := activeBox()
+		if err != nil {
+			return err
+		}
+		if plaintext, err = box.Decrypt(ciphertext); err != nil {
+			return fmt.Errorf("encrypted column %s: %w", field.DBName, err)
+		}
+	}
+
+	field.ReflectValueOf(ctx, dst).SetString(plaintext)
+	return nil
+}
+
+// Value implements schema.SerializerValuerInterface
+func (encryptedSerializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	plaintext, ok := fieldValue.(string)
+	if !ok {
+		return nil, fmt.Errorf("encrypted column %s must be a string, not %T", field.DBName, fieldValue)
+	}
+	if plaintext == "" {
+		return "", nil
+	}
+
+	box, err := activeBox()
+	if err != nil {
+		return nil, err
+	}
+	return box.Encrypt(plaintext)
+}
@@ -0,0 +1,245 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+
+	"fleet-monitor/backend/db"
+	"fleet-monitor/backend/repository"
+	"fleet-monitor/backend/utils"
+
+	"gorm.io/gorm"
+)
+
+// Entities named in the audit log.
+const (
+	AuditEntityDrone             = "drone"
+	AuditEntityTask              = "task"
+	AuditEntityUser              = "user"
+	AuditEntityQuarantinedSample = "quarantined_sample"
+	AuditEntityBatteryPack       = "battery_pack"
+)
+
+// AuditService reads the audit log of the changes made through DroneService,
+// TaskService and UserService, along with signing changes, battery installs and
+// drones grounded or cleared by their service intervals. The services write it
+// themselves, in the transaction making the change.
+type AuditService interface {
+	GetEntries(ctx context.Context, filter AuditFilter) ([]db.AuditEntry, error)
+}
+
+// AuditFilter selects audit entries. Zero fields leave that filter out and a Limit
+// <= 0 returns every entry.
+type AuditFilter struct {
+	Actor     string
+	Action    db.AuditAction
+	Entity    string
+	EntityID  uint
+	RequestID string
+	Since     time.Time
+	Until     time.Time
+	Limit     int
+}
+
+// Actor is who makes the changes of a context, as recorded in the audit log.
+type Actor struct {
+	Name     string
+	SourceIP string
+}
+
+// ActorSystem makes the changes of contexts without an actor, such as those of
+// `listen` and the retention purge.
+const ActorSystem = "system"
+
+type actorKey struct{}
+
+// WithActor returns a copy of ctx whose changes are recorded as made by actor.
+// Example
+// ctx = service.WithActor(ctx, service.Actor{Name: "alice", SourceIP: "192.0.2.10"})
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// actorOf returns the actor of ctx, ActorSystem if it has none.
+func actorOf(ctx context.Context) Actor {
+	actor, _ := ctx.Value(actorKey{}).(Actor)
+	if actor.Name == "" {
+		actor.Name = ActorSystem
+	}
+	return actor
+}
+
+type telemetryIngestKey struct{}
+
+// WithTelemetryIngest returns a copy of ctx whose real-time drone updates and
+// quarantined samples are left out of the audit log. Telemetry ingest such as `listen`
+// makes them every second for each drone, and the telemetry history and quarantine
+// keep them already. The same changes made through the API are audited.
+// Example
+// ctx = service.WithTelemetryIngest(ctx)
+func WithTelemetryIngest(ctx context.Context) context.Context {
+	return context.WithValue(ctx, telemetryIngestKey{}, true)
+}
+
+// isTelemetryIngest reports whether ctx was marked by WithTelemetryIngest.
+func isTelemetryIngest(ctx context.Context) bool {
+	ingest, _ := ctx.Value(telemetryIngestKey{}).(bool)
+	return ingest
+}
+
+type auditService struct {
+	store repository.Store
+}
+
+// NewAuditService creates a new AuditService backed by the given store.
+// Example
+// auditService := service.NewAuditService(repository.NewGormStore(db))
+func NewAuditService(store repository.Store) AuditService {
+	return &auditService{store: store}
+}
+
+// GetEntries returns the audit entries matching filter, newest first.
+// Example
+// entries, err := auditService.GetEntries(ctx, service.AuditFilter{Entity: "drone", EntityID: 7, Limit: 100})
+func (s *auditService) GetEntries(ctx context.Context, filter AuditFilter) ([]db.AuditEntry, error) {
+	if filter.Action != "" {
+		if err := validateEnum("action", filter.Action); err != nil {
+			return nil, err
+		}
+	}
+	if !filter.Since.IsZero() {
+		filter.Since = filter.Since.UTC()
+	}
+	if !filter.Until.IsZero() {
+		filter.Until = filter.Until.UTC()
+	}
+
+	entries, err := s.store.Audit().Find(ctx, repository.AuditFilter{
+		Actor:     filter.Actor,
+		Action:    filter.Action,
+		Entity:    filter.Entity,
+		EntityID:  filter.EntityID,
+		RequestID: filter.RequestID,
+		Since:     filter.Since,
+		Until:     filter.Until,
+		Limit:     filter.Limit,
+	})
+	if err != nil {
+		return nil, dbError(err, "audit entry", nil)
+	}
+	return entries, nil
+}
+
+// audit records a change of the entity with the given ID in the audit log of store,
+// which must be the store making the change so both are committed together. before is
+// nil for created records and after for deleted ones; both are db models.
+func audit(ctx context.Context, store repository.Store, action db.AuditAction, entity string, id uint, before, after interface{}) error {
+	return addAuditEntry(ctx, store, action, entity, id, auditChanges(before, after))
+}
+
+// addAuditEntry records changes as audit does, for callers that add changes of their
+// own to those of the model.
+func addAuditEntry(ctx context.Context, store repository.Store, action db.AuditAction, entity string, id uint, changes db.AuditChanges) error {
+	actor := actorOf(ctx)
+	entry := &db.AuditEntry{
+		CreatedAt: time.Now().UTC(),
+		Actor:     actor.Name,
+		Action:    action,
+		Entity:    entity,
+		EntityID:  id,
+		Changes:   changes,
+		RequestID: utils.RequestID(ctx),
+		SourceIP:  actor.SourceIP,
+	}
+	if err := store.Audit().Add(ctx, entry); err != nil {
+		return dbError(err, "audit entry", nil)
+	}
+	return nil
+}
+
+// auditChanges lists the fields that differ between before and after. When either is
+// nil, the fields of the other one that are set are listed.
+func auditChanges(before, after interface{}) db.AuditChanges {
+	old, updated := auditFields(before), auditFields(after)
+	changes := db.AuditChanges{}
+	switch {
+	case after == nil:
+		for name, value := range old {
+			if !reflect.ValueOf(value).IsZero() {
+				changes[name] = db.AuditChange{Before: value}
+			}
+		}
+	case before == nil:
+		for name, value := range updated {
+			if !reflect.ValueOf(value).IsZero() {
+				changes[name] = db.AuditChange{After: value}
+			}
+		}
+	default:
+		for name, value := range old {
+			if !sameJSON(value, updated[name]) {
+				changes[name] = db.AuditChange{Before: value, After: updated[name]}
+			}
+		}
+	}
+	return changes
+}
+
+var gormModelType = reflect.TypeOf(gorm.Model{})
+
+// auditFields returns the fields of a db model by their JSON name, leaving out the
+// gorm.Model bookkeeping, the timestamps of models declaring their own, associations
+// and fields hidden from JSON.
+func auditFields(model interface{}) map[string]interface{} {
+	fields := map[string]interface{}{}
+	if model == nil {
+		return fields
+	}
+	v := reflect.Indirect(reflect.ValueOf(model))
+	collectAuditFields(v, fields)
+	return fields
+}
+
+func collectAuditFields(v reflect.Value, fields map[string]interface{}) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" || field.Type == gormModelType || field.Name == "CreatedAt" || field.Name == "UpdatedAt" {
+			continue
+		}
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			collectAuditFields(v.Field(i), fields)
+			continue
+		}
+		if field.Type.Kind() == reflect.Slice || strings.Contains(field.Tag.Get("gorm"), "foreignKey") {
+			continue
+		}
+
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = v.Field(i).Interface()
+	}
+}
+
+// sameJSON reports whether a and b are written the same in JSON, which is how they
+// are stored in the audit log.
+func sameJSON(a, b interface{}) bool {
+	x, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	y, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(x, y)
+}
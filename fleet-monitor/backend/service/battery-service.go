@@ -0,0 +1,392 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fleet-monitor/backend/db"
+	"fleet-monitor/backend/repository"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultBatteryHealthThreshold is the health, in percent of the rated capacity,
+// below which a battery pack is flagged as low health.
+const DefaultBatteryHealthThreshold = 80.0
+
+const (
+	// minEstimateDrop is the least charge, in percentage points, a discharge must use
+	// for its capacity estimate to be trusted.
+	minEstimateDrop = 20.0
+	// healthSamples is how many of the latest capacity estimates a pack's health averages.
+	healthSamples = 5
+)
+
+// BatteryService keeps the inventory of battery packs and tracks their health from
+// the discharges recorded while they are installed in a drone.
+type BatteryService interface {
+	CreateBatteryPack(ctx context.Context, details BatteryPackDetails) (*db.BatteryPack, error)
+	UpdateBatteryPack(ctx context.Context, packID uint, details BatteryPackDetails) (*db.BatteryPack, error)
+	GetBatteryPacks(ctx context.Context, lowHealthOnly bool) ([]db.BatteryPack, error)
+	GetBatteryPack(ctx context.Context, packID uint) (*db.BatteryPack, error)
+	InstallBatteryPack(ctx context.Context, packID, droneID uint) (*db.BatteryPack, error)
+	RecordDischarge(ctx context.Context, droneID uint, details DischargeDetails) (*DischargeResult, error)
+	GetDischarges(ctx context.Context, packID uint, limit int) ([]db.BatteryDischarge, error)
+}
+
+// BatteryPackDetails holds the fields of a battery pack that can be set on create and
+// update. Retiring a pack removes it from its drone.
+type BatteryPackDetails struct {
+	SerialNumber     string `json:"serialNumber"`
+	Chemistry        string `json:"chemistry"`
+	CellCount        int    `json:"cellCount"`
+	RatedCapacityMah int    `json:"ratedCapacityMah"`
+	Retired          bool   `json:"retired"`
+}
+
+// DischargeDetails describes one flight's use of the pack installed in a drone.
+// Voltages are in volts; a remaining charge of -1 means it was not reported.
+type DischargeDetails struct {
+	StartedAt      time.Time `json:"startedAt"`
+	EndedAt        time.Time `json:"endedAt"`
+	StartVoltage   float64   `json:"startVoltage"`
+	EndVoltage     float64   `json:"endVoltage"`
+	StartRemaining int       `json:"startRemaining"`
+	EndRemaining   int       `json:"endRemaining"`
+	ConsumedMah    float64   `json:"consumedMah"`
+}
+
+// DischargeResult is a recorded discharge and the pack's updated health.
+type DischargeResult struct {
+	Pack      db.BatteryPack      `json:"pack"`
+	Discharge db.BatteryDischarge `json:"discharge"`
+}
+
+type batteryService struct {
+	store repository.Store
+}
+
+// NewBatteryService creates a new BatteryService backed by the given store.
+// Example
+// batteryService := service.NewBatteryService(repository.NewGormStore(db))
+func NewBatteryService(store repository.Store) BatteryService {
+	return &batteryService{store: store}
+}
+
+// CreateBatteryPack adds a battery pack to the inventory, not installed in any drone.
+// Example
+// pack, err := batteryService.CreateBatteryPack(ctx, service.BatteryPackDetails{SerialNumber: "BP-0042", Chemistry: "LiPo", CellCount: 6, RatedCapacityMah: 10000})
+func (s *batteryService) CreateBatteryPack(ctx context.Context, details BatteryPackDetails) (*db.BatteryPack, error) {
+	pack := &db.BatteryPack{}
+	details.apply(pack)
+
+	if fields := validateStruct(pack); len(fields) > 0 {
+		return nil, ValidationError(fields...)
+	}
+
+	if err := s.checkSerialNumberConflict(ctx, s.store, pack.SerialNumber, 0); err != nil {
+		return nil, err
+	}
+
+	if err := s.store.Batteries().Create(ctx, pack); err != nil {
+		return nil, dbError(err, "battery pack", pack.SerialNumber)
+	}
+
+	return pack, nil
+}
+
+// UpdateBatteryPack replaces the details of a battery pack. Its cycles and health are kept.
+func (s *batteryService) UpdateBatteryPack(ctx context.Context, packID uint, details BatteryPackDetails) (*db.BatteryPack, error) {
+	var pack *db.BatteryPack
+	err := s.store.Transaction(ctx, func(tx repository.Store) error {
+		var err error
+		pack, err = tx.Batteries().FindByID(ctx, packID)
+		if err != nil {
+			return dbError(err, "battery pack", packID)
+		}
+
+		details.apply(pack)
+		if fields := validateStruct(pack); len(fields) > 0 {
+			return ValidationError(fields...)
+		}
+
+		if err := s.checkSerialNumberConflict(ctx, tx, pack.SerialNumber, pack.ID); err != nil {
+			return err
+		}
+
+		if pack.Retired {
+			pack.DroneID = 0
+		}
+		if pack.EstimatedCapacityMah != nil {
+			setHealth(pack, *pack.EstimatedCapacityMah)
+		}
+
+		if err := tx.Batteries().Save(ctx, pack); err != nil {
+			return dbError(err, "battery pack", packID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, dbError(err, "battery pack", packID)
+	}
+
+	return pack, nil
+}
+
+// GetBatteryPacks returns the battery packs, or only those with low health.
+func (s *batteryService) GetBatteryPacks(ctx context.Context, lowHealthOnly bool) ([]db.BatteryPack, error) {
+	packs, err := s.store.Batteries().FindAll(ctx)
+	if err != nil {
+		return nil, dbError(err, "battery pack", nil)
+	}
+
+	if !lowHealthOnly {
+		return packs, nil
+	}
+
+	low := []db.BatteryPack{}
+	for _, pack := range packs {
+		if pack.LowHealth {
+			low = append(low, pack)
+		}
+	}
+	return low, nil
+}
+
+func (s *batteryService) GetBatteryPack(ctx context.Context, packID uint) (*db.BatteryPack, error) {
+	pack, err := s.store.Batteries().FindByID(ctx, packID)
+	if err != nil {
+		return nil, dbError(err, "battery pack", packID)
+	}
+
+	return pack, nil
+}
+
+// InstallBatteryPack puts the pack in the drone, taking out the pack that was in it.
+// A droneID of 0 removes the pack from its drone. Retired packs can't be installed.
+// Example
+// pack, err := batteryService.InstallBatteryPack(ctx, 3, 7)
+func (s *batteryService) InstallBatteryPack(ctx context.Context, packID, droneID uint) (*db.BatteryPack, error) {
+	var pack *db.BatteryPack
+	err := s.store.Transaction(ctx, func(tx repository.Store) error {
+		var err error
+		pack, err = tx.Batteries().FindByID(ctx, packID)
+		if err != nil {
+			return dbError(err, "battery pack", packID)
+		}
+
+		if droneID != 0 {
+			if pack.Retired {
+				return ConflictError(fmt.Sprintf("battery pack %d is retired", packID), pack)
+			}
+
+			found, err := tx.Drones().Exists(ctx, droneID)
+			if err != nil {
+				return dbError(err, "drone", droneID)
+			}
+			if !found {
+				return NotFoundError("drone", droneID)
+			}
+
+			current, err := tx.Batteries().FindByDrone(ctx, droneID)
+			if err != nil && !errors.Is(err, repository.ErrNotFound) {
+				return dbError(err, "battery pack", nil)
+			}
+			if err == nil && current.ID != pack.ID {
+				removed := *current
+				current.DroneID = 0
+				if err := tx.Batteries().Save(ctx, current); err != nil {
+					return dbError(err, "battery pack", current.ID)
+				}
+				if err := audit(ctx, tx, db.AuditActionUpdate, AuditEntityBatteryPack, current.ID, &removed, current); err != nil {
+					return err
+				}
+			}
+		}
+
+		before := *pack
+		pack.DroneID = droneID
+		if err := tx.Batteries().Save(ctx, pack); err != nil {
+			return dbError(err, "battery pack", packID)
+		}
+		return audit(ctx, tx, db.AuditActionUpdate, AuditEntityBatteryPack, pack.ID, &before, pack)
+	})
+	if err != nil {
+		return nil, dbError(err, "battery pack", packID)
+	}
+
+	return pack, nil
+}
+
+// RecordDischarge adds a discharge to the pack installed in the drone, counts a cycle
+// and updates the pack's estimated capacity and health. It returns a not found error
+// if no pack is installed in the drone.
+func (s *batteryService) RecordDischarge(ctx context.Context, droneID uint, details DischargeDetails) (*DischargeResult, error) {
+	discharge := &db.BatteryDischarge{
+		DroneID:        droneID,
+		StartedAt:      details.StartedAt.UTC(),
+		EndedAt:        details.EndedAt.UTC(),
+		StartVoltage:   details.StartVoltage,
+		EndVoltage:     details.EndVoltage,
+		StartRemaining: details.StartRemaining,
+		EndRemaining:   details.EndRemaining,
+		ConsumedMah:    details.ConsumedMah,
+	}
+	if fields := validateStruct(discharge); len(fields) > 0 {
+		return nil, ValidationError(fields...)
+	}
+
+	var pack *db.BatteryPack
+	err := s.store.Transaction(ctx, func(tx repository.Store) error {
+		var err error
+		pack, err = tx.Batteries().FindByDrone(ctx, droneID)
+		if err != nil {
+			return dbError(err, "battery pack installed in drone", droneID)
+		}
+
+		discharge.PackID = pack.ID
+		discharge.EstimatedCapacityMah = estimateCapacity(pack, discharge)
+		if err := tx.Batteries().AddDischarge(ctx, discharge); err != nil {
+			return dbError(err, "battery discharge", nil)
+		}
+
+		pack.Cycles++
+		if discharge.EstimatedCapacityMah != nil {
+			if err := updateHealth(ctx, tx, pack); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Batteries().Save(ctx, pack); err != nil {
+			return dbError(err, "battery pack", pack.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, dbError(err, "drone", droneID)
+	}
+
+	return &DischargeResult{Pack: *pack, Discharge: *discharge}, nil
+}
+
+// GetDischarges returns the pack's discharges, newest first. limit <= 0 returns all of them.
+func (s *batteryService) GetDischarges(ctx context.Context, packID uint, limit int) ([]db.BatteryDischarge, error) {
+	if _, err := s.GetBatteryPack(ctx, packID); err != nil {
+		return nil, err
+	}
+
+	discharges, err := s.store.Batteries().FindDischarges(ctx, packID, limit)
+	if err != nil {
+		return nil, dbError(err, "battery discharge", nil)
+	}
+
+	return discharges, nil
+}
+
+// checkSerialNumberConflict returns a conflict error holding the pack that already uses
+// serialNumber. The pack with ID exceptID, if any, is the one being updated and is ignored.
+func (s *batteryService) checkSerialNumberConflict(ctx context.Context, store repository.Store, serialNumber string, exceptID uint) error {
+	existing, err := store.Batteries().FindBySerialNumber(ctx, serialNumber)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return dbError(err, "battery pack", serialNumber)
+	}
+	if existing.ID == exceptID {
+		return nil
+	}
+
+	return ConflictError(fmt.Sprintf("serial number %q is already used by battery pack %d", serialNumber, existing.ID), existing)
+}
+
+// apply copies the trimmed details onto pack.
+func (d BatteryPackDetails) apply(pack *db.BatteryPack) {
+	pack.SerialNumber = strings.TrimSpace(d.SerialNumber)
+	pack.Chemistry = strings.TrimSpace(d.Chemistry)
+	pack.CellCount = d.CellCount
+	pack.RatedCapacityMah = d.RatedCapacityMah
+	pack.Retired = d.Retired
+}
+
+// estimateCapacity works out the full capacity of the pack from the charge a discharge
+// consumed and the share of the pack it used. The share comes from the resting voltage
+// per cell if the cell count is known, and from the charge the drone reported otherwise.
+func estimateCapacity(pack *db.BatteryPack, discharge *db.BatteryDischarge) *float64 {
+	drop := -1.0
+	switch {
+	case pack.CellCount > 0 && discharge.StartVoltage > 0 && discharge.EndVoltage > 0:
+		cells := float64(pack.CellCount)
+		drop = lipoCharge(discharge.StartVoltage/cells) - lipoCharge(discharge.EndVoltage/cells)
+	case discharge.StartRemaining >= 0 && discharge.EndRemaining >= 0:
+		drop = float64(discharge.StartRemaining - discharge.EndRemaining)
+	}
+
+	if drop < minEstimateDrop || discharge.ConsumedMah <= 0 {
+		return nil
+	}
+
+	capacity := discharge.ConsumedMah * 100 / drop
+	return &capacity
+}
+
+// updateHealth sets the pack's estimated capacity to the average of its latest estimates.
+func updateHealth(ctx context.Context, tx repository.Store, pack *db.BatteryPack) error {
+	discharges, err := tx.Batteries().FindDischarges(ctx, pack.ID, 0)
+	if err != nil {
+		return dbError(err, "battery discharge", nil)
+	}
+
+	var sum float64
+	var n int
+	for _, discharge := range discharges {
+		if discharge.EstimatedCapacityMah == nil {
+			continue
+		}
+		sum += *discharge.EstimatedCapacityMah
+		n++
+		if n == healthSamples {
+			break
+		}
+	}
+	if n == 0 {
+		return nil
+	}
+
+	setHealth(pack, sum/float64(n))
+	return nil
+}
+
+// setHealth sets the pack's estimated capacity and the health it gives.
+func setHealth(pack *db.BatteryPack, capacityMah float64) {
+	health := capacityMah / float64(pack.RatedCapacityMah) * 100
+	pack.EstimatedCapacityMah = &capacityMah
+	pack.HealthPercent = &health
+	pack.LowHealth = health < DefaultBatteryHealthThreshold
+}
+
+// lipoRestingVoltages maps the resting voltage of a lithium polymer cell to its
+// state of charge in percent, from empty to full.
+var lipoRestingVoltages = []struct {
+	volts   float64
+	percent float64
+}{
+	{3.27, 0}, {3.61, 5}, {3.69, 10}, {3.71, 15}, {3.73, 20}, {3.75, 25}, {3.77, 30},
+	{3.79, 35}, {3.80, 40}, {3.82, 45}, {3.84, 50}, {3.85, 55}, {3.87, 60}, {3.91, 65},
+	{3.95, 70}, {3.98, 75}, {4.02, 80}, {4.08, 85}, {4.11, 90}, {4.15, 95}, {4.20, 100},
+}
+
+// lipoCharge interpolates the state of charge of a cell at the given resting voltage.
+func lipoCharge(volts float64) float64 {
+	table := lipoRestingVoltages
+	if volts <= table[0].volts {
+		return 0
+	}
+	for i := 1; i < len(table); i++ {
+		if volts <= table[i].volts {
+			lo, hi := table[i-1], table[i]
+			return lo.percent + (volts-lo.volts)/(hi.volts-lo.volts)*(hi.percent-lo.percent)
+		}
+	}
+	return 100
+}
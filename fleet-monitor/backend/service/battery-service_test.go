@@ -0,0 +1,219 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"fleet-monitor/backend/db"
+)
+
+func TestEstimateCapacity(t *testing.T) {
+	tests := []struct {
+		name      string
+		cellCount int
+		discharge db.BatteryDischarge
+		want      float64 // 0 means no estimate
+	}{
+		{
+			name:      "voltage curve",
+			cellCount: 6,
+			discharge: db.BatteryDischarge{StartVoltage: 4.20 * 6, EndVoltage: 3.84 * 6, StartRemaining: -1, EndRemaining: -1, ConsumedMah: 5000},
+			want:      10000,
+		},
+		{
+			name:      "voltage curve interpolated",
+			cellCount: 4,
+			discharge: db.BatteryDischarge{StartVoltage: 4.20 * 4, EndVoltage: 3.835 * 4, StartRemaining: -1, EndRemaining: -1, ConsumedMah: 5125},
+			want:      10000,
+		},
+		{
+			name:      "voltage wins over the reported charge",
+			cellCount: 6,
+			discharge: db.BatteryDischarge{StartVoltage: 4.20 * 6, EndVoltage: 3.84 * 6, StartRemaining: 100, EndRemaining: 10, ConsumedMah: 5000},
+			want:      10000,
+		},
+		{
+			name:      "percentage without a cell count",
+			discharge: db.BatteryDischarge{StartVoltage: 25.2, EndVoltage: 23.0, StartRemaining: 90, EndRemaining: 40, ConsumedMah: 4500},
+			want:      9000,
+		},
+		{
+			name:      "percentage without voltages",
+			cellCount: 6,
+			discharge: db.BatteryDischarge{StartRemaining: 80, EndRemaining: 30, ConsumedMah: 5000},
+			want:      10000,
+		},
+		{
+			name:      "drop at the cutoff",
+			discharge: db.BatteryDischarge{StartRemaining: 60, EndRemaining: 40, ConsumedMah: 2000},
+			want:      10000,
+		},
+		{
+			name:      "drop below the cutoff",
+			discharge: db.BatteryDischarge{StartRemaining: 60, EndRemaining: 41, ConsumedMah: 1900},
+		},
+		{
+			name:      "voltage drop below the cutoff",
+			cellCount: 6,
+			discharge: db.BatteryDischarge{StartVoltage: 4.20 * 6, EndVoltage: 4.15 * 6, StartRemaining: 100, EndRemaining: 50, ConsumedMah: 1000},
+		},
+		{
+			name:      "nothing consumed",
+			discharge: db.BatteryDischarge{StartRemaining: 90, EndRemaining: 40},
+		},
+		{
+			name:      "charge unknown",
+			discharge: db.BatteryDischarge{StartRemaining: -1, EndRemaining: -1, ConsumedMah: 5000},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pack := &db.BatteryPack{CellCount: tt.cellCount, RatedCapacityMah: 10000}
+			got := estimateCapacity(pack, &tt.discharge)
+			switch {
+			case tt.want == 0 && got != nil:
+				t.Errorf("estimate = %v, want none", *got)
+			case tt.want != 0 && got == nil:
+				t.Errorf("no estimate, want %v", tt.want)
+			case got != nil && math.Abs(*got-tt.want) > 0.01:
+				t.Errorf("estimate = %v, want %v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLipoCharge(t *testing.T) {
+	tests := []struct {
+		volts float64
+		want  float64
+	}{
+		{3.0, 0},
+		{3.27, 0},
+		{3.84, 50},
+		{4.05, 82.5},
+		{4.20, 100},
+		{4.35, 100},
+	}
+
+	for _, tt := range tests {
+		if got := lipoCharge(tt.volts); math.Abs(got-tt.want) > 0.01 {
+			t.Errorf("lipoCharge(%v) = %v, want %v", tt.volts, got, tt.want)
+		}
+	}
+}
+
+func TestSetHealth(t *testing.T) {
+	pack := &db.BatteryPack{RatedCapacityMah: 10000}
+
+	setHealth(pack, 8000)
+	if *pack.EstimatedCapacityMah != 8000 || *pack.HealthPercent != 80 || pack.LowHealth {
+		t.Errorf("health at the threshold = %v%%, low %v, want 80%% and not low", *pack.HealthPercent, pack.LowHealth)
+	}
+
+	setHealth(pack, 7900)
+	if *pack.HealthPercent != 79 || !pack.LowHealth {
+		t.Errorf("health below the threshold = %v%%, low %v, want 79%% and low", *pack.HealthPercent, pack.LowHealth)
+	}
+}
+
+func TestUpdateHealthAveragesLatestEstimates(t *testing.T) {
+	ctx := context.Background()
+	store, _ := newTestDrone(t)
+	pack, err := NewBatteryService(store).CreateBatteryPack(ctx, BatteryPackDetails{SerialNumber: "BP-1", RatedCapacityMah: 10000})
+	if err != nil {
+		t.Fatalf("CreateBatteryPack: %v", err)
+	}
+
+	if err := updateHealth(ctx, store, pack); err != nil || pack.HealthPercent != nil {
+		t.Fatalf("health without estimates = %v, %v, want unset", pack.HealthPercent, err)
+	}
+
+	// Oldest first: the first estimate falls out of the average of the latest five,
+	// and the newest discharge has no estimate to count.
+	estimates := []float64{2000, 9000, 9500, 8500, 9000, 9000, 0}
+	start := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	for i, estimate := range estimates {
+		discharge := &db.BatteryDischarge{PackID: pack.ID, StartedAt: start.Add(time.Duration(i) * time.Hour)}
+		if estimate > 0 {
+			capacity := estimate
+			discharge.EstimatedCapacityMah = &capacity
+		}
+		if err := store.Batteries().AddDischarge(ctx, discharge); err != nil {
+			t.Fatalf("AddDischarge: %v", err)
+		}
+	}
+
+	if err := updateHealth(ctx, store, pack); err != nil {
+		t.Fatalf("updateHealth: %v", err)
+	}
+	if pack.EstimatedCapacityMah == nil || *pack.EstimatedCapacityMah != 9000 || *pack.HealthPercent != 90 || pack.LowHealth {
+		t.Errorf("pack after updateHealth = %+v, want 9000 mAh at 90%%", pack)
+	}
+}
+
+func TestRecordDischargeFlagsLowHealth(t *testing.T) {
+	ctx := context.Background()
+	store, drone := newTestDrone(t)
+	batteries := NewBatteryService(store)
+	pack, err := batteries.CreateBatteryPack(ctx, BatteryPackDetails{SerialNumber: "BP-1", CellCount: 6, RatedCapacityMah: 10000})
+	if err != nil {
+		t.Fatalf("CreateBatteryPack: %v", err)
+	}
+	if _, err := batteries.InstallBatteryPack(ctx, pack.ID, drone.ID); err != nil {
+		t.Fatalf("InstallBatteryPack: %v", err)
+	}
+
+	start := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	details := DischargeDetails{StartedAt: start, EndedAt: start.Add(20 * time.Minute), StartRemaining: 90, EndRemaining: 70, ConsumedMah: 200}
+	result, err := batteries.RecordDischarge(ctx, drone.ID, details)
+	if err != nil {
+		t.Fatalf("RecordDischarge: %v", err)
+	}
+	if result.Discharge.EstimatedCapacityMah == nil || result.Pack.Cycles != 1 || !result.Pack.LowHealth {
+		t.Errorf("result = %+v, want one cycle and low health from a 1000 mAh estimate", result)
+	}
+
+	details.StartRemaining, details.EndRemaining = 90, 80
+	result, err = batteries.RecordDischarge(ctx, drone.ID, details)
+	if err != nil {
+		t.Fatalf("RecordDischarge: %v", err)
+	}
+	if result.Discharge.EstimatedCapacityMah != nil || result.Pack.Cycles != 2 || *result.Pack.EstimatedCapacityMah != 1000 {
+		t.Errorf("result of a short discharge = %+v, want a cycle and no new estimate", result)
+	}
+}
+
+func TestInstallBatteryPackIsAudited(t *testing.T) {
+	ctx := context.Background()
+	store, drone := newTestDrone(t)
+	batteries := NewBatteryService(store)
+
+	var packs []*db.BatteryPack
+	for _, serial := range []string{"BP-1", "BP-2"} {
+		pack, err := batteries.CreateBatteryPack(ctx, BatteryPackDetails{SerialNumber: serial, RatedCapacityMah: 10000})
+		if err != nil {
+			t.Fatalf("CreateBatteryPack: %v", err)
+		}
+		if _, err := batteries.InstallBatteryPack(ctx, pack.ID, drone.ID); err != nil {
+			t.Fatalf("InstallBatteryPack(%d): %v", pack.ID, err)
+		}
+		packs = append(packs, pack)
+	}
+
+	installed := fmt.Sprintf("0 -> %d", drone.ID)
+	// The second pack takes the place of the first, which is taken out.
+	for i, want := range [][]string{{installed, fmt.Sprintf("%d -> 0", drone.ID)}, {installed}} {
+		got := auditedChanges(t, store, AuditEntityBatteryPack, packs[i].ID, "drone_id")
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("audited installs of pack %d = %q, want %q", packs[i].ID, got, want)
+		}
+		if stamps := auditedChanges(t, store, AuditEntityBatteryPack, packs[i].ID, "updated_at"); len(stamps) > 0 {
+			t.Errorf("audited updated_at of pack %d = %q, want it left out", packs[i].ID, stamps)
+		}
+	}
+}
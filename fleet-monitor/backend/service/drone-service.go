@@ -1,148 +1,389 @@
 package service
 
 import (
+	"context"
+	"errors"
 	"fleet-monitor/backend/db"
-
-	"gorm.io/gorm"
+	"fleet-monitor/backend/repository"
+	"fmt"
+	"strings"
+	"time"
 )
 
 // DroneService provides methods for interacting with drones in the database.
-type DroneService struct {
-	db *gorm.DB
+type DroneService interface {
+	CreateDrone(ctx context.Context, details DroneDetails) (*db.Drone, error)
+	UpdateDrone(ctx context.Context, droneID uint, details DroneDetails) error
+	UpdateAutopilotInfo(ctx context.Context, mavlinkID string, info AutopilotInfo) (*db.Drone, error)
+	GetAllDrones(ctx context.Context) ([]db.Drone, error)
+	GetDronesByUser(ctx context.Context, ref UserRef) ([]db.Drone, error)
+	GetDronesByTaskStatus(ctx context.Context, taskStatus db.TaskStatus) ([]db.Drone, error)
+	GetDronesByHealthStatus(ctx context.Context, healthStatus db.HealthStatus) ([]db.Drone, error)
+	GetDronesByFlightPhase(ctx context.Context, flightPhase db.FlightPhase) ([]db.Drone, error)
+	DeleteDroneByID(ctx context.Context, droneID int) error
+	GetDroneByID(ctx context.Context, droneID int) (*db.Drone, error)
+	GetDeletedDrones(ctx context.Context) ([]db.Drone, error)
+	RestoreDrone(ctx context.Context, droneID uint) (*db.Drone, error)
+	PurgeDrone(ctx context.Context, droneID uint) error
+	UpdateDroneRealTime(ctx context.Context, drone *db.Drone, state RealTimeState) error
+	GetDroneTelemetry(ctx context.Context, droneID int, since, until time.Time, limit int) ([]db.Telemetry, error)
+	QuarantineSample(ctx context.Context, sample *db.QuarantinedSample) error
+	GetQuarantinedSamples(ctx context.Context, droneID int, since, until time.Time, limit int) ([]db.QuarantinedSample, error)
+}
+
+// DroneDetails holds the fields of a drone that can be set on create and update.
+// Live state is set by UpdateDroneRealTime instead.
+type DroneDetails struct {
+	Name               string  `json:"name"`
+	MavlinkID          string  `json:"mavlinkId"`
+	OwnerID            int     `json:"ownerId"`
+	AirframeType       string  `json:"airframeType"`
+	SerialNumber       string  `json:"serialNumber"`
+	RegistrationNumber string  `json:"registrationNumber"`
+	RemoteID           string  `json:"remoteId"`
+	MaxTakeoffWeightKg float64 `json:"maxTakeoffWeightKg"`
+	MaxSpeedMps        float64 `json:"maxSpeedMps"`
+	FirmwareVersion    string  `json:"firmwareVersion"`
+}
+
+// apply copies the trimmed details onto drone.
+func (d DroneDetails) apply(drone *db.Drone) {
+	drone.Name = strings.TrimSpace(d.Name)
+	drone.MavlinkID = strings.TrimSpace(d.MavlinkID)
+	drone.OwnerID = d.OwnerID
+	drone.AirframeType = strings.TrimSpace(d.AirframeType)
+	drone.SerialNumber = strings.TrimSpace(d.SerialNumber)
+	drone.RegistrationNumber = strings.TrimSpace(d.RegistrationNumber)
+	drone.RemoteID = strings.TrimSpace(d.RemoteID)
+	drone.MaxTakeoffWeightKg = d.MaxTakeoffWeightKg
+	drone.MaxSpeedMps = d.MaxSpeedMps
+	drone.FirmwareVersion = strings.TrimSpace(d.FirmwareVersion)
+}
+
+// RealTimeState is a drone's live state, stored as its latest state and added to its
+// telemetry history. Units are those of the db.Drone fields.
+type RealTimeState struct {
+	Velocity     db.Velocity     `json:"velocity"`
+	GPS          db.GPS          `json:"gps"`
+	Altitude     float64         `json:"altitude"`
+	Battery      int             `json:"battery"`
+	HealthStatus db.HealthStatus `json:"healthStatus"`
+	FlightPhase  db.FlightPhase  `json:"flightPhase"`
+	Heading      float64         `json:"heading"`
+	Attitude     db.Attitude     `json:"attitude"`
+	GroundSpeed  float64         `json:"groundSpeed"`
+	Airspeed     float64         `json:"airspeed"`
+	Radio        db.Radio        `json:"radio"`
 }
 
-// NewDroneService creates a new DroneService with the given database connection.
+// apply copies the state onto drone.
+// realTimeFields are the drone fields a RealTimeState sets. Live updates write only
+// these, so they never undo a change made to the rest of the drone meanwhile.
+var realTimeFields = []string{"Velocity", "GPS", "Altitude", "Battery", "HealthStatus", "FlightPhase",
+	"Heading", "Attitude", "GroundSpeed", "Airspeed", "Radio"}
+
+func (st RealTimeState) apply(drone *db.Drone) {
+	drone.Velocity = st.Velocity
+	drone.GPS = st.GPS
+	drone.Altitude = st.Altitude
+	drone.Battery = st.Battery
+	drone.HealthStatus = st.HealthStatus
+	drone.FlightPhase = st.FlightPhase
+	drone.Heading = st.Heading
+	drone.Attitude = st.Attitude
+	drone.GroundSpeed = st.GroundSpeed
+	drone.Airspeed = st.Airspeed
+	drone.Radio = st.Radio
+}
+
+// AutopilotInfo is what a drone reports about its flight controller over MAVLink.
+// Empty fields were not reported and leave the stored value as it is.
+type AutopilotInfo struct {
+	AutopilotType   string
+	FirmwareVersion string
+}
+
+type droneService struct {
+	store repository.Store
+}
+
+// NewDroneService creates a new DroneService backed by the given store.
 // Example
-// droneService:= service.NewDroneService(db)
-func NewDroneService(db *gorm.DB) *DroneService {
-	return &DroneService{db: db}
+// droneService := service.NewDroneService(repository.NewGormStore(db))
+func NewDroneService(store repository.Store) DroneService {
+	return &droneService{store: store}
 }
 
 // CreateDrone creates a new drone with the specified details.
-func (s *DroneService) CreateDrone(mavlinkID string, ownerID int) (*db.Drone, error) {
-	drone := &db.Drone{
-		MavlinkID: mavlinkID,
-		OwnerID:   ownerID,
+func (s *droneService) CreateDrone(ctx context.Context, details DroneDetails) (*db.Drone, error) {
+	drone := &db.Drone{SigningPolicy: db.SigningPolicyAccept}
+	details.apply(drone)
+
+	if err := s.validateDrone(ctx, drone); err != nil {
+		return nil, err
 	}
 
-	if err := s.db.Create(drone).Error; err != nil {
+	if err := s.checkMavlinkIDConflict(ctx, drone.MavlinkID, 0); err != nil {
 		return nil, err
 	}
 
+	err := s.store.Transaction(ctx, func(tx repository.Store) error {
+		if err := tx.Drones().Create(ctx, drone); err != nil {
+			return err
+		}
+		return audit(ctx, tx, db.AuditActionCreate, AuditEntityDrone, drone.ID, nil, drone)
+	})
+	if err != nil {
+		return nil, dbError(err, "drone", drone.MavlinkID)
+	}
+
 	return drone, nil
 }
 
-// UpdateDrone updates the drone with the given ID and sets its details.
-func (s *DroneService) UpdateDrone(droneID uint, mavlinkID string, ownerID int) error {
-	var drone db.Drone
+// UpdateDrone replaces the details of the drone with the given ID. The autopilot
+// type is left as it is since only the drone itself reports it.
+func (s *droneService) UpdateDrone(ctx context.Context, droneID uint, details DroneDetails) error {
+	drone, err := s.store.Drones().FindByID(ctx, droneID)
+	if err != nil {
+		return dbError(err, "drone", droneID)
+	}
+
+	before := *drone
+	details.apply(drone)
 
-	if err := s.db.First(&drone, droneID).Error; err != nil {
+	if err := s.validateDrone(ctx, drone); err != nil {
 		return err
 	}
 
-	drone.MavlinkID = mavlinkID
-	drone.OwnerID = ownerID
-
-	if err := s.db.Save(&drone).Error; err != nil {
+	if err := s.checkMavlinkIDConflict(ctx, drone.MavlinkID, drone.ID); err != nil {
 		return err
 	}
 
+	err = s.store.Transaction(ctx, func(tx repository.Store) error {
+		if err := tx.Drones().Save(ctx, drone); err != nil {
+			return err
+		}
+		return audit(ctx, tx, db.AuditActionUpdate, AuditEntityDrone, drone.ID, &before, drone)
+	})
+	if err != nil {
+		return dbError(err, "drone", droneID)
+	}
+
 	return nil
 }
 
-func (s *DroneService) GetAllDrones() ([]db.Drone, error) {
-	var drones []db.Drone
+// UpdateAutopilotInfo stores the autopilot type and firmware version reported by the
+// drone with the given mavlink ID. The drone is only saved if something changed, so
+// it can be called for every heartbeat.
+// Example
+// drone, err := droneService.UpdateAutopilotInfo(ctx, "1", service.AutopilotInfo{AutopilotType: "px4"})
+func (s *droneService) UpdateAutopilotInfo(ctx context.Context, mavlinkID string, info AutopilotInfo) (*db.Drone, error) {
+	drone, err := s.store.Drones().FindByMavlinkID(ctx, mavlinkID)
+	if err != nil {
+		return nil, dbError(err, "drone", mavlinkID)
+	}
 
-	if err := s.db.Find(&drones).Error; err != nil {
-		return nil, err
+	updated := *drone
+	if info.AutopilotType != "" {
+		updated.AutopilotType = info.AutopilotType
+	}
+	if info.FirmwareVersion != "" {
+		updated.FirmwareVersion = info.FirmwareVersion
+	}
+	if updated.AutopilotType == drone.AutopilotType && updated.FirmwareVersion == drone.FirmwareVersion {
+		return drone, nil
 	}
 
-	return drones, nil
+	if fields := validateStruct(&updated, "AutopilotType", "FirmwareVersion"); len(fields) > 0 {
+		return nil, ValidationError(fields...)
+	}
+
+	err = s.store.Transaction(ctx, func(tx repository.Store) error {
+		if err := tx.Drones().Update(ctx, &updated, "AutopilotType", "FirmwareVersion"); err != nil {
+			return err
+		}
+		return audit(ctx, tx, db.AuditActionUpdate, AuditEntityDrone, drone.ID, drone, &updated)
+	})
+	if err != nil {
+		return nil, dbError(err, "drone", drone.ID)
+	}
+
+	return &updated, nil
 }
 
-func (s *DroneService) GetDronesByUserName(userName string) ([]db.Drone, error) {
-	var drones []db.Drone
-	var user db.User
+func (s *droneService) GetAllDrones(ctx context.Context) ([]db.Drone, error) {
+	drones, err := s.store.Drones().FindAll(ctx)
+	if err != nil {
+		return nil, dbError(err, "drone", nil)
+	}
+
+	return drones, nil
+}
 
-	// Find the user by name
-	if err := s.db.Where("user_name = ?", userName).First(&user).Error; err != nil {
+// GetDronesByUser returns the drones owned by the user ref refers to.
+func (s *droneService) GetDronesByUser(ctx context.Context, ref UserRef) ([]db.Drone, error) {
+	// Find the user by ID or name
+	user, err := resolveUser(ctx, s.store.Users(), ref)
+	if err != nil {
 		return nil, err
 	}
 
-	// Find all drones associated with the user
-	if err := s.db.Model(&user).Association("Drones").Find(&drones); err != nil {
-		return nil, err
+	// Find all drones owned by the user
+	drones, err := s.store.Drones().FindByOwner(ctx, user.ID)
+	if err != nil {
+		return nil, dbError(err, "drone", nil)
 	}
 
 	return drones, nil
 }
 
-func (s *DroneService) GetDronesByTaskStatus(taskStatus db.TaskStatus) ([]db.Drone, error) {
-	var drones []db.Drone
-	var tasks []db.Task
+func (s *droneService) GetDronesByTaskStatus(ctx context.Context, taskStatus db.TaskStatus) ([]db.Drone, error) {
+	if err := validateEnum("taskStatus", taskStatus); err != nil {
+		return nil, err
+	}
 
 	// Find tasks with the specified TaskStatus
-	if err := s.db.Where("status = ?", taskStatus).Find(&tasks).Error; err != nil {
-		return nil, err
+	tasks, err := s.store.Tasks().FindByStatus(ctx, taskStatus)
+	if err != nil {
+		return nil, dbError(err, "task", nil)
 	}
 
 	// Extract drone IDs from the tasks
-	var droneIDs []int
+	droneIDs := make([]uint, 0, len(tasks))
 	for _, task := range tasks {
-		droneIDs = append(droneIDs, task.DroneID)
+		droneIDs = append(droneIDs, uint(task.DroneID))
 	}
 
 	// Find drones with the extracted IDs
-	if err := s.db.Find(&drones, droneIDs).Error; err != nil {
-		return nil, err
+	drones, err := s.store.Drones().FindByIDs(ctx, droneIDs)
+	if err != nil {
+		return nil, dbError(err, "drone", nil)
 	}
 
 	return drones, nil
 }
 
-func (s *DroneService) GetDronesByFlightStatus(flightStatus db.FlyingStatus) ([]db.Drone, error) {
-	var drones []db.Drone
-
-	// Find drones with the specified FlightStatus
-	if err := s.db.Where("flight_status = ?", flightStatus).Find(&drones).Error; err != nil {
+func (s *droneService) GetDronesByHealthStatus(ctx context.Context, healthStatus db.HealthStatus) ([]db.Drone, error) {
+	if err := validateEnum("healthStatus", healthStatus); err != nil {
 		return nil, err
 	}
 
+	drones, err := s.store.Drones().FindByHealthStatus(ctx, healthStatus)
+	if err != nil {
+		return nil, dbError(err, "drone", nil)
+	}
+
 	return drones, nil
 }
 
-func (s *DroneService) DeleteDroneByID(droneID int) error {
-	var drone db.Drone
+func (s *droneService) GetDronesByFlightPhase(ctx context.Context, flightPhase db.FlightPhase) ([]db.Drone, error) {
+	if err := validateEnum("flightPhase", flightPhase); err != nil {
+		return nil, err
+	}
 
-	// Find the drone by ID
-	if err := s.db.First(&drone, droneID).Error; err != nil {
-		return err
+	drones, err := s.store.Drones().FindByFlightPhase(ctx, flightPhase)
+	if err != nil {
+		return nil, dbError(err, "drone", nil)
 	}
 
-	// Delete the drone
-	if err := s.db.Delete(&drone).Error; err != nil {
-		return err
+	return drones, nil
+}
+
+func (s *droneService) DeleteDroneByID(ctx context.Context, droneID int) error {
+	err := s.store.Transaction(ctx, func(tx repository.Store) error {
+		drone, err := tx.Drones().FindByID(ctx, uint(droneID))
+		if err != nil {
+			return err
+		}
+		if err := tx.Drones().Delete(ctx, drone.ID); err != nil {
+			return err
+		}
+		return audit(ctx, tx, db.AuditActionDelete, AuditEntityDrone, drone.ID, drone, nil)
+	})
+	if err != nil {
+		return dbError(err, "drone", droneID)
 	}
 
 	return nil
 }
 
-func (s *DroneService) GetDroneByID(droneID int) (*db.Drone, error) {
-	var drone db.Drone
+func (s *droneService) GetDroneByID(ctx context.Context, droneID int) (*db.Drone, error) {
+	drone, err := s.store.Drones().FindByID(ctx, uint(droneID))
+	if err != nil {
+		return nil, dbError(err, "drone", droneID)
+	}
+
+	return drone, nil
+}
+
+// GetDeletedDrones returns the soft-deleted drones that can still be restored or purged.
+func (s *droneService) GetDeletedDrones(ctx context.Context) ([]db.Drone, error) {
+	drones, err := s.store.Drones().FindDeleted(ctx)
+	if err != nil {
+		return nil, dbError(err, "drone", nil)
+	}
 
-	// Find the drone by ID
-	if err := s.db.First(&drone, droneID).Error; err != nil {
+	return drones, nil
+}
+
+// RestoreDrone undoes the soft delete of a drone. Its owner must not be deleted,
+// and its mavlink ID must not have been taken by another drone in the meantime.
+func (s *droneService) RestoreDrone(ctx context.Context, droneID uint) (*db.Drone, error) {
+	drone, err := s.store.Drones().FindDeletedByID(ctx, droneID)
+	if err != nil {
+		return nil, dbError(err, "deleted drone", droneID)
+	}
+
+	if drone.OwnerID != 0 {
+		found, err := s.store.Users().Exists(ctx, uint(drone.OwnerID))
+		if err != nil {
+			return nil, dbError(err, "user", drone.OwnerID)
+		}
+		if !found {
+			return nil, ConflictError(fmt.Sprintf("owner user %d of drone %d is deleted, restore the user first", drone.OwnerID, droneID), nil)
+		}
+	}
+
+	if err := s.checkMavlinkIDConflict(ctx, drone.MavlinkID, drone.ID); err != nil {
 		return nil, err
 	}
 
-	return &drone, nil
+	err = s.store.Transaction(ctx, func(tx repository.Store) error {
+		if err := tx.Drones().Restore(ctx, droneID); err != nil {
+			return err
+		}
+		return audit(ctx, tx, db.AuditActionRestore, AuditEntityDrone, droneID, nil, drone)
+	})
+	if err != nil {
+		return nil, dbError(err, "drone", droneID)
+	}
+
+	return s.GetDroneByID(ctx, int(droneID))
+}
+
+// PurgeDrone permanently removes a soft-deleted drone with its telemetry history and
+// its soft-deleted tasks. Live tasks still using the drone prevent the purge.
+func (s *droneService) PurgeDrone(ctx context.Context, droneID uint) error {
+	err := s.store.Transaction(ctx, func(tx repository.Store) error {
+		drone, err := tx.Drones().FindDeletedByID(ctx, droneID)
+		if err != nil {
+			return dbError(err, "deleted drone", droneID)
+		}
+
+		return purgeDrone(ctx, tx, drone, true)
+	})
+	if err != nil {
+		return dbError(err, "drone", droneID)
+	}
+
+	return nil
 }
 
 // CreateDroneFromJSON creates a new drone using JSON data.
 // Example JSON request for creating a drone
 // droneJSON := `{"mavlinkId": "ABC456", "ownerId": 2}`
 // createDroneFromJSON(droneService, droneJSON)
-// func (s *DroneService) CreateDroneFromJSON(jsonStr string) (*db.Drone, error) {
+// func (s *droneService) CreateDroneFromJSON(jsonStr string) (*db.Drone, error) {
 // 	var droneData map[string]interface{}
 // 	if err := json.Unmarshal([]byte(jsonStr), &droneData); err != nil {
 // 		return nil, err
@@ -159,22 +400,154 @@ func (s *DroneService) GetDroneByID(droneID int) (*db.Drone, error) {
 // 	return drone, nil
 // }
 
-// UpdateDroneRealTime updates the drone's velocity and GPS information based on JSON data.
+// UpdateDroneRealTime updates the drone's live state and adds it to the telemetry history.
+// Only the live fields are written, so a stale drone never reverts other changes.
+// The update is audited unless ctx comes from WithTelemetryIngest.
 // Input example
-// velocity := Velocity{X: 2.0, Y: 1.0, Z: 0.5}
-// gps := GPS{Latitude: 40.0, Longitude: -75.0}
-// altitude := 100.0
+// state := service.RealTimeState{Velocity: db.Velocity{X: 2.0, Y: 1.0, Z: 0.5}, GPS: db.GPS{Latitude: 40.0, Longitude: -75.0}, Altitude: 100.0, Heading: 90}
 // THIS IS NOT BEING TESTED FOR REALTIME DB APPLICATION, MIGHT CAUSE SYSTEM LAG
-func (s *DroneService) UpdateDroneRealTime(drone *db.Drone, velocity db.Velocity, gps db.GPS, altitude float64, battery int, status db.FlyingStatus) error {
-	drone.Velocity = velocity
-	drone.GPS = gps
-	drone.Altitude = altitude
-	drone.FlightStatus = status
-	drone.Battery = battery
-
-	if err := s.db.Save(drone).Error; err != nil {
+func (s *droneService) UpdateDroneRealTime(ctx context.Context, drone *db.Drone, state RealTimeState) error {
+	updated := *drone
+	state.apply(&updated)
+
+	// Only the real-time fields are checked, so live data keeps flowing for drones
+	// whose registration data predates validation.
+	fields := validateStruct(&updated, "GPS.Latitude", "GPS.Longitude", "GPS.FixType", "GPS.Satellites", "GPS.HDOP",
+		"HealthStatus", "FlightPhase", "Battery", "Heading")
+	if len(fields) > 0 {
+		return ValidationError(fields...)
+	}
+
+	// The latest state and its history sample are written together. The caller's drone
+	// only takes the new state once both are stored.
+	err := s.store.Transaction(ctx, func(tx repository.Store) error {
+		if err := tx.Drones().Update(ctx, &updated, realTimeFields...); err != nil {
+			return err
+		}
+
+		err := tx.Drones().AddTelemetry(ctx, &db.Telemetry{
+			DroneID:      updated.ID,
+			RecordedAt:   time.Now().UTC(),
+			GPS:          updated.GPS,
+			Velocity:     updated.Velocity,
+			Altitude:     updated.Altitude,
+			HealthStatus: updated.HealthStatus,
+			FlightPhase:  updated.FlightPhase,
+			Battery:      updated.Battery,
+			Heading:      updated.Heading,
+			Attitude:     updated.Attitude,
+			GroundSpeed:  updated.GroundSpeed,
+			Airspeed:     updated.Airspeed,
+			Radio:        updated.Radio,
+		})
+		if err != nil || isTelemetryIngest(ctx) {
+			return err
+		}
+		return audit(ctx, tx, db.AuditActionUpdate, AuditEntityDrone, updated.ID, drone, &updated)
+	})
+	if err != nil {
+		return dbError(err, "drone", drone.ID)
+	}
+	*drone = updated
+
+	return nil
+}
+
+// GetDroneTelemetry returns the drone's telemetry history between since and until, newest first.
+// Zero times leave that end of the range open, and limit <= 0 returns every sample.
+// Example
+// samples, err := droneService.GetDroneTelemetry(7, time.Now().Add(-time.Hour), time.Time{}, 500)
+func (s *droneService) GetDroneTelemetry(ctx context.Context, droneID int, since, until time.Time, limit int) ([]db.Telemetry, error) {
+	if _, err := s.GetDroneByID(ctx, droneID); err != nil {
+		return nil, err
+	}
+
+	if !since.IsZero() {
+		since = since.UTC()
+	}
+	if !until.IsZero() {
+		until = until.UTC()
+	}
+
+	samples, err := s.store.Drones().FindTelemetry(ctx, uint(droneID), since, until, limit)
+	if err != nil {
+		return nil, dbError(err, "telemetry", droneID)
+	}
+
+	return samples, nil
+}
+
+// QuarantineSample records a position update held back from the drone's live state
+// because it looked implausible. It is audited unless ctx comes from WithTelemetryIngest.
+func (s *droneService) QuarantineSample(ctx context.Context, sample *db.QuarantinedSample) error {
+	if sample.ReceivedAt.IsZero() {
+		sample.ReceivedAt = time.Now()
+	}
+	sample.ReceivedAt = sample.ReceivedAt.UTC()
+
+	err := s.store.Transaction(ctx, func(tx repository.Store) error {
+		if err := tx.Drones().AddQuarantined(ctx, sample); err != nil || isTelemetryIngest(ctx) {
+			return err
+		}
+		return audit(ctx, tx, db.AuditActionCreate, AuditEntityQuarantinedSample, sample.ID, nil, sample)
+	})
+	if err != nil {
+		return dbError(err, "quarantined sample", sample.DroneID)
+	}
+	return nil
+}
+
+// GetQuarantinedSamples returns the drone's quarantined position updates between since
+// and until, newest first, like GetDroneTelemetry.
+// Example
+// samples, err := droneService.GetQuarantinedSamples(ctx, 7, time.Now().Add(-time.Hour), time.Time{}, 100)
+func (s *droneService) GetQuarantinedSamples(ctx context.Context, droneID int, since, until time.Time, limit int) ([]db.QuarantinedSample, error) {
+	if _, err := s.GetDroneByID(ctx, droneID); err != nil {
+		return nil, err
+	}
+
+	if !since.IsZero() {
+		since = since.UTC()
+	}
+	if !until.IsZero() {
+		until = until.UTC()
+	}
+
+	samples, err := s.store.Drones().FindQuarantined(ctx, uint(droneID), since, until, limit)
+	if err != nil {
+		return nil, dbError(err, "quarantined sample", droneID)
+	}
+
+	return samples, nil
+}
+
+// validateDrone checks the drone's fields and that its owner exists.
+func (s *droneService) validateDrone(ctx context.Context, drone *db.Drone) error {
+	fields, err := checkReference(ctx, validateStruct(drone), "owner_id", "user", drone.OwnerID, s.store.Users().Exists)
+	if err != nil {
 		return err
 	}
 
+	if len(fields) > 0 {
+		return ValidationError(fields...)
+	}
+
 	return nil
 }
+
+// checkMavlinkIDConflict returns a conflict error holding the drone that already uses mavlinkID.
+// The drone with ID exceptID, if any, is the one being updated and is ignored.
+func (s *droneService) checkMavlinkIDConflict(ctx context.Context, mavlinkID string, exceptID uint) error {
+	existing, err := s.store.Drones().FindByMavlinkID(ctx, mavlinkID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return dbError(err, "drone", mavlinkID)
+	}
+	if existing.ID == exceptID {
+		return nil
+	}
+
+	return ConflictError(fmt.Sprintf("mavlink ID %q is already used by drone %d", mavlinkID, existing.ID), existing)
+}
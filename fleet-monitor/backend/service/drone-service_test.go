@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"fleet-monitor/backend/db"
+)
+
+func TestLiveUpdatesKeepOtherChanges(t *testing.T) {
+	ctx := context.Background()
+	store, drone := newTestDrone(t)
+	drones := NewDroneService(store)
+	maintenance := NewMaintenanceService(store)
+
+	// Telemetry ingest holds on to the drone it loaded when the link came up, while
+	// the drone is grounded and edited through the API.
+	live := *drone
+	if _, err := maintenance.AddServiceInterval(ctx, drone.ID, ServiceIntervalDetails{Name: "props", Cycles: 1}); err != nil {
+		t.Fatalf("AddServiceInterval: %v", err)
+	}
+	start := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	if _, err := maintenance.RecordFlight(ctx, drone.ID, FlightDetails{StartedAt: start, EndedAt: start.Add(time.Hour)}); err != nil {
+		t.Fatalf("RecordFlight: %v", err)
+	}
+	details := DroneDetails{Name: "scout", MavlinkID: "1", OwnerID: drone.OwnerID, SerialNumber: "SN-7", MaxSpeedMps: 12}
+	if err := drones.UpdateDrone(ctx, drone.ID, details); err != nil {
+		t.Fatalf("UpdateDrone: %v", err)
+	}
+
+	state := RealTimeState{GPS: db.GPS{Latitude: 47.37, Longitude: 8.54, FixType: 3}, Altitude: 120, Battery: 80}
+	if err := drones.UpdateDroneRealTime(ctx, &live, state); err != nil {
+		t.Fatalf("UpdateDroneRealTime: %v", err)
+	}
+	if _, err := drones.UpdateAutopilotInfo(ctx, "1", AutopilotInfo{AutopilotType: "px4", FirmwareVersion: "1.14.0"}); err != nil {
+		t.Fatalf("UpdateAutopilotInfo: %v", err)
+	}
+
+	stored := storedDrone(t, store, drone.ID)
+	if !stored.Grounded || stored.FlightCycles != 1 || stored.SerialNumber != "SN-7" || stored.MaxSpeedMps != 12 {
+		t.Errorf("drone after live updates = %+v, want the grounding and registry changes kept", stored)
+	}
+	if stored.Battery != 80 || stored.GPS.Latitude != 47.37 || stored.AutopilotType != "px4" || stored.FirmwareVersion != "1.14.0" {
+		t.Errorf("drone after live updates = %+v, want the live state and autopilot info", stored)
+	}
+
+	// Grounding changes leave the live state alone in turn.
+	if _, err := maintenance.RecordFlight(ctx, drone.ID, FlightDetails{StartedAt: start.Add(2 * time.Hour), EndedAt: start.Add(3 * time.Hour)}); err != nil {
+		t.Fatalf("RecordFlight: %v", err)
+	}
+	if stored := storedDrone(t, store, drone.ID); stored.Battery != 80 || stored.FlightCycles != 2 {
+		t.Errorf("drone after a flight = %d%% battery, %d cycles, want 80%% and 2", stored.Battery, stored.FlightCycles)
+	}
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"fleet-monitor/backend/repository"
+)
+
+// ErrorCode classifies a service error so callers can react to it without
+// inspecting messages. The values are part of the API response body and must stay stable.
+type ErrorCode string
+
+const (
+	ErrorCodeNotFound   ErrorCode = "not_found"
+	ErrorCodeConflict   ErrorCode = "conflict"
+	ErrorCodeValidation ErrorCode = "validation_failed"
+	ErrorCodeForbidden  ErrorCode = "forbidden"
+	ErrorCodeTimeout    ErrorCode = "timeout"
+	ErrorCodeCanceled   ErrorCode = "canceled"
+	ErrorCodeInternal   ErrorCode = "internal"
+)
+
+// Sentinel errors for errors.Is checks against a service error's code.
+// Example
+// if errors.Is(err, service.ErrNotFound) { ... }
+var (
+	ErrNotFound   = &Error{Code: ErrorCodeNotFound}
+	ErrConflict   = &Error{Code: ErrorCodeConflict}
+	ErrValidation = &Error{Code: ErrorCodeValidation}
+	ErrForbidden  = &Error{Code: ErrorCodeForbidden}
+	ErrTimeout    = &Error{Code: ErrorCodeTimeout}
+	ErrCanceled   = &Error{Code: ErrorCodeCanceled}
+)
+
+// FieldError describes a single invalid field of a request.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error is the typed error returned by DroneService, TaskService and UserService.
+// Conflicting holds the existing record a conflict error collided with, if known.
+type Error struct {
+	Code        ErrorCode
+	Message     string
+	Fields      []FieldError
+	Conflicting interface{}
+	Err         error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", e.Message, e.Err)
+	}
+	if e.Message == "" {
+		return string(e.Code)
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is a sentinel error with the same code.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok {
+		return false
+	}
+	return t.Code == e.Code && t.Message == ""
+}
+
+// NotFoundError reports that the entity identified by id does not exist.
+func NotFoundError(entity string, id interface{}) *Error {
+	return &Error{
+		Code:    ErrorCodeNotFound,
+		Message: fmt.Sprintf("%s %v not found", entity, id),
+	}
+}
+
+// ConflictError reports that the operation collides with the existing record.
+func ConflictError(message string, existing interface{}) *Error {
+	return &Error{Code: ErrorCodeConflict, Message: message, Conflicting: existing}
+}
+
+// ValidationError reports one or more invalid fields.
+func ValidationError(fields ...FieldError) *Error {
+	return &Error{
+		Code:    ErrorCodeValidation,
+		Message: "validation failed",
+		Fields:  fields,
+	}
+}
+
+// ForbiddenError reports that the operation is not allowed.
+func ForbiddenError(message string) *Error {
+	return &Error{Code: ErrorCodeForbidden, Message: message}
+}
+
+// dbError translates a repository error into a service error.
+// Errors that are already service errors are returned unchanged.
+func dbError(err error, entity string, id interface{}) error {
+	if err == nil {
+		return nil
+	}
+
+	var serviceErr *Error
+	if errors.As(err, &serviceErr) {
+		return err
+	}
+
+	if errors.Is(err, repository.ErrNotFound) {
+		return NotFoundError(entity, id)
+	}
+
+	// The caller's context ended, e.g. the request timed out or the client went away.
+	if errors.Is(err, context.DeadlineExceeded) {
+		return &Error{Code: ErrorCodeTimeout, Message: fmt.Sprintf("%s query timed out", entity), Err: err}
+	}
+	if errors.Is(err, context.Canceled) {
+		return &Error{Code: ErrorCodeCanceled, Message: fmt.Sprintf("%s query was canceled", entity), Err: err}
+	}
+
+	// Constraint violations that slipped past the service checks, e.g. two concurrent creates.
+	if errors.Is(err, repository.ErrDuplicatedKey) {
+		return &Error{Code: ErrorCodeConflict, Message: fmt.Sprintf("%s %v already exists", entity, id), Err: err}
+	}
+	if errors.Is(err, repository.ErrForeignKeyViolated) {
+		return &Error{Code: ErrorCodeConflict, Message: fmt.Sprintf("%s %v references or is referenced by a missing record", entity, id), Err: err}
+	}
+
+	return &Error{
+		Code:    ErrorCodeInternal,
+		Message: fmt.Sprintf("%s query failed", entity),
+		Err:     err,
+	}
+}
@@ -0,0 +1,417 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fleet-monitor/backend/db"
+	"fleet-monitor/backend/repository"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MaintenanceService tracks the flight time, service intervals and maintenance log of drones.
+// A drone overdue for one of its service intervals is grounded until the service is logged.
+type MaintenanceService interface {
+	RecordFlight(ctx context.Context, droneID uint, details FlightDetails) (*db.Flight, error)
+	GetFlights(ctx context.Context, droneID uint) ([]db.Flight, error)
+	AddServiceInterval(ctx context.Context, droneID uint, details ServiceIntervalDetails) (*db.ServiceInterval, error)
+	UpdateServiceInterval(ctx context.Context, droneID, intervalID uint, details ServiceIntervalDetails) (*db.ServiceInterval, error)
+	DeleteServiceInterval(ctx context.Context, droneID, intervalID uint) error
+	LogMaintenance(ctx context.Context, droneID uint, details MaintenanceDetails) (*db.MaintenanceLog, error)
+	GetMaintenanceStatus(ctx context.Context, droneID uint) (*MaintenanceStatus, error)
+}
+
+// FlightDetails describes a flight to record. Source defaults to manual.
+type FlightDetails struct {
+	StartedAt time.Time       `json:"startedAt"`
+	EndedAt   time.Time       `json:"endedAt"`
+	Source    db.FlightSource `json:"source"`
+}
+
+// ServiceIntervalDetails holds the fields of a service interval that can be set on
+// create and update. At least one of FlightHours and Cycles must be set.
+type ServiceIntervalDetails struct {
+	Name        string  `json:"name"`
+	FlightHours float64 `json:"flightHours"`
+	Cycles      int     `json:"cycles"`
+}
+
+// MaintenanceDetails describes work done on a drone. PerformedAt defaults to now.
+// Setting ServiceIntervalID marks that interval as serviced.
+type MaintenanceDetails struct {
+	PerformedAt       time.Time `json:"performedAt"`
+	Action            string    `json:"action"`
+	Parts             string    `json:"parts"`
+	Technician        string    `json:"technician"`
+	Notes             string    `json:"notes"`
+	ServiceIntervalID uint      `json:"serviceIntervalId"`
+}
+
+// MaintenanceStatus sums up a drone's flight time and how close each of its
+// service intervals is to being due.
+type MaintenanceStatus struct {
+	DroneID      uint                `json:"droneId"`
+	FlightHours  float64             `json:"flightHours"`
+	FlightCycles int                 `json:"flightCycles"`
+	Grounded     bool                `json:"grounded"`
+	Intervals    []IntervalStatus    `json:"intervals"`
+	Log          []db.MaintenanceLog `json:"log"`
+}
+
+// IntervalStatus is a service interval with the flight time and cycles since it was
+// last serviced. An interval is overdue, and grounds its drone, as soon as it is due:
+// once a remaining value reaches zero.
+type IntervalStatus struct {
+	db.ServiceInterval
+	FlightHoursSinceService float64  `json:"flightHoursSinceService"`
+	CyclesSinceService      int      `json:"cyclesSinceService"`
+	FlightHoursRemaining    *float64 `json:"flightHoursRemaining,omitempty"`
+	CyclesRemaining         *int     `json:"cyclesRemaining,omitempty"`
+	Overdue                 bool     `json:"overdue"`
+}
+
+type maintenanceService struct {
+	store repository.Store
+}
+
+// NewMaintenanceService creates a new MaintenanceService backed by the given store.
+// Example
+// maintenanceService := service.NewMaintenanceService(repository.NewGormStore(db))
+func NewMaintenanceService(store repository.Store) MaintenanceService {
+	return &maintenanceService{store: store}
+}
+
+// RecordFlight adds a flight to the drone's history and to its flight time and cycles,
+// grounding the drone if that makes a service interval overdue.
+// Example
+// flight, err := maintenanceService.RecordFlight(ctx, 7, service.FlightDetails{StartedAt: takeoff, EndedAt: landing})
+func (s *maintenanceService) RecordFlight(ctx context.Context, droneID uint, details FlightDetails) (*db.Flight, error) {
+	flight := &db.Flight{
+		DroneID:   droneID,
+		StartedAt: details.StartedAt.UTC(),
+		EndedAt:   details.EndedAt.UTC(),
+		Source:    details.Source,
+	}
+	if flight.Source == "" {
+		flight.Source = db.FlightSourceManual
+	}
+
+	fields := validateStruct(flight)
+	if !details.StartedAt.IsZero() && !details.EndedAt.IsZero() && !flight.EndedAt.After(flight.StartedAt) {
+		fields = append(fields, FieldError{Field: "ended_at", Message: "must be after started_at"})
+	}
+	if len(fields) > 0 {
+		return nil, ValidationError(fields...)
+	}
+
+	err := s.store.Transaction(ctx, func(tx repository.Store) error {
+		drone, err := tx.Drones().FindByID(ctx, droneID)
+		if err != nil {
+			return dbError(err, "drone", droneID)
+		}
+
+		if err := tx.Maintenance().AddFlight(ctx, flight); err != nil {
+			return dbError(err, "flight", nil)
+		}
+
+		drone.FlightSeconds += int64(flight.Duration() / time.Second)
+		drone.FlightCycles++
+		return updateGrounding(ctx, tx, drone, true)
+	})
+	if err != nil {
+		return nil, dbError(err, "drone", droneID)
+	}
+
+	return flight, nil
+}
+
+// GetFlights returns the drone's flights, newest first.
+func (s *maintenanceService) GetFlights(ctx context.Context, droneID uint) ([]db.Flight, error) {
+	if err := s.checkDrone(ctx, droneID); err != nil {
+		return nil, err
+	}
+
+	flights, err := s.store.Maintenance().FindFlights(ctx, droneID)
+	if err != nil {
+		return nil, dbError(err, "flight", nil)
+	}
+
+	return flights, nil
+}
+
+// AddServiceInterval adds a recurring service to the drone, counted from now.
+// Example
+// interval, err := maintenanceService.AddServiceInterval(ctx, 7, service.ServiceIntervalDetails{Name: "motor inspection", FlightHours: 25})
+func (s *maintenanceService) AddServiceInterval(ctx context.Context, droneID uint, details ServiceIntervalDetails) (*db.ServiceInterval, error) {
+	interval := &db.ServiceInterval{DroneID: droneID}
+	details.apply(interval)
+	if err := validateInterval(interval); err != nil {
+		return nil, err
+	}
+
+	err := s.store.Transaction(ctx, func(tx repository.Store) error {
+		drone, err := tx.Drones().FindByID(ctx, droneID)
+		if err != nil {
+			return dbError(err, "drone", droneID)
+		}
+
+		interval.ServicedAt = time.Now().UTC()
+		interval.ServicedFlightSeconds = drone.FlightSeconds
+		interval.ServicedCycles = drone.FlightCycles
+		if err := tx.Maintenance().CreateInterval(ctx, interval); err != nil {
+			return dbError(err, "service interval", nil)
+		}
+
+		return updateGrounding(ctx, tx, drone, false)
+	})
+	if err != nil {
+		return nil, dbError(err, "drone", droneID)
+	}
+
+	return interval, nil
+}
+
+// UpdateServiceInterval replaces the name and limits of a service interval. The
+// counters since its last service are kept.
+func (s *maintenanceService) UpdateServiceInterval(ctx context.Context, droneID, intervalID uint, details ServiceIntervalDetails) (*db.ServiceInterval, error) {
+	var interval *db.ServiceInterval
+	err := s.store.Transaction(ctx, func(tx repository.Store) error {
+		drone, err := tx.Drones().FindByID(ctx, droneID)
+		if err != nil {
+			return dbError(err, "drone", droneID)
+		}
+
+		interval, err = tx.Maintenance().FindInterval(ctx, droneID, intervalID)
+		if err != nil {
+			return dbError(err, "service interval", intervalID)
+		}
+
+		details.apply(interval)
+		if err := validateInterval(interval); err != nil {
+			return err
+		}
+
+		if err := tx.Maintenance().SaveInterval(ctx, interval); err != nil {
+			return dbError(err, "service interval", intervalID)
+		}
+
+		return updateGrounding(ctx, tx, drone, false)
+	})
+	if err != nil {
+		return nil, dbError(err, "drone", droneID)
+	}
+
+	return interval, nil
+}
+
+// DeleteServiceInterval removes a service interval, which ungrounds the drone if it
+// was the only overdue one.
+func (s *maintenanceService) DeleteServiceInterval(ctx context.Context, droneID, intervalID uint) error {
+	err := s.store.Transaction(ctx, func(tx repository.Store) error {
+		drone, err := tx.Drones().FindByID(ctx, droneID)
+		if err != nil {
+			return dbError(err, "drone", droneID)
+		}
+
+		if err := tx.Maintenance().DeleteInterval(ctx, droneID, intervalID); err != nil {
+			return dbError(err, "service interval", intervalID)
+		}
+
+		return updateGrounding(ctx, tx, drone, false)
+	})
+	if err != nil {
+		return dbError(err, "drone", droneID)
+	}
+
+	return nil
+}
+
+// LogMaintenance adds an entry to the drone's maintenance log. If it names a service
+// interval, the interval restarts from the drone's current flight time and cycles.
+// Example
+// entry, err := maintenanceService.LogMaintenance(ctx, 7, service.MaintenanceDetails{Action: "replaced motor 3", Parts: "T-Motor MN3110", ServiceIntervalID: 2})
+func (s *maintenanceService) LogMaintenance(ctx context.Context, droneID uint, details MaintenanceDetails) (*db.MaintenanceLog, error) {
+	entry := &db.MaintenanceLog{
+		DroneID:           droneID,
+		PerformedAt:       details.PerformedAt.UTC(),
+		Action:            strings.TrimSpace(details.Action),
+		Parts:             strings.TrimSpace(details.Parts),
+		Technician:        strings.TrimSpace(details.Technician),
+		Notes:             strings.TrimSpace(details.Notes),
+		ServiceIntervalID: details.ServiceIntervalID,
+	}
+	if details.PerformedAt.IsZero() {
+		entry.PerformedAt = time.Now().UTC()
+	}
+
+	if fields := validateStruct(entry); len(fields) > 0 {
+		return nil, ValidationError(fields...)
+	}
+
+	err := s.store.Transaction(ctx, func(tx repository.Store) error {
+		drone, err := tx.Drones().FindByID(ctx, droneID)
+		if err != nil {
+			return dbError(err, "drone", droneID)
+		}
+
+		entry.FlightSeconds = drone.FlightSeconds
+		entry.Cycles = drone.FlightCycles
+
+		if entry.ServiceIntervalID != 0 {
+			interval, err := tx.Maintenance().FindInterval(ctx, droneID, entry.ServiceIntervalID)
+			if errors.Is(err, repository.ErrNotFound) {
+				return ValidationError(FieldError{
+					Field:   "service_interval_id",
+					Message: fmt.Sprintf("service interval %d does not exist for drone %d", entry.ServiceIntervalID, droneID),
+				})
+			}
+			if err != nil {
+				return dbError(err, "service interval", entry.ServiceIntervalID)
+			}
+
+			interval.ServicedAt = entry.PerformedAt
+			interval.ServicedFlightSeconds = drone.FlightSeconds
+			interval.ServicedCycles = drone.FlightCycles
+			if err := tx.Maintenance().SaveInterval(ctx, interval); err != nil {
+				return dbError(err, "service interval", interval.ID)
+			}
+		}
+
+		if err := tx.Maintenance().AddLog(ctx, entry); err != nil {
+			return dbError(err, "maintenance log", nil)
+		}
+
+		return updateGrounding(ctx, tx, drone, false)
+	})
+	if err != nil {
+		return nil, dbError(err, "drone", droneID)
+	}
+
+	return entry, nil
+}
+
+// GetMaintenanceStatus returns the drone's flight totals, the state of each of its
+// service intervals and its maintenance log, newest first.
+func (s *maintenanceService) GetMaintenanceStatus(ctx context.Context, droneID uint) (*MaintenanceStatus, error) {
+	drone, err := s.store.Drones().FindByID(ctx, droneID)
+	if err != nil {
+		return nil, dbError(err, "drone", droneID)
+	}
+
+	intervals, err := s.store.Maintenance().FindIntervals(ctx, droneID)
+	if err != nil {
+		return nil, dbError(err, "service interval", nil)
+	}
+
+	entries, err := s.store.Maintenance().FindLogs(ctx, droneID)
+	if err != nil {
+		return nil, dbError(err, "maintenance log", nil)
+	}
+
+	status := &MaintenanceStatus{
+		DroneID:      drone.ID,
+		FlightHours:  hours(drone.FlightSeconds),
+		FlightCycles: drone.FlightCycles,
+		Grounded:     drone.Grounded,
+		Intervals:    make([]IntervalStatus, 0, len(intervals)),
+		Log:          append([]db.MaintenanceLog{}, entries...),
+	}
+	for _, interval := range intervals {
+		status.Intervals = append(status.Intervals, intervalStatus(drone, interval))
+	}
+
+	return status, nil
+}
+
+func (s *maintenanceService) checkDrone(ctx context.Context, droneID uint) error {
+	found, err := s.store.Drones().Exists(ctx, droneID)
+	if err != nil {
+		return dbError(err, "drone", droneID)
+	}
+	if !found {
+		return NotFoundError("drone", droneID)
+	}
+	return nil
+}
+
+// apply copies the trimmed details onto interval.
+func (d ServiceIntervalDetails) apply(interval *db.ServiceInterval) {
+	interval.Name = strings.TrimSpace(d.Name)
+	interval.FlightHours = d.FlightHours
+	interval.Cycles = d.Cycles
+}
+
+func validateInterval(interval *db.ServiceInterval) error {
+	fields := validateStruct(interval)
+	if interval.FlightHours == 0 && interval.Cycles == 0 {
+		fields = append(fields, FieldError{Field: "flight_hours", Message: "or cycles must be set"})
+	}
+	if len(fields) > 0 {
+		return ValidationError(fields...)
+	}
+	return nil
+}
+
+// intervalStatus works out how far the drone is into the interval. Reaching a limit
+// exactly counts as overdue, so a drone never flies past a due service.
+func intervalStatus(drone *db.Drone, interval db.ServiceInterval) IntervalStatus {
+	status := IntervalStatus{
+		ServiceInterval:         interval,
+		FlightHoursSinceService: hours(drone.FlightSeconds - interval.ServicedFlightSeconds),
+		CyclesSinceService:      drone.FlightCycles - interval.ServicedCycles,
+	}
+	if interval.FlightHours > 0 {
+		remaining := interval.FlightHours - status.FlightHoursSinceService
+		status.FlightHoursRemaining = &remaining
+		status.Overdue = status.Overdue || remaining <= 0
+	}
+	if interval.Cycles > 0 {
+		remaining := interval.Cycles - status.CyclesSinceService
+		status.CyclesRemaining = &remaining
+		status.Overdue = status.Overdue || remaining <= 0
+	}
+	return status
+}
+
+// updateGrounding grounds the drone if any of its service intervals is overdue and
+// clears the flag otherwise. The flag and the flight totals are written if the flag
+// changed or force is set; the rest of the drone is left as stored. A changed flag is
+// audited, whichever service or flight changed it.
+func updateGrounding(ctx context.Context, tx repository.Store, drone *db.Drone, force bool) error {
+	intervals, err := tx.Maintenance().FindIntervals(ctx, drone.ID)
+	if err != nil {
+		return dbError(err, "service interval", nil)
+	}
+
+	grounded := false
+	for _, interval := range intervals {
+		if intervalStatus(drone, interval).Overdue {
+			grounded = true
+			break
+		}
+	}
+	if grounded == drone.Grounded && !force {
+		return nil
+	}
+
+	before := *drone
+	drone.Grounded = grounded
+	if err := tx.Drones().Update(ctx, drone, "FlightSeconds", "FlightCycles", "Grounded"); err != nil {
+		return dbError(err, "drone", drone.ID)
+	}
+	if before.Grounded == grounded {
+		return nil
+	}
+	return audit(ctx, tx, db.AuditActionUpdate, AuditEntityDrone, drone.ID, &before, drone)
+}
+
+// hours converts seconds of flight time to hours.
+func hours(seconds int64) float64 {
+	return float64(seconds) / float64(time.Hour/time.Second)
+}
+
+// groundedError is returned when a grounded drone would be given a task.
+func groundedError(drone *db.Drone) error {
+	return ConflictError(fmt.Sprintf("drone %d is grounded until its overdue service is logged", drone.ID), drone)
+}
@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"fleet-monitor/backend/db"
+)
+
+func TestIntervalStatus(t *testing.T) {
+	tests := []struct {
+		name          string
+		flightSeconds int64
+		cycles        int
+		interval      db.ServiceInterval
+		wantHours     interface{}
+		wantCycles    interface{}
+		wantOverdue   bool
+	}{
+		{
+			name:          "hours left",
+			flightSeconds: 10 * 3600,
+			interval:      db.ServiceInterval{FlightHours: 25, ServicedFlightSeconds: 4 * 3600},
+			wantHours:     19.0,
+		},
+		{
+			name:          "hours due exactly",
+			flightSeconds: 25 * 3600,
+			interval:      db.ServiceInterval{FlightHours: 25},
+			wantHours:     0.0,
+			wantOverdue:   true,
+		},
+		{
+			name:          "hours past due",
+			flightSeconds: 26 * 3600,
+			interval:      db.ServiceInterval{FlightHours: 25},
+			wantHours:     -1.0,
+			wantOverdue:   true,
+		},
+		{
+			name:       "cycles left",
+			cycles:     12,
+			interval:   db.ServiceInterval{Cycles: 10, ServicedCycles: 5},
+			wantCycles: 3,
+		},
+		{
+			name:        "cycles due exactly",
+			cycles:      10,
+			interval:    db.ServiceInterval{Cycles: 10},
+			wantCycles:  0,
+			wantOverdue: true,
+		},
+		{
+			name:          "cycles due first",
+			flightSeconds: 3600,
+			cycles:        10,
+			interval:      db.ServiceInterval{FlightHours: 25, Cycles: 10},
+			wantHours:     24.0,
+			wantCycles:    0,
+			wantOverdue:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		drone := &db.Drone{FlightSeconds: tt.flightSeconds, FlightCycles: tt.cycles}
+		status := intervalStatus(drone, tt.interval)
+
+		if hours := hoursOf(status.FlightHoursRemaining); hours != tt.wantHours {
+			t.Errorf("%s: hours remaining = %v, want %v", tt.name, hours, tt.wantHours)
+		}
+		if cycles := cyclesOf(status.CyclesRemaining); cycles != tt.wantCycles {
+			t.Errorf("%s: cycles remaining = %v, want %v", tt.name, cycles, tt.wantCycles)
+		}
+		if status.Overdue != tt.wantOverdue {
+			t.Errorf("%s: overdue = %v, want %v", tt.name, status.Overdue, tt.wantOverdue)
+		}
+	}
+}
+
+func TestServiceIntervalGroundsDrone(t *testing.T) {
+	ctx := context.Background()
+	store, drone := newTestDrone(t)
+	maintenance := NewMaintenanceService(store)
+	tasks := NewTaskService(store)
+
+	interval, err := maintenance.AddServiceInterval(ctx, drone.ID, ServiceIntervalDetails{Name: "props", Cycles: 2})
+	if err != nil {
+		t.Fatalf("AddServiceInterval: %v", err)
+	}
+
+	takeoff := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	for i := 0; i < 2; i++ {
+		if storedDrone(t, store, drone.ID).Grounded {
+			t.Fatalf("drone grounded after %d of 2 cycles", i)
+		}
+		start := takeoff.Add(time.Duration(i) * time.Hour)
+		if _, err := maintenance.RecordFlight(ctx, drone.ID, FlightDetails{StartedAt: start, EndedAt: start.Add(30 * time.Minute)}); err != nil {
+			t.Fatalf("RecordFlight: %v", err)
+		}
+	}
+
+	stored := storedDrone(t, store, drone.ID)
+	if !stored.Grounded || stored.FlightCycles != 2 || stored.FlightSeconds != 3600 {
+		t.Fatalf("drone after 2 flights = grounded %v, %d cycles, %ds", stored.Grounded, stored.FlightCycles, stored.FlightSeconds)
+	}
+	_, err = tasks.CreateTask(ctx, stored.OwnerID, int(drone.ID), 8.5, 47.3, 8.6, 47.4, "survey")
+	if !errors.Is(err, ErrConflict) {
+		t.Fatalf("CreateTask for a grounded drone: %v, want a conflict", err)
+	}
+
+	_, err = maintenance.LogMaintenance(ctx, drone.ID, MaintenanceDetails{Action: "replaced props", ServiceIntervalID: interval.ID + 1})
+	if !errors.Is(err, ErrValidation) {
+		t.Fatalf("LogMaintenance for a missing interval: %v, want a validation error", err)
+	}
+	if !storedDrone(t, store, drone.ID).Grounded {
+		t.Fatal("a rejected maintenance entry ungrounded the drone")
+	}
+
+	// Logging the service restarts the interval from the current totals.
+	entry, err := maintenance.LogMaintenance(ctx, drone.ID, MaintenanceDetails{Action: "replaced props", ServiceIntervalID: interval.ID})
+	if err != nil {
+		t.Fatalf("LogMaintenance: %v", err)
+	}
+	if entry.Cycles != 2 || entry.FlightSeconds != 3600 {
+		t.Errorf("log entry totals = %d cycles, %ds, want 2 and 3600", entry.Cycles, entry.FlightSeconds)
+	}
+	status, err := maintenance.GetMaintenanceStatus(ctx, drone.ID)
+	if err != nil {
+		t.Fatalf("GetMaintenanceStatus: %v", err)
+	}
+	if status.Grounded || len(status.Intervals) != 1 || status.Intervals[0].CyclesSinceService != 0 ||
+		*status.Intervals[0].CyclesRemaining != 2 || len(status.Log) != 1 {
+		t.Fatalf("status after the service = %+v", status)
+	}
+	if _, err := tasks.CreateTask(ctx, stored.OwnerID, int(drone.ID), 8.5, 47.3, 8.6, 47.4, "survey"); err != nil {
+		t.Fatalf("CreateTask after the service: %v", err)
+	}
+
+	grounding := auditedChanges(t, store, AuditEntityDrone, drone.ID, "grounded")
+	if want := []string{"false -> true", "true -> false"}; !reflect.DeepEqual(grounding, want) {
+		t.Errorf("audited grounding = %q, want %q", grounding, want)
+	}
+}
+
+func TestServiceIntervalChangesUnground(t *testing.T) {
+	ctx := context.Background()
+	store, drone := newTestDrone(t)
+	maintenance := NewMaintenanceService(store)
+
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	if _, err := maintenance.RecordFlight(ctx, drone.ID, FlightDetails{StartedAt: start, EndedAt: start.Add(2 * time.Hour)}); err != nil {
+		t.Fatalf("RecordFlight: %v", err)
+	}
+
+	// An interval counts from when it is added, so it is never overdue at first.
+	interval, err := maintenance.AddServiceInterval(ctx, drone.ID, ServiceIntervalDetails{Name: "motors", FlightHours: 1})
+	if err != nil {
+		t.Fatalf("AddServiceInterval: %v", err)
+	}
+	if storedDrone(t, store, drone.ID).Grounded {
+		t.Fatal("a new interval grounded the drone")
+	}
+
+	start = start.Add(3 * time.Hour)
+	if _, err := maintenance.RecordFlight(ctx, drone.ID, FlightDetails{StartedAt: start, EndedAt: start.Add(time.Hour)}); err != nil {
+		t.Fatalf("RecordFlight: %v", err)
+	}
+	if !storedDrone(t, store, drone.ID).Grounded {
+		t.Fatal("drone not grounded on reaching its flight hours")
+	}
+
+	if _, err := maintenance.UpdateServiceInterval(ctx, drone.ID, interval.ID, ServiceIntervalDetails{Name: "motors", FlightHours: 5}); err != nil {
+		t.Fatalf("UpdateServiceInterval: %v", err)
+	}
+	if storedDrone(t, store, drone.ID).Grounded {
+		t.Fatal("drone still grounded after raising the limit")
+	}
+
+	if _, err := maintenance.UpdateServiceInterval(ctx, drone.ID, interval.ID, ServiceIntervalDetails{Name: "motors", Cycles: 1}); err != nil {
+		t.Fatalf("UpdateServiceInterval: %v", err)
+	}
+	if !storedDrone(t, store, drone.ID).Grounded {
+		t.Fatal("drone not grounded after lowering the limit")
+	}
+
+	if err := maintenance.DeleteServiceInterval(ctx, drone.ID, interval.ID); err != nil {
+		t.Fatalf("DeleteServiceInterval: %v", err)
+	}
+	if storedDrone(t, store, drone.ID).Grounded {
+		t.Fatal("drone still grounded after deleting its only interval")
+	}
+}
+
+// hoursOf returns the remaining flight hours, or nil for an interval without them.
+func hoursOf(remaining *float64) interface{} {
+	if remaining == nil {
+		return nil
+	}
+	return *remaining
+}
+
+// cyclesOf returns the remaining cycles, or nil for an interval without them.
+func cyclesOf(remaining *int) interface{} {
+	if remaining == nil {
+		return nil
+	}
+	return *remaining
+}
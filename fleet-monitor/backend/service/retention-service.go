@@ -0,0 +1,248 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fleet-monitor/backend/db"
+	"fleet-monitor/backend/repository"
+	"fmt"
+	"time"
+)
+
+// DefaultRetention is how long soft-deleted records are kept before PurgeDeleted removes them.
+const DefaultRetention = 30 * 24 * time.Hour
+
+// RetentionService permanently removes soft-deleted records once their retention period is over.
+type RetentionService interface {
+	PurgeDeleted(ctx context.Context, before time.Time) (*PurgeReport, error)
+}
+
+// PurgeReport lists the IDs removed by PurgeDeleted and the records it had to keep.
+type PurgeReport struct {
+	Before  time.Time      `json:"before"`
+	Tasks   []uint         `json:"tasks"`
+	Drones  []uint         `json:"drones"`
+	Users   []uint         `json:"users"`
+	Skipped []SkippedPurge `json:"skipped,omitempty"`
+}
+
+// SkippedPurge is a record past its retention period that is still referenced by a
+// live record or by a deleted one whose retention period is not over yet.
+type SkippedPurge struct {
+	Entity string `json:"entity"`
+	ID     uint   `json:"id"`
+	Reason string `json:"reason"`
+}
+
+type retentionService struct {
+	store repository.Store
+}
+
+// NewRetentionService creates a new RetentionService backed by the given store.
+// Example
+// retentionService := service.NewRetentionService(repository.NewGormStore(db))
+func NewRetentionService(store repository.Store) RetentionService {
+	return &retentionService{store: store}
+}
+
+// PurgeDeleted permanently removes the tasks, drones and users soft-deleted before the
+// given time, in that order so dependents go first. Each record is purged in its own
+// transaction; records still referenced by others are skipped and reported.
+// Example
+// report, err := retentionService.PurgeDeleted(ctx, time.Now().Add(-service.DefaultRetention))
+func (s *retentionService) PurgeDeleted(ctx context.Context, before time.Time) (*PurgeReport, error) {
+	report := &PurgeReport{Before: before, Tasks: []uint{}, Drones: []uint{}, Users: []uint{}}
+
+	tasks, err := s.store.Tasks().FindDeleted(ctx)
+	if err != nil {
+		return nil, dbError(err, "task", nil)
+	}
+	for i := range tasks {
+		task := &tasks[i]
+		if !task.DeletedAt.Time.Before(before) {
+			continue
+		}
+		err := s.store.Transaction(ctx, func(tx repository.Store) error {
+			return purgeTask(ctx, tx, task)
+		})
+		if err != nil {
+			return report, dbError(err, "task", task.ID)
+		}
+		report.Tasks = append(report.Tasks, task.ID)
+	}
+
+	drones, err := s.store.Drones().FindDeleted(ctx)
+	if err != nil {
+		return report, dbError(err, "drone", nil)
+	}
+	for i := range drones {
+		drone := &drones[i]
+		if !drone.DeletedAt.Time.Before(before) {
+			continue
+		}
+		err := s.store.Transaction(ctx, func(tx repository.Store) error {
+			return purgeDrone(ctx, tx, drone, false)
+		})
+		skipped, err := report.skip("drone", drone.ID, err)
+		if err != nil {
+			return report, dbError(err, "drone", drone.ID)
+		}
+		if !skipped {
+			report.Drones = append(report.Drones, drone.ID)
+		}
+	}
+
+	users, err := s.store.Users().FindDeleted(ctx)
+	if err != nil {
+		return report, dbError(err, "user", nil)
+	}
+	for i := range users {
+		user := &users[i]
+		if !user.DeletedAt.Time.Before(before) {
+			continue
+		}
+		err := s.store.Transaction(ctx, func(tx repository.Store) error {
+			return purgeUser(ctx, tx, user, false)
+		})
+		skipped, err := report.skip("user", user.ID, err)
+		if err != nil {
+			return report, dbError(err, "user", user.ID)
+		}
+		if !skipped {
+			report.Users = append(report.Users, user.ID)
+		}
+	}
+
+	return report, nil
+}
+
+// skip records a conflict as a skipped record. Other errors are returned.
+func (r *PurgeReport) skip(entity string, id uint, err error) (bool, error) {
+	if err == nil {
+		return false, nil
+	}
+
+	var serviceErr *Error
+	if errors.As(err, &serviceErr) && serviceErr.Code == ErrorCodeConflict {
+		r.Skipped = append(r.Skipped, SkippedPurge{Entity: entity, ID: id, Reason: serviceErr.Message})
+		return true, nil
+	}
+
+	return false, err
+}
+
+// purgeDrone permanently removes a soft-deleted drone, its telemetry history and its
+// signing key.
+// Live tasks using the drone prevent the purge. Soft-deleted tasks using it are
+// purged along with it if cascade is set and prevent the purge otherwise.
+func purgeDrone(ctx context.Context, tx repository.Store, drone *db.Drone, cascade bool) error {
+	tasks, err := tx.Tasks().FindByDrone(ctx, drone.ID)
+	if err != nil {
+		return dbError(err, "task", nil)
+	}
+	if len(tasks) > 0 {
+		return ConflictError(fmt.Sprintf("drone %d is still used by task %d", drone.ID, tasks[0].ID), &tasks[0])
+	}
+
+	deleted, err := tx.Tasks().FindDeleted(ctx)
+	if err != nil {
+		return dbError(err, "task", nil)
+	}
+	for i := range deleted {
+		task := &deleted[i]
+		if task.DroneID != int(drone.ID) {
+			continue
+		}
+		if !cascade {
+			return ConflictError(fmt.Sprintf("drone %d is still used by deleted task %d", drone.ID, task.ID), task)
+		}
+		if err := purgeTask(ctx, tx, task); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Drones().Purge(ctx, drone.ID); err != nil {
+		return dbError(err, "drone", drone.ID)
+	}
+	if err := audit(ctx, tx, db.AuditActionPurge, AuditEntityDrone, drone.ID, drone, nil); err != nil {
+		return err
+	}
+
+	// The security alerts of the drone are kept as a record.
+	name := signingKeyName(drone.ID)
+	if err := tx.Secrets().DeleteByName(ctx, name); err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return dbError(err, "secret", name)
+	}
+
+	return nil
+}
+
+// purgeUser permanently removes a soft-deleted user. Live drones or tasks of the user
+// prevent the purge. The user's soft-deleted tasks and drones are purged along with
+// the user if cascade is set and prevent the purge otherwise.
+func purgeUser(ctx context.Context, tx repository.Store, user *db.User, cascade bool) error {
+	drones, err := tx.Drones().FindByOwner(ctx, user.ID)
+	if err != nil {
+		return dbError(err, "drone", nil)
+	}
+	if len(drones) > 0 {
+		return ConflictError(fmt.Sprintf("user %d still owns drone %d", user.ID, drones[0].ID), &drones[0])
+	}
+
+	tasks, err := tx.Tasks().FindByUser(ctx, user.ID)
+	if err != nil {
+		return dbError(err, "task", nil)
+	}
+	if len(tasks) > 0 {
+		return ConflictError(fmt.Sprintf("user %d still has task %d", user.ID, tasks[0].ID), &tasks[0])
+	}
+
+	deletedTasks, err := tx.Tasks().FindDeleted(ctx)
+	if err != nil {
+		return dbError(err, "task", nil)
+	}
+	for i := range deletedTasks {
+		task := &deletedTasks[i]
+		if task.UserID != int(user.ID) {
+			continue
+		}
+		if !cascade {
+			return ConflictError(fmt.Sprintf("user %d still has deleted task %d", user.ID, task.ID), task)
+		}
+		if err := purgeTask(ctx, tx, task); err != nil {
+			return err
+		}
+	}
+
+	deletedDrones, err := tx.Drones().FindDeleted(ctx)
+	if err != nil {
+		return dbError(err, "drone", nil)
+	}
+	for i := range deletedDrones {
+		drone := &deletedDrones[i]
+		if drone.OwnerID != int(user.ID) {
+			continue
+		}
+		if !cascade {
+			return ConflictError(fmt.Sprintf("user %d still owns deleted drone %d", user.ID, drone.ID), drone)
+		}
+		if err := purgeDrone(ctx, tx, drone, true); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Users().Purge(ctx, user.ID); err != nil {
+		return dbError(err, "user", user.ID)
+	}
+
+	return audit(ctx, tx, db.AuditActionPurge, AuditEntityUser, user.ID, user, nil)
+}
+
+// purgeTask permanently removes a soft-deleted task.
+func purgeTask(ctx context.Context, tx repository.Store, task *db.Task) error {
+	if err := tx.Tasks().Purge(ctx, task.ID); err != nil {
+		return dbError(err, "deleted task", task.ID)
+	}
+
+	return audit(ctx, tx, db.AuditActionPurge, AuditEntityTask, task.ID, task, nil)
+}
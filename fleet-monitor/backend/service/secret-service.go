@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"fleet-monitor/backend/db"
+	"fleet-monitor/backend/repository"
+)
+
+// SecretService keeps named secrets such as API keys, webhook secrets and MAVLink
+// signing keys. The gorm store encrypts them with the master key, so every method
+// fails with secrets.ErrNoMasterKey wrapped in an internal error when none is set.
+type SecretService interface {
+	SetSecret(ctx context.Context, name, value string) (*db.Secret, error)
+	GetSecret(ctx context.Context, name string) (string, error)
+	GetSecrets(ctx context.Context) ([]db.Secret, error)
+	DeleteSecret(ctx context.Context, name string) error
+}
+
+type secretService struct {
+	store repository.Store
+}
+
+// NewSecretService creates a new SecretService backed by the given store.
+// Example
+// secretService := service.NewSecretService(repository.NewGormStore(db))
+func NewSecretService(store repository.Store) SecretService {
+	return &secretService{store: store}
+}
+
+// SetSecret creates the secret or replaces its value.
+// Example
+// secret, err := secretService.SetSecret(ctx, "webhooks/slack", "https://hooks.slack.com/services/...")
+func (s *secretService) SetSecret(ctx context.Context, name, value string) (*db.Secret, error) {
+	name = strings.TrimSpace(name)
+
+	var secret *db.Secret
+	err := s.store.Transaction(ctx, func(tx repository.Store) error {
+		var err error
+		secret, err = saveSecret(ctx, tx, name, value)
+		return err
+	})
+	if err != nil {
+		return nil, dbError(err, "secret", name)
+	}
+
+	return secret, nil
+}
+
+// saveSecret creates or replaces a secret within the transaction tx.
+func saveSecret(ctx context.Context, tx repository.Store, name, value string) (*db.Secret, error) {
+	secret, err := tx.Secrets().FindByName(ctx, name)
+	if errors.Is(err, repository.ErrNotFound) {
+		secret, err = &db.Secret{Name: name}, nil
+	}
+	if err != nil {
+		return nil, dbError(err, "secret", name)
+	}
+
+	secret.Value = value
+	if fields := validateStruct(secret); len(fields) > 0 {
+		return nil, ValidationError(fields...)
+	}
+
+	if err := tx.Secrets().Save(ctx, secret); err != nil {
+		return nil, dbError(err, "secret", name)
+	}
+	return secret, nil
+}
+
+// GetSecret returns the decrypted value of a secret.
+func (s *secretService) GetSecret(ctx context.Context, name string) (string, error) {
+	secret, err := s.store.Secrets().FindByName(ctx, name)
+	if err != nil {
+		return "", dbError(err, "secret", name)
+	}
+	return secret.Value, nil
+}
+
+// GetSecrets lists the secrets by name. Their values are decrypted but never
+// serialized to JSON.
+func (s *secretService) GetSecrets(ctx context.Context) ([]db.Secret, error) {
+	secrets, err := s.store.Secrets().FindAll(ctx)
+	if err != nil {
+		return nil, dbError(err, "secret", nil)
+	}
+	return secrets, nil
+}
+
+// DeleteSecret removes a secret for good. It works without the master key.
+func (s *secretService) DeleteSecret(ctx context.Context, name string) error {
+	return dbError(s.store.Secrets().DeleteByName(ctx, name), "secret", name)
+}
@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"fleet-monitor/backend/db"
+	"fleet-monitor/backend/mavlink"
+	"fleet-monitor/backend/repository"
+)
+
+// SecurityService manages the MAVLink 2 signing of drones and the security alerts
+// raised by frames failing their signature checks. Signing keys are kept in the
+// secrets table, so setting or reading one needs the master key.
+type SecurityService interface {
+	GetSigning(ctx context.Context, droneID uint) (*DroneSigning, error)
+	GetSigningByMavlinkID(ctx context.Context, mavlinkID string) (*DroneSigning, error)
+	SetSigning(ctx context.Context, droneID uint, settings SigningSettings) (*DroneSigning, error)
+	RecordAlert(ctx context.Context, alert *db.SecurityAlert) error
+	GetAlerts(ctx context.Context, droneID uint, since time.Time, limit int) ([]db.SecurityAlert, error)
+}
+
+// DroneSigning is how a drone's MAVLink frames are checked. Key is nil when the drone
+// has no signing key and is never serialized to JSON.
+type DroneSigning struct {
+	DroneID uint                `json:"droneId"`
+	Policy  db.SigningPolicy    `json:"policy"`
+	HasKey  bool                `json:"hasKey"`
+	Key     *mavlink.SigningKey `json:"-"`
+}
+
+// SigningSettings changes a drone's signing. A nil Key leaves the key as it is and an
+// empty one removes it; otherwise it is 64 hexadecimal digits or a passphrase, see
+// mavlink.ParseSigningKey. Flagging or rejecting unsigned frames needs a key.
+type SigningSettings struct {
+	Policy db.SigningPolicy `json:"policy"`
+	Key    *string          `json:"key"`
+}
+
+type securityService struct {
+	store repository.Store
+}
+
+// NewSecurityService creates a new SecurityService backed by the given store.
+// Example
+// securityService := service.NewSecurityService(repository.NewGormStore(db))
+func NewSecurityService(store repository.Store) SecurityService {
+	return &securityService{store: store}
+}
+
+// signingKeyName is the secret holding a drone's signing key.
+func signingKeyName(droneID uint) string {
+	return fmt.Sprintf("mavlink-signing/%d", droneID)
+}
+
+// GetSigning returns the signing policy of a drone and whether it has a key.
+func (s *securityService) GetSigning(ctx context.Context, droneID uint) (*DroneSigning, error) {
+	drone, err := s.store.Drones().FindByID(ctx, droneID)
+	if err != nil {
+		return nil, dbError(err, "drone", droneID)
+	}
+	return s.signing(ctx, s.store, drone)
+}
+
+// GetSigningByMavlinkID returns the signing of the drone with the given mavlink ID,
+// with its key. If the drone was found but its key cannot be read, e.g. without the
+// master key, the signing is returned without the key together with the error.
+// Example
+// signing, err := securityService.GetSigningByMavlinkID(ctx, "1")
+func (s *securityService) GetSigningByMavlinkID(ctx context.Context, mavlinkID string) (*DroneSigning, error) {
+	drone, err := s.store.Drones().FindByMavlinkID(ctx, mavlinkID)
+	if err != nil {
+		return nil, dbError(err, "drone", mavlinkID)
+	}
+	return s.signing(ctx, s.store, drone)
+}
+
+// SetSigning changes the signing policy and key of a drone.
+// Example
+// key := "correct horse battery staple"
+// signing, err := securityService.SetSigning(ctx, 7, service.SigningSettings{Policy: db.SigningPolicyReject, Key: &key})
+func (s *securityService) SetSigning(ctx context.Context, droneID uint, settings SigningSettings) (*DroneSigning, error) {
+	if err := validateEnum("policy", settings.Policy); err != nil {
+		return nil, err
+	}
+
+	var key *mavlink.SigningKey
+	if settings.Key != nil && strings.TrimSpace(*settings.Key) != "" {
+		parsed, err := mavlink.ParseSigningKey(*settings.Key)
+		if err != nil {
+			return nil, ValidationError(FieldError{Field: "key", Message: err.Error()})
+		}
+		key = &parsed
+	}
+
+	var signing *DroneSigning
+	err := s.store.Transaction(ctx, func(tx repository.Store) error {
+		drone, err := tx.Drones().FindByID(ctx, droneID)
+		if err != nil {
+			return dbError(err, "drone", droneID)
+		}
+
+		name := signingKeyName(drone.ID)
+		hadKey := false
+		switch {
+		case key != nil:
+			_, err := tx.Secrets().FindByName(ctx, name)
+			hadKey = !errors.Is(err, repository.ErrNotFound)
+			if _, err := saveSecret(ctx, tx, name, key.String()); err != nil {
+				return err
+			}
+		case settings.Key != nil:
+			err := tx.Secrets().DeleteByName(ctx, name)
+			if err != nil && !errors.Is(err, repository.ErrNotFound) {
+				return dbError(err, "secret", name)
+			}
+			hadKey = err == nil
+		}
+
+		before := *drone
+		drone.SigningPolicy = settings.Policy
+		if signing, err = s.signing(ctx, tx, drone); err != nil {
+			return err
+		}
+		if !signing.HasKey && settings.Policy != db.SigningPolicyAccept {
+			return ValidationError(FieldError{Field: "key", Message: fmt.Sprintf("a signing key is required to %s unsigned frames", settings.Policy)})
+		}
+
+		if err := tx.Drones().Update(ctx, drone, "SigningPolicy"); err != nil {
+			return dbError(err, "drone", droneID)
+		}
+
+		// The key itself stays out of the log; a key written or removed shows as
+		// signing_key, whether the drone had one before and has one after.
+		changes := auditChanges(&before, drone)
+		if settings.Key != nil {
+			changes["signing_key"] = db.AuditChange{Before: hadKey, After: signing.HasKey}
+		}
+		return addAuditEntry(ctx, tx, db.AuditActionUpdate, AuditEntityDrone, drone.ID, changes)
+	})
+	if err != nil {
+		return nil, dbError(err, "drone", droneID)
+	}
+
+	return signing, nil
+}
+
+// signing loads the key of drone from the secrets of store. A key that cannot be
+// read leaves the signing without it, returned along with the error.
+func (s *securityService) signing(ctx context.Context, store repository.Store, drone *db.Drone) (*DroneSigning, error) {
+	signing := &DroneSigning{DroneID: drone.ID, Policy: drone.SigningPolicy}
+	if signing.Policy == "" {
+		signing.Policy = db.SigningPolicyAccept
+	}
+
+	name := signingKeyName(drone.ID)
+	secret, err := store.Secrets().FindByName(ctx, name)
+	if errors.Is(err, repository.ErrNotFound) {
+		return signing, nil
+	}
+	if err != nil {
+		return signing, dbError(err, "secret", name)
+	}
+
+	key, err := mavlink.ParseSigningKey(secret.Value)
+	if err != nil {
+		return signing, fmt.Errorf("signing key of drone %d: %w", drone.ID, err)
+	}
+	signing.HasKey = true
+	signing.Key = &key
+	return signing, nil
+}
+
+// RecordAlert stores a security alert.
+func (s *securityService) RecordAlert(ctx context.Context, alert *db.SecurityAlert) error {
+	if fields := validateStruct(alert); len(fields) > 0 {
+		return ValidationError(fields...)
+	}
+	if alert.CreatedAt.IsZero() {
+		alert.CreatedAt = time.Now().UTC()
+	}
+
+	if err := s.store.SecurityAlerts().Add(ctx, alert); err != nil {
+		return dbError(err, "security alert", nil)
+	}
+	return nil
+}
+
+// GetAlerts returns the security alerts newest first. A zero droneID returns the
+// alerts of every drone, a zero since leaves the range open and limit <= 0 returns
+// every alert.
+// Example
+// alerts, err := securityService.GetAlerts(ctx, 0, time.Now().Add(-24*time.Hour), 100)
+func (s *securityService) GetAlerts(ctx context.Context, droneID uint, since time.Time, limit int) ([]db.SecurityAlert, error) {
+	if !since.IsZero() {
+		since = since.UTC()
+	}
+
+	alerts, err := s.store.SecurityAlerts().Find(ctx, droneID, since, limit)
+	if err != nil {
+		return nil, dbError(err, "security alert", nil)
+	}
+	return alerts, nil
+}
@@ -0,0 +1,36 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"fleet-monitor/backend/db"
+)
+
+func TestSetSigningIsAudited(t *testing.T) {
+	ctx := context.Background()
+	store, drone := newTestDrone(t)
+	security := NewSecurityService(store)
+
+	key, none := "correct horse battery staple", ""
+	steps := []SigningSettings{
+		{Policy: db.SigningPolicyReject, Key: &key},
+		{Policy: db.SigningPolicyFlag},
+		{Policy: db.SigningPolicyAccept, Key: &none},
+	}
+	for _, settings := range steps {
+		if _, err := security.SetSigning(ctx, drone.ID, settings); err != nil {
+			t.Fatalf("SetSigning(%+v): %v", settings, err)
+		}
+	}
+
+	policies := auditedChanges(t, store, AuditEntityDrone, drone.ID, "signing_policy")
+	if want := []string{"accept -> reject", "reject -> flag", "flag -> accept"}; !reflect.DeepEqual(policies, want) {
+		t.Errorf("audited policies = %q, want %q", policies, want)
+	}
+	keys := auditedChanges(t, store, AuditEntityDrone, drone.ID, "signing_key")
+	if want := []string{"false -> true", "true -> false"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("audited keys = %q, want %q", keys, want)
+	}
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"fleet-monitor/backend/db"
+	"fleet-monitor/backend/repository"
+)
+
+// newTestDrone returns an in-memory store holding a user and a drone of theirs.
+func newTestDrone(t *testing.T) (repository.Store, *db.Drone) {
+	t.Helper()
+	ctx := context.Background()
+	store := repository.NewMemoryStore()
+
+	user, err := NewUserService(store).CreateUser(ctx, UserDetails{UserName: "alice"})
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	drone, err := NewDroneService(store).CreateDrone(ctx, DroneDetails{Name: "scout", MavlinkID: "1", OwnerID: int(user.ID)})
+	if err != nil {
+		t.Fatalf("CreateDrone: %v", err)
+	}
+	return store, drone
+}
+
+// storedDrone reads the drone back from the store.
+func storedDrone(t *testing.T, store repository.Store, id uint) *db.Drone {
+	t.Helper()
+	drone, err := store.Drones().FindByID(context.Background(), id)
+	if err != nil {
+		t.Fatalf("FindByID(%d): %v", id, err)
+	}
+	return drone
+}
+
+// auditedChanges returns the change of field in each update of the entity recorded
+// in the audit log, oldest first, written as "before -> after".
+func auditedChanges(t *testing.T, store repository.Store, entity string, id uint, field string) []string {
+	t.Helper()
+	entries, err := store.Audit().Find(context.Background(), repository.AuditFilter{Entity: entity, EntityID: id, Action: db.AuditActionUpdate})
+	if err != nil {
+		t.Fatalf("Audit().Find: %v", err)
+	}
+	var changes []string
+	for i := len(entries) - 1; i >= 0; i-- {
+		if change, ok := entries[i].Changes[field]; ok {
+			changes = append(changes, fmt.Sprintf("%v -> %v", change.Before, change.After))
+		}
+	}
+	return changes
+}
@@ -1,25 +1,38 @@
 package service
 
 import (
+	"context"
 	"fleet-monitor/backend/db"
-
-	"gorm.io/gorm"
+	"fleet-monitor/backend/repository"
+	"fmt"
 )
 
 // TaskService provides methods for interacting with tasks in the database.
-type TaskService struct {
-	db *gorm.DB
+type TaskService interface {
+	CreateTask(ctx context.Context, userID, droneID int, startLon, startLat, endLon, endLat float64, description string) (*db.Task, error)
+	UpdateTask(ctx context.Context, taskID uint, status db.TaskStatus) error
+	GetAllTasks(ctx context.Context) ([]db.Task, error)
+	GetTasksByStatus(ctx context.Context, taskStatus db.TaskStatus) ([]db.Task, error)
+	DeleteTaskByID(ctx context.Context, taskID int) error
+	GetDeletedTasks(ctx context.Context) ([]db.Task, error)
+	RestoreTask(ctx context.Context, taskID uint) (*db.Task, error)
+	PurgeTask(ctx context.Context, taskID uint) error
+}
+
+type taskService struct {
+	store repository.Store
 }
 
-// NewTaskService creates a new TaskService with the given database connection.
+// NewTaskService creates a new TaskService backed by the given store.
 // Example
-// taskService := service.NewTaskService(db)
-func NewTaskService(db *gorm.DB) *TaskService {
-	return &TaskService{db: db}
+// taskService := service.NewTaskService(repository.NewGormStore(db))
+func NewTaskService(store repository.Store) TaskService {
+	return &taskService{store: store}
 }
 
 // CreateTask creates a new task with the specified details and sets its status to "waiting".
-func (s *TaskService) CreateTask(userID, droneID int, startLon, startLat, endLon, endLat float64, description string) (*db.Task, error) {
+// Grounded drones can't be given new tasks.
+func (s *taskService) CreateTask(ctx context.Context, userID, droneID int, startLon, startLat, endLon, endLat float64, description string) (*db.Task, error) {
 	task := &db.Task{
 		UserID:      userID,
 		DroneID:     droneID,
@@ -31,49 +44,187 @@ func (s *TaskService) CreateTask(userID, droneID int, startLon, startLat, endLon
 		Status:      db.TaskStatusWaiting,
 	}
 
-	if err := s.db.Create(task).Error; err != nil {
+	if err := s.validateTask(ctx, task); err != nil {
 		return nil, err
 	}
 
+	// Grounded drones are overdue for service and take no new tasks.
+	drone, err := s.store.Drones().FindByID(ctx, uint(task.DroneID))
+	if err != nil {
+		return nil, dbError(err, "drone", task.DroneID)
+	}
+	if drone.Grounded {
+		return nil, groundedError(drone)
+	}
+
+	err = s.store.Transaction(ctx, func(tx repository.Store) error {
+		if err := tx.Tasks().Create(ctx, task); err != nil {
+			return err
+		}
+		return audit(ctx, tx, db.AuditActionCreate, AuditEntityTask, task.ID, nil, task)
+	})
+	if err != nil {
+		return nil, dbError(err, "task", nil)
+	}
+
 	return task, nil
 }
 
 // UpdateTask updates the task with the given ID and sets its status to the provided status.
 // Example
 // taskService.UpdateTask(task.ID, TaskStatusOngoing)
-func (s *TaskService) UpdateTask(taskID uint, status db.TaskStatus) error {
-	var task db.Task
-
-	if err := s.db.First(&task, taskID).Error; err != nil {
-		return err
+func (s *taskService) UpdateTask(ctx context.Context, taskID uint, status db.TaskStatus) error {
+	task, err := s.store.Tasks().FindByID(ctx, taskID)
+	if err != nil {
+		return dbError(err, "task", taskID)
 	}
 
+	before := *task
 	task.Status = status
 
-	if err := s.db.Save(&task).Error; err != nil {
-		return err
+	if fields := validateStruct(task, "Status"); len(fields) > 0 {
+		return ValidationError(fields...)
+	}
+
+	err = s.store.Transaction(ctx, func(tx repository.Store) error {
+		if err := tx.Tasks().Save(ctx, task); err != nil {
+			return err
+		}
+		return audit(ctx, tx, db.AuditActionUpdate, AuditEntityTask, task.ID, &before, task)
+	})
+	if err != nil {
+		return dbError(err, "task", taskID)
 	}
 
 	return nil
 }
 
-func (s *TaskService) GetAllTasks() ([]db.Task, error) {
-	var tasks []db.Task
+func (s *taskService) GetAllTasks(ctx context.Context) ([]db.Task, error) {
+	tasks, err := s.store.Tasks().FindAll(ctx)
+	if err != nil {
+		return nil, dbError(err, "task", nil)
+	}
 
-	if err := s.db.Find(&tasks).Error; err != nil {
+	return tasks, nil
+}
+
+func (s *taskService) GetTasksByStatus(ctx context.Context, taskStatus db.TaskStatus) ([]db.Task, error) {
+	if err := validateEnum("status", taskStatus); err != nil {
 		return nil, err
 	}
 
+	// Find tasks with the specified TaskStatus
+	tasks, err := s.store.Tasks().FindByStatus(ctx, taskStatus)
+	if err != nil {
+		return nil, dbError(err, "task", nil)
+	}
+
 	return tasks, nil
 }
 
-func (s *TaskService) GetTasksByStatus(taskStatus db.TaskStatus) ([]db.Task, error) {
-	var tasks []db.Task
+func (s *taskService) DeleteTaskByID(ctx context.Context, taskID int) error {
+	err := s.store.Transaction(ctx, func(tx repository.Store) error {
+		task, err := tx.Tasks().FindByID(ctx, uint(taskID))
+		if err != nil {
+			return err
+		}
+		if err := tx.Tasks().Delete(ctx, task.ID); err != nil {
+			return err
+		}
+		return audit(ctx, tx, db.AuditActionDelete, AuditEntityTask, task.ID, task, nil)
+	})
+	if err != nil {
+		return dbError(err, "task", taskID)
+	}
 
-	// Find tasks with the specified TaskStatus
-	if err := s.db.Where("status = ?", taskStatus).Find(&tasks).Error; err != nil {
-		return nil, err
+	return nil
+}
+
+// GetDeletedTasks returns the soft-deleted tasks that can still be restored or purged.
+func (s *taskService) GetDeletedTasks(ctx context.Context) ([]db.Task, error) {
+	tasks, err := s.store.Tasks().FindDeleted(ctx)
+	if err != nil {
+		return nil, dbError(err, "task", nil)
 	}
 
 	return tasks, nil
 }
+
+// RestoreTask undoes the soft delete of a task. Its user and drone must not be deleted.
+func (s *taskService) RestoreTask(ctx context.Context, taskID uint) (*db.Task, error) {
+	task, err := s.store.Tasks().FindDeletedByID(ctx, taskID)
+	if err != nil {
+		return nil, dbError(err, "deleted task", taskID)
+	}
+
+	references := []struct {
+		entity string
+		id     int
+		exists func(ctx context.Context, id uint) (bool, error)
+	}{
+		{"user", task.UserID, s.store.Users().Exists},
+		{"drone", task.DroneID, s.store.Drones().Exists},
+	}
+	for _, ref := range references {
+		found, err := ref.exists(ctx, uint(ref.id))
+		if err != nil {
+			return nil, dbError(err, ref.entity, ref.id)
+		}
+		if !found {
+			return nil, ConflictError(fmt.Sprintf("%s %d of task %d is deleted, restore the %s first", ref.entity, ref.id, taskID, ref.entity), nil)
+		}
+	}
+
+	err = s.store.Transaction(ctx, func(tx repository.Store) error {
+		if err := tx.Tasks().Restore(ctx, taskID); err != nil {
+			return err
+		}
+		return audit(ctx, tx, db.AuditActionRestore, AuditEntityTask, taskID, nil, task)
+	})
+	if err != nil {
+		return nil, dbError(err, "task", taskID)
+	}
+
+	task, err = s.store.Tasks().FindByID(ctx, taskID)
+	if err != nil {
+		return nil, dbError(err, "task", taskID)
+	}
+
+	return task, nil
+}
+
+// PurgeTask permanently removes a soft-deleted task.
+func (s *taskService) PurgeTask(ctx context.Context, taskID uint) error {
+	err := s.store.Transaction(ctx, func(tx repository.Store) error {
+		task, err := tx.Tasks().FindDeletedByID(ctx, taskID)
+		if err != nil {
+			return dbError(err, "deleted task", taskID)
+		}
+
+		return purgeTask(ctx, tx, task)
+	})
+	if err != nil {
+		return dbError(err, "task", taskID)
+	}
+
+	return nil
+}
+
+// validateTask checks the task's fields and that its user and drone exist.
+func (s *taskService) validateTask(ctx context.Context, task *db.Task) error {
+	fields, err := checkReference(ctx, validateStruct(task), "userId", "user", task.UserID, s.store.Users().Exists)
+	if err != nil {
+		return err
+	}
+
+	fields, err = checkReference(ctx, fields, "droneId", "drone", task.DroneID, s.store.Drones().Exists)
+	if err != nil {
+		return err
+	}
+
+	if len(fields) > 0 {
+		return ValidationError(fields...)
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"fleet-monitor/backend/db"
+	"fleet-monitor/backend/repository"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// UserRef identifies a user either by ID or by username, never both.
+// Example
+// drones, err := droneService.GetDronesByUser(ctx, service.UserByName("alice"))
+type UserRef struct {
+	ID   uint
+	Name string
+}
+
+// UserByID refers to the user with the given ID.
+func UserByID(id uint) UserRef {
+	return UserRef{ID: id}
+}
+
+// UserByName refers to the user with the given username.
+func UserByName(name string) UserRef {
+	return UserRef{Name: name}
+}
+
+// ParseUserRef parses the "id:42" and "name:alice" forms used in URLs.
+// Bare values are rejected since a numeric username could not be told apart from an ID.
+func ParseUserRef(value string) (UserRef, error) {
+	kind, key, ok := strings.Cut(value, ":")
+	if !ok {
+		return UserRef{}, fmt.Errorf("invalid user reference %q, expected id:<number> or name:<username>", value)
+	}
+
+	switch kind {
+	case "id":
+		id, err := strconv.ParseUint(key, 10, 0)
+		if err != nil || id == 0 {
+			return UserRef{}, fmt.Errorf("invalid user ID %q", key)
+		}
+		return UserByID(uint(id)), nil
+
+	case "name":
+		name := strings.TrimSpace(key)
+		if name == "" {
+			return UserRef{}, fmt.Errorf("invalid user reference %q, the username is empty", value)
+		}
+		return UserByName(name), nil
+	}
+
+	return UserRef{}, fmt.Errorf("invalid user reference %q, expected id:<number> or name:<username>", value)
+}
+
+// String formats r the way ParseUserRef reads it.
+func (r UserRef) String() string {
+	if r.ID != 0 {
+		return "id:" + strconv.FormatUint(uint64(r.ID), 10)
+	}
+	return "name:" + r.Name
+}
+
+// resolveUser loads the live user r refers to.
+func resolveUser(ctx context.Context, users repository.UserRepository, r UserRef) (*db.User, error) {
+	var (
+		user *db.User
+		err  error
+	)
+
+	switch {
+	case r.ID != 0 && r.Name == "":
+		user, err = users.FindByID(ctx, r.ID)
+	case r.ID == 0 && r.Name != "":
+		user, err = users.FindByUserName(ctx, r.Name)
+	default:
+		return nil, ValidationError(FieldError{Field: "user", Message: "must be referenced by either ID or username"})
+	}
+	if err != nil {
+		return nil, dbError(err, "user", r)
+	}
+
+	return user, nil
+}
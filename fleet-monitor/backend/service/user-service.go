@@ -1,119 +1,502 @@
 package service
 
 import (
+	"context"
+	"errors"
 	"fleet-monitor/backend/db"
-
-	"gorm.io/gorm"
+	"fleet-monitor/backend/repository"
+	"fmt"
+	"strings"
 )
 
 // UserService provides methods for interacting with users in the database.
-type UserService struct {
-	db *gorm.DB
+type UserService interface {
+	CreateUser(ctx context.Context, details UserDetails) (*db.User, error)
+	GetAllUsernames(ctx context.Context) ([]string, error)
+	GetAllUsers(ctx context.Context) ([]UserProfile, error)
+	GetUser(ctx context.Context, ref UserRef) (*UserProfile, error)
+	UpdateUser(ctx context.Context, ref UserRef, details UserDetails) error
+	DeleteUser(ctx context.Context, ref UserRef, opts DeleteUserOptions) (*DeleteUserResult, error)
+	GetDeletedUsers(ctx context.Context) ([]db.User, error)
+	RestoreUser(ctx context.Context, userID uint) (*db.User, error)
+	PurgeUser(ctx context.Context, userID uint) error
+}
+
+// UserDetails holds the fields of a user that can be set on create and update.
+type UserDetails struct {
+	UserName     string `json:"userName"`
+	DisplayName  string `json:"displayName"`
+	Email        string `json:"email"`
+	ContactPhone string `json:"contactPhone"`
+}
+
+// apply copies the trimmed details onto user.
+func (d UserDetails) apply(user *db.User) {
+	user.UserName = strings.TrimSpace(d.UserName)
+	user.DisplayName = strings.TrimSpace(d.DisplayName)
+	user.Email = strings.TrimSpace(d.Email)
+	user.ContactPhone = strings.TrimSpace(d.ContactPhone)
+}
+
+// UserProfile is a user with the counts of their fleet. GetUser also fills in the
+// user's Drones and Tasks; GetAllUsers leaves them out.
+type UserProfile struct {
+	db.User
+	Summary FleetSummary `json:"summary"`
+}
+
+// FleetSummary counts a user's drones by health status and flight phase, and tasks by
+// status. Drones that never reported a status or phase are counted as "unknown".
+type FleetSummary struct {
+	Drones               int                     `json:"drones"`
+	DronesByHealthStatus map[db.HealthStatus]int `json:"dronesByHealthStatus"`
+	DronesByFlightPhase  map[db.FlightPhase]int  `json:"dronesByFlightPhase"`
+	Tasks                int                     `json:"tasks"`
+	TasksByStatus        map[db.TaskStatus]int   `json:"tasksByStatus"`
 }
 
-// NewUserService creates a new UserService with the given database connection.
+func summarize(drones []db.Drone, tasks []db.Task) FleetSummary {
+	summary := FleetSummary{
+		Drones:               len(drones),
+		DronesByHealthStatus: map[db.HealthStatus]int{},
+		DronesByFlightPhase:  map[db.FlightPhase]int{},
+		Tasks:                len(tasks),
+		TasksByStatus:        map[db.TaskStatus]int{},
+	}
+	for _, drone := range drones {
+		status, phase := drone.HealthStatus, drone.FlightPhase
+		if status == "" {
+			status = "unknown"
+		}
+		if phase == "" {
+			phase = "unknown"
+		}
+		summary.DronesByHealthStatus[status]++
+		summary.DronesByFlightPhase[phase]++
+	}
+	for _, task := range tasks {
+		summary.TasksByStatus[task.Status]++
+	}
+	return summary
+}
+
+// DeletePolicy decides what happens to the drones and tasks of a user being deleted.
+type DeletePolicy string
+
+const (
+	// DeletePolicyReject refuses to delete a user who still owns drones or has tasks.
+	DeletePolicyReject DeletePolicy = "reject"
+	// DeletePolicyReassign hands the user's drones and tasks over to another user.
+	DeletePolicyReassign DeletePolicy = "reassign"
+	// DeletePolicyCascade deletes the user's drones and tasks, and the tasks using those drones.
+	DeletePolicyCascade DeletePolicy = "cascade"
+)
+
+// IsValid reports whether p is one of the known delete policies.
+func (p DeletePolicy) IsValid() bool {
+	switch p {
+	case DeletePolicyReject, DeletePolicyReassign, DeletePolicyCascade:
+		return true
+	}
+	return false
+}
+
+// DeleteUserOptions controls DeleteUser. The zero value rejects users with drones or tasks.
+type DeleteUserOptions struct {
+	Policy DeletePolicy
+	// ReassignTo is the ID of the user receiving the drones and tasks with DeletePolicyReassign.
+	ReassignTo uint
+	// DryRun reports what would be affected without changing anything.
+	DryRun bool
+}
+
+// DeleteUserResult lists the records affected by deleting a user.
+// With DeletePolicyReassign, Drones and Tasks show their new owner.
+type DeleteUserResult struct {
+	User         *db.User     `json:"user"`
+	Policy       DeletePolicy `json:"policy"`
+	DryRun       bool         `json:"dryRun"`
+	Drones       []db.Drone   `json:"drones"`
+	Tasks        []db.Task    `json:"tasks"`
+	ReassignedTo *db.User     `json:"reassignedTo,omitempty"`
+	// Blocked is set when DeletePolicyReject refuses the delete; Drones and Tasks are
+	// then the records in the way. A dry run reports it instead of a conflict error.
+	Blocked bool `json:"blocked,omitempty"`
+}
+
+// errDryRun rolls back the transaction of a dry run.
+var errDryRun = errors.New("dry run")
+
+type userService struct {
+	store repository.Store
+}
+
+// NewUserService creates a new UserService backed by the given store.
 // Example
-// userService := service.NewUserService(db)
-func NewUserService(db *gorm.DB) *UserService {
-	return &UserService{db: db}
+// userService := service.NewUserService(repository.NewGormStore(db))
+func NewUserService(store repository.Store) UserService {
+	return &userService{store: store}
 }
 
 // CreateUser creates a new user with the specified details.
-func (s *UserService) CreateUser(userName string) (*db.User, error) {
-	user := &db.User{
-		UserName: userName,
+// Example
+// user, err := userService.CreateUser(ctx, service.UserDetails{UserName: "alice", Email: "alice@example.com"})
+func (s *userService) CreateUser(ctx context.Context, details UserDetails) (*db.User, error) {
+	user := &db.User{}
+	details.apply(user)
+
+	if fields := validateStruct(user); len(fields) > 0 {
+		return nil, ValidationError(fields...)
 	}
 
-	if err := s.db.Create(user).Error; err != nil {
+	if err := s.checkUserNameConflict(ctx, user.UserName, 0); err != nil {
 		return nil, err
 	}
 
+	err := s.store.Transaction(ctx, func(tx repository.Store) error {
+		if err := tx.Users().Create(ctx, user); err != nil {
+			return err
+		}
+		return audit(ctx, tx, db.AuditActionCreate, AuditEntityUser, user.ID, nil, user)
+	})
+	if err != nil {
+		return nil, dbError(err, "user", user.UserName)
+	}
+
 	return user, nil
 }
 
-func (s *UserService) GetAllUsernames() ([]string, error) {
-	var usernames []string
-
-	// Select only the username column
-	if err := s.db.Model(&db.User{}).Pluck("username", &usernames).Error; err != nil {
-		return nil, err
+func (s *userService) GetAllUsernames(ctx context.Context) ([]string, error) {
+	usernames, err := s.store.Users().UserNames(ctx)
+	if err != nil {
+		return nil, dbError(err, "user", nil)
 	}
 
 	return usernames, nil
 }
 
-// UpdateUser updates the user with the given ID and sets its details.
-func (s *UserService) UpdateUser(userID uint, userName string) error {
-	var user db.User
-
-	if err := s.db.First(&user, userID).Error; err != nil {
-		return err
+// GetAllUsers returns every user with the counts of their fleet.
+func (s *userService) GetAllUsers(ctx context.Context) ([]UserProfile, error) {
+	users, err := s.store.Users().FindAll(ctx)
+	if err != nil {
+		return nil, dbError(err, "user", nil)
+	}
+	drones, err := s.store.Drones().FindAll(ctx)
+	if err != nil {
+		return nil, dbError(err, "drone", nil)
+	}
+	tasks, err := s.store.Tasks().FindAll(ctx)
+	if err != nil {
+		return nil, dbError(err, "task", nil)
 	}
 
-	user.UserName = userName
+	dronesByOwner := make(map[int][]db.Drone)
+	for _, drone := range drones {
+		dronesByOwner[drone.OwnerID] = append(dronesByOwner[drone.OwnerID], drone)
+	}
+	tasksByUser := make(map[int][]db.Task)
+	for _, task := range tasks {
+		tasksByUser[task.UserID] = append(tasksByUser[task.UserID], task)
+	}
 
-	if err := s.db.Save(&user).Error; err != nil {
-		return err
+	profiles := make([]UserProfile, 0, len(users))
+	for _, user := range users {
+		id := int(user.ID)
+		profiles = append(profiles, UserProfile{User: user, Summary: summarize(dronesByOwner[id], tasksByUser[id])})
 	}
 
-	return nil
+	return profiles, nil
 }
 
-// //////////////// THIS SECTION ISNT TESTED YET///////////////////////
-func (s *UserService) DeleteUserByID(userID int) error {
-	var user db.User
+// GetUser returns the user ref refers to with their drones, tasks and fleet counts.
+func (s *userService) GetUser(ctx context.Context, ref UserRef) (*UserProfile, error) {
+	user, err := resolveUser(ctx, s.store.Users(), ref)
+	if err != nil {
+		return nil, err
+	}
 
-	if err := s.db.First(&user, userID).Error; err != nil {
+	if user.Drones, err = s.store.Drones().FindByOwner(ctx, user.ID); err != nil {
+		return nil, dbError(err, "drone", nil)
+	}
+	if user.Tasks, err = s.store.Tasks().FindByUser(ctx, user.ID); err != nil {
+		return nil, dbError(err, "task", nil)
+	}
+
+	return &UserProfile{User: *user, Summary: summarize(user.Drones, user.Tasks)}, nil
+}
+
+// UpdateUser replaces the details of the user ref refers to. Empty optional fields are cleared.
+// Example
+// userService.UpdateUser(ctx, service.UserByID(user.ID), service.UserDetails{UserName: "NewName"})
+func (s *userService) UpdateUser(ctx context.Context, ref UserRef, details UserDetails) error {
+	user, err := resolveUser(ctx, s.store.Users(), ref)
+	if err != nil {
 		return err
 	}
 
-	if err := s.db.Delete(&user).Error; err != nil {
+	before := *user
+	details.apply(user)
+
+	if fields := validateStruct(user); len(fields) > 0 {
+		return ValidationError(fields...)
+	}
+
+	if err := s.checkUserNameConflict(ctx, user.UserName, user.ID); err != nil {
 		return err
 	}
 
+	err = s.store.Transaction(ctx, func(tx repository.Store) error {
+		if err := tx.Users().Save(ctx, user); err != nil {
+			return err
+		}
+		return audit(ctx, tx, db.AuditActionUpdate, AuditEntityUser, user.ID, &before, user)
+	})
+	if err != nil {
+		return dbError(err, "user", user.ID)
+	}
+
 	return nil
 }
 
-// DeleteUserByName deletes a user by username.
-func (s *UserService) DeleteUserByName(username string) error {
-	var user db.User
+// DeleteUser deletes the user ref refers to, handling their drones and tasks as opts say.
+// Example
+// result, err := userService.DeleteUser(ctx, service.UserByName("alice"), service.DeleteUserOptions{DryRun: true})
+func (s *userService) DeleteUser(ctx context.Context, ref UserRef, opts DeleteUserOptions) (*DeleteUserResult, error) {
+	user, err := resolveUser(ctx, s.store.Users(), ref)
+	if err != nil {
+		return nil, err
+	}
 
-	if err := s.db.Where("user_name = ?", username).First(&user).Error; err != nil {
-		return err
+	return s.deleteUser(ctx, user.ID, opts)
+}
+
+// deleteUser soft-deletes the user and applies opts.Policy to their drones and tasks,
+// all in one transaction. A dry run makes the same changes and then rolls them back.
+func (s *userService) deleteUser(ctx context.Context, userID uint, opts DeleteUserOptions) (*DeleteUserResult, error) {
+	if opts.Policy == "" {
+		opts.Policy = DeletePolicyReject
+	}
+	if err := validateEnum("policy", opts.Policy); err != nil {
+		return nil, err
+	}
+	if opts.Policy == DeletePolicyReassign {
+		if opts.ReassignTo == 0 {
+			return nil, ValidationError(FieldError{Field: "reassignTo", Message: "is required"})
+		}
+		if opts.ReassignTo == userID {
+			return nil, ValidationError(FieldError{Field: "reassignTo", Message: "must differ from the deleted user"})
+		}
 	}
 
-	if err := s.db.Delete(&user).Error; err != nil {
-		return err
+	result := &DeleteUserResult{Policy: opts.Policy, DryRun: opts.DryRun}
+
+	err := s.store.Transaction(ctx, func(tx repository.Store) error {
+		user, err := tx.Users().FindByID(ctx, userID)
+		if err != nil {
+			return dbError(err, "user", userID)
+		}
+		result.User = user
+
+		if result.Drones, err = tx.Drones().FindByOwner(ctx, userID); err != nil {
+			return dbError(err, "drone", nil)
+		}
+		if result.Tasks, err = tx.Tasks().FindByUser(ctx, userID); err != nil {
+			return dbError(err, "task", nil)
+		}
+
+		switch opts.Policy {
+		case DeletePolicyReject:
+			if len(result.Drones) > 0 || len(result.Tasks) > 0 {
+				result.Blocked = true
+				if opts.DryRun {
+					return errDryRun
+				}
+				return ConflictError(fmt.Sprintf("user %d still owns %d drone(s) and has %d task(s)", userID, len(result.Drones), len(result.Tasks)), result)
+			}
+
+		case DeletePolicyReassign:
+			if err := reassignUserRecords(ctx, tx, result, opts.ReassignTo); err != nil {
+				return err
+			}
+
+		case DeletePolicyCascade:
+			if err := cascadeUserRecords(ctx, tx, result); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Users().Delete(ctx, userID); err != nil {
+			return dbError(err, "user", userID)
+		}
+		if err := audit(ctx, tx, db.AuditActionDelete, AuditEntityUser, userID, user, nil); err != nil {
+			return err
+		}
+
+		if opts.DryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if errors.Is(err, errDryRun) {
+		return result, nil
+	}
+	if err != nil {
+		return nil, dbError(err, "user", userID)
+	}
+
+	return result, nil
+}
+
+// reassignUserRecords hands the drones and tasks in result over to the user with ID targetID.
+func reassignUserRecords(ctx context.Context, tx repository.Store, result *DeleteUserResult, targetID uint) error {
+	target, err := tx.Users().FindByID(ctx, targetID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ValidationError(FieldError{Field: "reassignTo", Message: fmt.Sprintf("user %d does not exist", targetID)})
+	}
+	if err != nil {
+		return dbError(err, "user", targetID)
+	}
+	result.ReassignedTo = target
+
+	for i := range result.Drones {
+		drone := &result.Drones[i]
+		before := *drone
+		drone.OwnerID = int(target.ID)
+		if err := tx.Drones().Save(ctx, drone); err != nil {
+			return dbError(err, "drone", drone.ID)
+		}
+		if err := audit(ctx, tx, db.AuditActionUpdate, AuditEntityDrone, drone.ID, &before, drone); err != nil {
+			return err
+		}
+	}
+	for i := range result.Tasks {
+		task := &result.Tasks[i]
+		before := *task
+		task.UserID = int(target.ID)
+		if err := tx.Tasks().Save(ctx, task); err != nil {
+			return dbError(err, "task", task.ID)
+		}
+		if err := audit(ctx, tx, db.AuditActionUpdate, AuditEntityTask, task.ID, &before, task); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
-// DeleteUser deletes a user by either username or user ID.
-// If the provided value is numeric, it's considered as the user ID.
-// If the provided value is a string, it's considered as the username.
-// DeleteUser deletes a user by either username or user ID.
-// If the provided value is not int, it's considered as the username.
-func (s *UserService) DeleteUser(identifier interface{}) error {
-	var user db.User
+// cascadeUserRecords soft-deletes the drones and tasks in result. Tasks of other users
+// that use one of the drones are deleted too and added to result.
+func cascadeUserRecords(ctx context.Context, tx repository.Store, result *DeleteUserResult) error {
+	seen := make(map[uint]bool, len(result.Tasks))
+	for _, task := range result.Tasks {
+		seen[task.ID] = true
+	}
+	for _, drone := range result.Drones {
+		tasks, err := tx.Tasks().FindByDrone(ctx, drone.ID)
+		if err != nil {
+			return dbError(err, "task", nil)
+		}
+		for _, task := range tasks {
+			if !seen[task.ID] {
+				seen[task.ID] = true
+				result.Tasks = append(result.Tasks, task)
+			}
+		}
+	}
 
-	// If identifier is not of type int, assume it's a username
-	if _, ok := identifier.(int); !ok {
-		// Delete by username
-		if err := s.db.Where("user_name = ?", identifier).First(&user).Error; err != nil {
+	for i := range result.Tasks {
+		task := &result.Tasks[i]
+		if err := tx.Tasks().Delete(ctx, task.ID); err != nil {
+			return dbError(err, "task", task.ID)
+		}
+		if err := audit(ctx, tx, db.AuditActionDelete, AuditEntityTask, task.ID, task, nil); err != nil {
 			return err
 		}
-	} else {
-		// Delete by user ID
-		if err := s.db.First(&user, identifier).Error; err != nil {
+	}
+	for i := range result.Drones {
+		drone := &result.Drones[i]
+		if err := tx.Drones().Delete(ctx, drone.ID); err != nil {
+			return dbError(err, "drone", drone.ID)
+		}
+		if err := audit(ctx, tx, db.AuditActionDelete, AuditEntityDrone, drone.ID, drone, nil); err != nil {
 			return err
 		}
 	}
 
-	// Delete the user
-	if err := s.db.Delete(&user).Error; err != nil {
-		return err
+	return nil
+}
+
+// GetDeletedUsers returns the soft-deleted users that can still be restored or purged.
+func (s *userService) GetDeletedUsers(ctx context.Context) ([]db.User, error) {
+	users, err := s.store.Users().FindDeleted(ctx)
+	if err != nil {
+		return nil, dbError(err, "user", nil)
+	}
+
+	return users, nil
+}
+
+// RestoreUser undoes the soft delete of a user, unless the username has been taken
+// by another user in the meantime. The user's deleted drones and tasks stay deleted.
+func (s *userService) RestoreUser(ctx context.Context, userID uint) (*db.User, error) {
+	user, err := s.store.Users().FindDeletedByID(ctx, userID)
+	if err != nil {
+		return nil, dbError(err, "deleted user", userID)
+	}
+
+	if err := s.checkUserNameConflict(ctx, user.UserName, user.ID); err != nil {
+		return nil, err
+	}
+
+	err = s.store.Transaction(ctx, func(tx repository.Store) error {
+		if err := tx.Users().Restore(ctx, userID); err != nil {
+			return err
+		}
+		return audit(ctx, tx, db.AuditActionRestore, AuditEntityUser, userID, nil, user)
+	})
+	if err != nil {
+		return nil, dbError(err, "user", userID)
+	}
+
+	user, err = s.store.Users().FindByID(ctx, userID)
+	if err != nil {
+		return nil, dbError(err, "user", userID)
+	}
+
+	return user, nil
+}
+
+// PurgeUser permanently removes a soft-deleted user together with their soft-deleted
+// drones and tasks. Live drones or tasks still referencing the user prevent the purge.
+func (s *userService) PurgeUser(ctx context.Context, userID uint) error {
+	err := s.store.Transaction(ctx, func(tx repository.Store) error {
+		user, err := tx.Users().FindDeletedByID(ctx, userID)
+		if err != nil {
+			return dbError(err, "deleted user", userID)
+		}
+
+		return purgeUser(ctx, tx, user, true)
+	})
+	if err != nil {
+		return dbError(err, "user", userID)
 	}
 
 	return nil
 }
+
+// checkUserNameConflict returns a conflict error holding the user that already uses userName.
+// The user with ID exceptID, if any, is the one being updated and is ignored.
+func (s *userService) checkUserNameConflict(ctx context.Context, userName string, exceptID uint) error {
+	existing, err := s.store.Users().FindByUserName(ctx, userName)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return dbError(err, "user", userName)
+	}
+	if existing.ID == exceptID {
+		return nil
+	}
+
+	return ConflictError(fmt.Sprintf("username %q is already taken by user %d", userName, existing.ID), existing)
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// enum is implemented by string enums such as db.HealthStatus and db.TaskStatus
+// so the "enum" validation tag can check membership.
+type enum interface {
+	IsValid() bool
+}
+
+var validate = newValidator()
+
+// newValidator builds the validator used for the `validate` struct tags on the db models.
+// Field errors are reported under the fields' JSON names.
+func newValidator() *validator.Validate {
+	v := validator.New()
+
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+
+	_ = v.RegisterValidation("enum", func(fl validator.FieldLevel) bool {
+		value, ok := fl.Field().Interface().(enum)
+		return ok && value.IsValid()
+	})
+
+	return v
+}
+
+// validateStruct checks the `validate` tags of v and returns one FieldError per invalid field.
+// When only is given, just those struct fields (Go names, e.g. "GPS" or "Battery") are checked.
+func validateStruct(v interface{}, only ...string) []FieldError {
+	var err error
+	if len(only) > 0 {
+		err = validate.StructPartial(v, only...)
+	} else {
+		err = validate.Struct(v)
+	}
+	if err == nil {
+		return nil
+	}
+
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return []FieldError{{Message: err.Error()}}
+	}
+
+	fields := make([]FieldError, 0, len(validationErrors))
+	for _, fe := range validationErrors {
+		fields = append(fields, FieldError{
+			Field:   fieldPath(fe.Namespace()),
+			Message: fieldMessage(fe),
+		})
+	}
+
+	return fields
+}
+
+// validateEnum checks a single enum value, e.g. a status used as a query filter.
+func validateEnum(field string, value enum) error {
+	if value.IsValid() {
+		return nil
+	}
+	return ValidationError(FieldError{
+		Field:   field,
+		Message: fmt.Sprintf("unsupported value %q", value),
+	})
+}
+
+// fieldPath strips the struct name from a validator namespace,
+// e.g. "Drone.gps.latitude" becomes "gps.latitude".
+func fieldPath(namespace string) string {
+	if i := strings.Index(namespace, "."); i >= 0 {
+		return namespace[i+1:]
+	}
+	return namespace
+}
+
+func fieldMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "gte", "min":
+		return "must be at least " + fe.Param() + lengthUnit(fe)
+	case "lte", "max":
+		return "must be at most " + fe.Param() + lengthUnit(fe)
+	case "email":
+		return "must be an email address"
+	case "e164":
+		return "must be a phone number in E.164 format, e.g. +14155550100"
+	case "enum":
+		return fmt.Sprintf("unsupported value %q", fe.Value())
+	}
+	return "failed on " + fe.Tag()
+}
+
+// lengthUnit qualifies min and max limits of strings, which count characters.
+func lengthUnit(fe validator.FieldError) string {
+	if fe.Kind() == reflect.String {
+		return " characters"
+	}
+	return ""
+}
+
+// checkReference appends a FieldError to fields when exists reports no entity with the given ID.
+// Zero IDs are skipped since they are already reported by the "required" tag.
+func checkReference(ctx context.Context, fields []FieldError, field string, entity string, id int, exists func(ctx context.Context, id uint) (bool, error)) ([]FieldError, error) {
+	if id == 0 {
+		return fields, nil
+	}
+
+	found, err := exists(ctx, uint(id))
+	if err != nil {
+		return fields, dbError(err, entity, id)
+	}
+
+	if !found {
+		fields = append(fields, FieldError{
+			Field:   field,
+			Message: fmt.Sprintf("%s %d does not exist", entity, id),
+		})
+	}
+
+	return fields, nil
+}
@@ -65,6 +65,9 @@ func (aws *AesWithSalt) Decrypt(ciphertext string) (string, error) {
 
 	// get prefix (salt header + salt),
 	// salt uses same length as salt header's, so AesSaltHeaderLength*2
+	if len(ct) < AesSaltHeaderLength*2 {
+		return "", errors.New("ciphertext is shorter than the salt header")
+	}
 	prefix := ct[:AesSaltHeaderLength*2]
 	// salt header check
 	saltHeader := []byte(prefix[:AesSaltHeaderLength])
@@ -0,0 +1,249 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Envelope format, version 1, hexadecimal encoded like AesWithSalt's:
+//
+//	magic    4 bytes  "FMAE"
+//	version  1 byte   1
+//	time     1 byte   Argon2id passes
+//	memory   4 bytes  Argon2id memory in KiB, big endian
+//	threads  1 byte   Argon2id parallelism
+//	salt    16 bytes
+//	nonce   12 bytes
+//	sealed            AES-256-GCM ciphertext and 16-byte tag
+//
+// Everything before sealed is authenticated as additional data, so the KDF
+// parameters cannot be altered either.
+const (
+	EnvelopeMagic   = "FMAE"
+	EnvelopeVersion = 1
+
+	envelopeSaltLength   = 16
+	envelopeNonceLength  = 12
+	envelopeKeyLength    = 32
+	envelopeHeaderLength = len(EnvelopeMagic) + 1 + 1 + 4 + 1 + envelopeSaltLength + envelopeNonceLength
+
+	// envelopeMaxMemory bounds the memory, 256 MiB, a header may ask for, as it is
+	// read before the envelope is authenticated.
+	envelopeMaxMemory = 256 * 1024
+	envelopeMaxTime   = 16
+	// envelopeCacheSize bounds the keys kept for the salts seen by Decrypt.
+	envelopeCacheSize = 64
+)
+
+// legacyHexHeader starts the hexadecimal ciphertexts of AesWithSalt.
+var legacyHexHeader = hex.EncodeToString([]byte(AesSaltHeader))
+
+// Argon2Params are the Argon2id parameters deriving an Envelope's keys.
+type Argon2Params struct {
+	Time    uint8
+	Memory  uint32 // KiB
+	Threads uint8
+}
+
+// DefaultArgon2Params follow the OWASP recommendation for Argon2id: 2 passes over
+// 19 MiB with one thread.
+var DefaultArgon2Params = Argon2Params{Time: 2, Memory: 19 * 1024, Threads: 1}
+
+// Envelope encrypts with AES-256-GCM under a key derived from a password with
+// Argon2id, so altered ciphertexts fail to decrypt instead of yielding garbage. It
+// also decrypts the ciphertexts of AesWithSalt, for values written before it
+// existed.
+// The key derivation is deliberately slow, so an Envelope picks one salt and
+// reuses its key for every value it encrypts, each with a fresh nonce, and keeps
+// the keys of the salts it decrypted.
+type Envelope struct {
+	password []byte
+	params   Argon2Params
+	legacy   *AesWithSalt
+
+	mu   sync.Mutex
+	salt []byte
+	keys map[string][]byte
+}
+
+// NewEnvelope creates an Envelope with DefaultArgon2Params.
+// Example
+// envelope := utils.NewEnvelope(masterKey)
+// ciphertext, err := envelope.Encrypt("hunter2")
+func NewEnvelope(password string) *Envelope {
+	return NewEnvelopeWithParams(password, DefaultArgon2Params)
+}
+
+// NewEnvelopeWithParams creates an Envelope deriving the keys of the values it
+// encrypts with params. Values are decrypted with the parameters they were
+// encrypted with.
+func NewEnvelopeWithParams(password string, params Argon2Params) *Envelope {
+	return &Envelope{
+		password: []byte(password),
+		params:   params,
+		legacy:   NewAesWithSalt(password),
+		keys:     map[string][]byte{},
+	}
+}
+
+// IsLegacyCiphertext tells whether ciphertext was encrypted by AesWithSalt rather
+// than by an Envelope.
+func IsLegacyCiphertext(ciphertext string) bool {
+	return strings.HasPrefix(ciphertext, legacyHexHeader)
+}
+
+// Encrypt encrypts plaintext to a hexadecimal encoded envelope.
+func (e *Envelope) Encrypt(plaintext string) (string, error) {
+	salt, key, err := e.sealingKey()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, envelopeNonceLength)
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	return e.seal(plaintext, key, salt, nonce)
+}
+
+// Decrypt decrypts a value returned by Encrypt or by AesWithSalt.Encrypt.
+func (e *Envelope) Decrypt(ciphertext string) (string, error) {
+	if IsLegacyCiphertext(ciphertext) {
+		return e.legacy.Decrypt(ciphertext)
+	}
+
+	ct, err := hex.DecodeString(ciphertext)
+	if err != nil {
+		return "", err
+	}
+	if len(ct) < envelopeHeaderLength || !bytes.HasPrefix(ct, []byte(EnvelopeMagic)) {
+		return "", errors.New("not an envelope")
+	}
+	header := ct[:envelopeHeaderLength]
+	if version := header[len(EnvelopeMagic)]; version != EnvelopeVersion {
+		return "", fmt.Errorf("unsupported envelope version %d", version)
+	}
+
+	params, salt, nonce := e.parseHeader(header)
+	if params.Time == 0 || params.Time > envelopeMaxTime || params.Threads == 0 ||
+		params.Memory < 8*uint32(params.Threads) || params.Memory > envelopeMaxMemory {
+		return "", errors.New("envelope has invalid key derivation parameters")
+	}
+
+	aead, err := newGCM(e.key(params, salt))
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := aead.Open(nil, nonce, ct[envelopeHeaderLength:], header)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// Upgrade re-encrypts a ciphertext of AesWithSalt into an envelope and reports
+// whether it did. Envelopes are returned unchanged.
+func (e *Envelope) Upgrade(ciphertext string) (string, bool, error) {
+	if !IsLegacyCiphertext(ciphertext) {
+		return ciphertext, false, nil
+	}
+	plaintext, err := e.legacy.Decrypt(ciphertext)
+	if err != nil {
+		return "", false, err
+	}
+	upgraded, err := e.Encrypt(plaintext)
+	if err != nil {
+		return "", false, err
+	}
+	return upgraded, true, nil
+}
+
+// seal encrypts plaintext under key, which was derived from salt with e.params.
+func (e *Envelope) seal(plaintext string, key, salt, nonce []byte) (string, error) {
+	header := make([]byte, 0, envelopeHeaderLength)
+	header = append(header, EnvelopeMagic...)
+	header = append(header, EnvelopeVersion, e.params.Time)
+	header = append(header, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(header[len(header)-4:], e.params.Memory)
+	header = append(header, e.params.Threads)
+	header = append(header, salt...)
+	header = append(header, nonce...)
+
+	aead, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(aead.Seal(header, nonce, []byte(plaintext), header)), nil
+}
+
+// parseHeader splits a header of envelopeHeaderLength bytes.
+func (e *Envelope) parseHeader(header []byte) (params Argon2Params, salt, nonce []byte) {
+	offset := len(EnvelopeMagic) + 1
+	params.Time = header[offset]
+	params.Memory = binary.BigEndian.Uint32(header[offset+1:])
+	params.Threads = header[offset+5]
+	offset += 6
+	return params, header[offset : offset+envelopeSaltLength], header[offset+envelopeSaltLength:]
+}
+
+// sealingKey returns the salt of the values e encrypts, picked on first use, and
+// its key.
+func (e *Envelope) sealingKey() (salt, key []byte, err error) {
+	e.mu.Lock()
+	salt = e.salt
+	e.mu.Unlock()
+
+	if salt == nil {
+		salt = make([]byte, envelopeSaltLength)
+		if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+			return nil, nil, err
+		}
+		e.mu.Lock()
+		if e.salt == nil {
+			e.salt = salt
+		}
+		salt = e.salt
+		e.mu.Unlock()
+	}
+	return salt, e.key(e.params, salt), nil
+}
+
+// key derives the key of salt with params, or takes it from the cache.
+func (e *Envelope) key(params Argon2Params, salt []byte) []byte {
+	id := fmt.Sprintf("%d/%d/%d/%x", params.Time, params.Memory, params.Threads, salt)
+
+	e.mu.Lock()
+	key, ok := e.keys[id]
+	e.mu.Unlock()
+	if ok {
+		return key
+	}
+
+	key = argon2.IDKey(e.password, salt, uint32(params.Time), params.Memory, params.Threads, envelopeKeyLength)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if len(e.keys) >= envelopeCacheSize {
+		e.keys = map[string][]byte{}
+	}
+	e.keys[id] = key
+	return key
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	cb, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(cb)
+}
@@ -0,0 +1,196 @@
+package utils
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// Known answers, computed outside this package: the legacy value with MD5 and
+// `openssl enc -aes-256-cbc`, the envelope with argon2.IDKey and crypto/cipher
+// following the format documented in envelope.go.
+const (
+	katPassword  = "fleet-monitor test key"
+	katPlaintext = "hunter2 on the link"
+
+	// katLegacy is AesWithSalt's "Salted_By_My_App__" header, the salt 00..11 and
+	// the CBC ciphertext.
+	katLegacy = "53616c7465645f42795f4d795f4170705f5f" +
+		"000102030405060708090a0b0c0d0e0f1011" +
+		"6da513cd25bfd7268106f586b4d984d757c9ccb83bdded13f9341ab8d8debb37"
+
+	// katEnvelope is version 1 with 1 pass over 64 KiB on 1 thread, the salt
+	// "0123456789abcdef" and the nonce "fleet-nonce!".
+	katEnvelope = "464d4145" + "01" + "01" + "00000040" + "01" +
+		"30313233343536373839616263646566" +
+		"666c6565742d6e6f6e636521" +
+		"83c5f9218a04b4775aa52416a30bc1791ccca41c0b06126ef7655adc05a7c2959bf8b4"
+)
+
+var katParams = Argon2Params{Time: 1, Memory: 64, Threads: 1}
+
+func TestAesWithSaltKnownAnswer(t *testing.T) {
+	plaintext, err := NewAesWithSalt(katPassword).Decrypt(katLegacy)
+	if err != nil || plaintext != katPlaintext {
+		t.Fatalf("Decrypt = %q, %v, want %q", plaintext, err, katPlaintext)
+	}
+}
+
+func TestEnvelopeDecryptKnownAnswers(t *testing.T) {
+	e := NewEnvelopeWithParams(katPassword, katParams)
+
+	for name, ciphertext := range map[string]string{"legacy": katLegacy, "envelope": katEnvelope} {
+		plaintext, err := e.Decrypt(ciphertext)
+		if err != nil || plaintext != katPlaintext {
+			t.Errorf("Decrypt(%s) = %q, %v, want %q", name, plaintext, err, katPlaintext)
+		}
+	}
+
+	// A default Envelope reads the parameters from the header.
+	if plaintext, err := NewEnvelope(katPassword).Decrypt(katEnvelope); err != nil || plaintext != katPlaintext {
+		t.Errorf("Decrypt with the default parameters = %q, %v", plaintext, err)
+	}
+
+	if _, err := NewEnvelopeWithParams("wrong key", katParams).Decrypt(katEnvelope); err == nil {
+		t.Error("Decrypt with the wrong password succeeded")
+	}
+}
+
+func TestEnvelopeSealKnownAnswer(t *testing.T) {
+	e := NewEnvelopeWithParams(katPassword, katParams)
+	salt := []byte("0123456789abcdef")
+
+	ciphertext, err := e.seal(katPlaintext, e.key(katParams, salt), salt, []byte("fleet-nonce!"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ciphertext != katEnvelope {
+		t.Fatalf("seal = %s, want %s", ciphertext, katEnvelope)
+	}
+}
+
+func TestEnvelopeEncrypt(t *testing.T) {
+	e := NewEnvelopeWithParams(katPassword, katParams)
+
+	first, err := e.Encrypt(katPlaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := e.Encrypt(katPlaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first == second {
+		t.Fatal("two encryptions share a nonce")
+	}
+	if !strings.HasPrefix(first, hex.EncodeToString([]byte(EnvelopeMagic))) || IsLegacyCiphertext(first) {
+		t.Fatalf("Encrypt = %s, want an envelope", first)
+	}
+
+	for _, ciphertext := range []string{first, second} {
+		plaintext, err := NewEnvelopeWithParams(katPassword, DefaultArgon2Params).Decrypt(ciphertext)
+		if err != nil || plaintext != katPlaintext {
+			t.Errorf("Decrypt = %q, %v, want %q", plaintext, err, katPlaintext)
+		}
+	}
+}
+
+func TestEnvelopeTamper(t *testing.T) {
+	ct, err := hex.DecodeString(katEnvelope)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := NewEnvelopeWithParams(katPassword, katParams)
+
+	// Every byte is covered: the header as additional data, the rest by the tag.
+	for i := range ct {
+		tampered := append([]byte(nil), ct...)
+		tampered[i] ^= 0x01
+		if plaintext, err := e.Decrypt(hex.EncodeToString(tampered)); err == nil {
+			t.Errorf("flipping byte %d decrypted to %q", i, plaintext)
+		}
+	}
+
+	for name, ciphertext := range map[string]string{
+		"truncated":    katEnvelope[:len(katEnvelope)-2],
+		"header only":  katEnvelope[:2*envelopeHeaderLength],
+		"short header": katEnvelope[:20],
+		"not hex":      "FMAE",
+		"empty":        "",
+	} {
+		if _, err := e.Decrypt(ciphertext); err == nil {
+			t.Errorf("Decrypt(%s) succeeded", name)
+		}
+	}
+
+	// A legacy value has no tag, but a broken header or padding is still caught.
+	legacy, _ := hex.DecodeString(katLegacy)
+	legacy[AesSaltHeaderLength-1] ^= 0x01
+	if _, err := e.Decrypt(hex.EncodeToString(legacy)); err == nil {
+		t.Error("legacy value with a broken header decrypted")
+	}
+}
+
+func TestEnvelopeRejectsHeaders(t *testing.T) {
+	ct, err := hex.DecodeString(katEnvelope)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const (
+		versionOffset = len(EnvelopeMagic)
+		timeOffset    = versionOffset + 1
+		memoryOffset  = timeOffset + 1
+		threadsOffset = memoryOffset + 4
+	)
+
+	tests := []struct {
+		name   string
+		modify func(header []byte)
+		want   string
+	}{
+		{"version 2", func(h []byte) { h[versionOffset] = 2 }, "unsupported envelope version 2"},
+		{"too many passes", func(h []byte) { h[timeOffset] = envelopeMaxTime + 1 }, "key derivation parameters"},
+		{"no passes", func(h []byte) { h[timeOffset] = 0 }, "key derivation parameters"},
+		{"too much memory", func(h []byte) { binary.BigEndian.PutUint32(h[memoryOffset:], envelopeMaxMemory+1) }, "key derivation parameters"},
+		{"too little memory", func(h []byte) { binary.BigEndian.PutUint32(h[memoryOffset:], 7) }, "key derivation parameters"},
+		{"no threads", func(h []byte) { h[threadsOffset] = 0 }, "key derivation parameters"},
+		{"bad magic", func(h []byte) { h[0] = 'X' }, "not an envelope"},
+	}
+
+	e := NewEnvelopeWithParams(katPassword, katParams)
+	for _, tt := range tests {
+		tampered := append([]byte(nil), ct...)
+		tt.modify(tampered)
+		_, err := e.Decrypt(hex.EncodeToString(tampered))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: Decrypt error %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestEnvelopeUpgrade(t *testing.T) {
+	e := NewEnvelopeWithParams(katPassword, katParams)
+
+	upgraded, ok, err := e.Upgrade(katLegacy)
+	if err != nil || !ok {
+		t.Fatalf("Upgrade(legacy) = %v, %v", ok, err)
+	}
+	if IsLegacyCiphertext(upgraded) {
+		t.Fatal("Upgrade left a legacy value")
+	}
+	if plaintext, err := e.Decrypt(upgraded); err != nil || plaintext != katPlaintext {
+		t.Fatalf("Decrypt(upgraded) = %q, %v", plaintext, err)
+	}
+
+	for _, envelope := range []string{katEnvelope, upgraded} {
+		same, ok, err := e.Upgrade(envelope)
+		if err != nil || ok || same != envelope {
+			t.Errorf("Upgrade(envelope) = %s, %v, %v, want it unchanged", same, ok, err)
+		}
+	}
+
+	if _, _, err := NewEnvelopeWithParams("wrong key", katParams).Upgrade(katLegacy); err == nil {
+		t.Error("Upgrade with the wrong password succeeded")
+	}
+}
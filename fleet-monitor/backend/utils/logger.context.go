@@ -0,0 +1,31 @@
+package utils
+
+import "context"
+
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of ctx carrying the ID of the request it serves.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID carried by ctx, or "" if there is none.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// WithContext returns a logger adding the request ID carried by ctx, if any, to every
+// entry, so the entries of the web, service and database loggers for one request can
+// be correlated.
+// Example
+// logger.WithContext(ctx).Warn("drone grounded", "drone", drone.ID)
+func (l *Logger) WithContext(ctx context.Context) *Logger {
+	if ctx == nil {
+		return l
+	}
+	if id := RequestID(ctx); id != "" {
+		return l.With("requestId", id)
+	}
+	return l
+}
@@ -1,56 +1,323 @@
 package utils
 
 import (
-	"log"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
 	"os"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
+	"unicode"
+)
 
-	"gorm.io/gorm/logger"
+// Prefixes of the subsystem loggers, also the keys of LogOptions.Levels.
+const (
+	LogPrefixDatabase = "DBS"
+	LogPrefixWails    = "WLS"
+	LogPrefixI18n     = "I18"
+	LogPrefixTray     = "TRY"
+	LogPrefixWeb      = "WEB"
+	LogPrefixMavlink  = "MAV"
 )
 
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+var levelNames = []string{"debug", "info", "warn", "error"}
+
+// levelTags are the level names in console output.
+var levelTags = []string{"DEB", "INF", "WAR", "ERR"}
+
+func (l Level) String() string {
+	if l < LevelDebug || l > LevelError {
+		return "level(" + strconv.Itoa(int(l)) + ")"
+	}
+	return levelNames[l]
+}
+
+// ParseLevel parses debug, info, warn or error.
+func ParseLevel(s string) (Level, error) {
+	for i, name := range levelNames {
+		if strings.EqualFold(s, name) {
+			return Level(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown log level %q, expected one of %s", s, strings.Join(levelNames, ", "))
+}
+
+// ParseLevels parses per-subsystem levels such as "DBS=warn,WEB=debug".
+func ParseLevels(s string) (map[string]Level, error) {
+	levels := map[string]Level{}
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		prefix, name, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid log level override %q, expected PREFIX=level", item)
+		}
+		level, err := ParseLevel(strings.TrimSpace(name))
+		if err != nil {
+			return nil, err
+		}
+		levels[strings.TrimSpace(prefix)] = level
+	}
+	return levels, nil
+}
+
+type LogFormat string
+
+const (
+	// LogFormatConsole writes one readable line per entry, e.g.
+	// 2023-11-01 10:00:00.000 INF [MAV] drone connected drone=7 autopilot=px4
+	LogFormatConsole LogFormat = "console"
+	// LogFormatJSON writes one JSON object per line with time, level, logger and msg
+	// followed by the entry's fields.
+	LogFormatJSON LogFormat = "json"
+)
+
+// LogOptions configures a LogOutput.
+type LogOptions struct {
+	Format LogFormat
+	Level  Level
+	// Levels overrides Level for the subsystems with the given prefixes, e.g. {"DBS": LevelWarn}.
+	Levels map[string]Level
+	// File is the path of the log file. Empty logs to stdout.
+	File     string
+	Rotation RotationOptions
+}
+
+// LogOutput is where the loggers of all subsystems write, in a single format.
+type LogOutput struct {
+	mu     sync.Mutex
+	w      io.Writer
+	closer io.Closer
+	format LogFormat
+	level  Level
+	levels map[string]Level
+}
+
+// NewLogOutput opens the output described by opts.
+// Example
+// output, err := utils.NewLogOutput(utils.LogOptions{Format: utils.LogFormatJSON, Level: utils.LevelInfo, File: "fleet-monitor.log", Rotation: utils.RotationOptions{MaxSizeMB: 10, MaxBackups: 5}})
+// logger := output.Logger(utils.LogPrefixWeb)
+func NewLogOutput(opts LogOptions) (*LogOutput, error) {
+	switch opts.Format {
+	case "":
+		opts.Format = LogFormatConsole
+	case LogFormatConsole, LogFormatJSON:
+	default:
+		return nil, fmt.Errorf("unknown log format %q, expected console or json", opts.Format)
+	}
+
+	output := &LogOutput{w: os.Stdout, format: opts.Format, level: opts.Level, levels: opts.Levels}
+	if opts.File != "" {
+		file, err := OpenRotatingFile(opts.File, opts.Rotation)
+		if err != nil {
+			return nil, err
+		}
+		output.w, output.closer = file, file
+	}
+	return output, nil
+}
+
+func newWriterOutput(w io.Writer, level Level) *LogOutput {
+	return &LogOutput{w: w, format: LogFormatConsole, level: level}
+}
+
+// Logger returns the logger of the subsystem with the given prefix.
+func (o *LogOutput) Logger(prefix string) *Logger {
+	level, ok := o.levels[prefix]
+	if !ok {
+		level = o.level
+	}
+	return &Logger{out: o, prefix: prefix, level: level}
+}
+
+// Close closes the log file, if any.
+func (o *LogOutput) Close() error {
+	if o.closer == nil {
+		return nil
+	}
+	return o.closer.Close()
+}
+
+func (o *LogOutput) write(entry []byte) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	// There is nowhere left to report a failed log write.
+	_, _ = o.w.Write(entry)
+}
+
+// Logger writes leveled entries with key/value fields for one subsystem.
 type Logger struct {
-	*log.Logger
+	out    *LogOutput
+	prefix string
+	level  Level
+	fields []interface{}
 }
 
+// NewConsoleLogger returns a logger writing info and above to stdout.
 func NewConsoleLogger(prefix string) *Logger {
-	l := &Logger{}
-	l.Logger = log.New(os.Stdout, l.modifyPrefix(prefix), log.Ldate|log.Ltime|log.Lshortfile)
-	return l
+	return newWriterOutput(os.Stdout, LevelInfo).Logger(prefix)
 }
 
+// NewFileLogger returns a logger writing info and above to f.
 func NewFileLogger(prefix string, f *os.File) *Logger {
-	l := &Logger{}
-	l.Logger = log.New(f, l.modifyPrefix(prefix), log.Ldate|log.Ltime|log.Llongfile)
-	return l
-}
-
-func NewGormConsoleLogger(prefix string) logger.Interface {
-	return logger.New(
-		NewConsoleLogger(prefix),
-		logger.Config{
-			SlowThreshold:             time.Second,
-			Colorful:                  true,
-			IgnoreRecordNotFoundError: false,
-			LogLevel:                  logger.Info,
-		},
-	)
-}
-
-func NewGormFileLogger(prefix string, file *os.File) logger.Interface {
-	return logger.New(
-		NewFileLogger(prefix, file),
-		logger.Config{
-			SlowThreshold:             time.Second,
-			Colorful:                  false,
-			IgnoreRecordNotFoundError: false,
-			LogLevel:                  logger.Error,
-		},
-	)
-}
-
-func (l *Logger) modifyPrefix(prefix string) string {
-	if prefix == "" {
-		return prefix
-	}
-	return "[" + prefix + "] "
+	return newWriterOutput(f, LevelInfo).Logger(prefix)
+}
+
+// With returns a logger adding the given key/value pairs to every entry.
+// Example
+// logger.With("drone", 7).Warn("battery low", "remaining", 18)
+func (l *Logger) With(keyvals ...interface{}) *Logger {
+	child := *l
+	child.fields = append(append([]interface{}{}, l.fields...), keyvals...)
+	return &child
+}
+
+// WithLevel returns a logger with a different minimum level.
+func (l *Logger) WithLevel(level Level) *Logger {
+	child := *l
+	child.level = level
+	return &child
+}
+
+// Enabled reports whether entries of the given level are written.
+func (l *Logger) Enabled(level Level) bool {
+	return level >= l.level
+}
+
+func (l *Logger) Debug(msg string, keyvals ...interface{}) { l.log(LevelDebug, msg, keyvals) }
+func (l *Logger) Info(msg string, keyvals ...interface{})  { l.log(LevelInfo, msg, keyvals) }
+func (l *Logger) Warn(msg string, keyvals ...interface{})  { l.log(LevelWarn, msg, keyvals) }
+func (l *Logger) Error(msg string, keyvals ...interface{}) { l.log(LevelError, msg, keyvals) }
+
+// Printf writes an info entry for callers that format their own messages.
+func (l *Logger) Printf(format string, v ...interface{}) {
+	l.log(LevelInfo, fmt.Sprintf(format, v...), nil)
+}
+
+func (l *Logger) log(level Level, msg string, keyvals []interface{}) {
+	if !l.Enabled(level) {
+		return
+	}
+
+	fields := l.fields
+	if len(keyvals) > 0 {
+		fields = append(append([]interface{}{}, l.fields...), keyvals...)
+	}
+
+	var buf bytes.Buffer
+	now := time.Now()
+	if l.out.format == LogFormatJSON {
+		writeJSONEntry(&buf, now, level, l.prefix, msg, fields)
+	} else {
+		writeConsoleEntry(&buf, now, level, l.prefix, msg, fields)
+	}
+	l.out.write(buf.Bytes())
+}
+
+func writeConsoleEntry(buf *bytes.Buffer, now time.Time, level Level, prefix, msg string, fields []interface{}) {
+	buf.WriteString(now.Format("2006-01-02 15:04:05.000"))
+	buf.WriteByte(' ')
+	buf.WriteString(levelTags[level])
+	if prefix != "" {
+		buf.WriteString(" [" + prefix + "]")
+	}
+	buf.WriteByte(' ')
+	buf.WriteString(msg)
+	eachField(fields, func(key string, value interface{}) {
+		buf.WriteByte(' ')
+		buf.WriteString(key)
+		buf.WriteByte('=')
+		buf.WriteString(consoleValue(value))
+	})
+	buf.WriteByte('\n')
+}
+
+func writeJSONEntry(buf *bytes.Buffer, now time.Time, level Level, prefix, msg string, fields []interface{}) {
+	buf.WriteString(`{"time":`)
+	writeJSON(buf, now.Format(time.RFC3339Nano))
+	buf.WriteString(`,"level":`)
+	writeJSON(buf, level.String())
+	if prefix != "" {
+		buf.WriteString(`,"logger":`)
+		writeJSON(buf, prefix)
+	}
+	buf.WriteString(`,"msg":`)
+	writeJSON(buf, msg)
+	eachField(fields, func(key string, value interface{}) {
+		buf.WriteByte(',')
+		writeJSON(buf, key)
+		buf.WriteByte(':')
+		writeJSON(buf, plainValue(value))
+	})
+	buf.WriteString("}\n")
+}
+
+// eachField calls fn for each key/value pair. A key without a value is reported
+// under the key "!extra".
+func eachField(fields []interface{}, fn func(key string, value interface{})) {
+	for i := 0; i < len(fields); i += 2 {
+		if i+1 == len(fields) {
+			fn("!extra", fields[i])
+			return
+		}
+		fn(fmt.Sprint(fields[i]), fields[i+1])
+	}
+}
+
+// plainValue turns errors, durations, times and Stringers into strings so they read
+// the same in both formats.
+func plainValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case error:
+		return v.Error()
+	case time.Duration:
+		return v.String()
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case fmt.Stringer:
+		return v.String()
+	}
+	return value
+}
+
+func writeJSON(buf *bytes.Buffer, value interface{}) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		data, _ = json.Marshal(fmt.Sprint(value))
+	}
+	buf.Write(data)
+}
+
+func consoleValue(value interface{}) string {
+	var s string
+	switch v := plainValue(value).(type) {
+	case string:
+		s = v
+	case map[string]interface{}, []interface{}:
+		data, _ := json.Marshal(v)
+		s = string(data)
+	default:
+		s = fmt.Sprint(v)
+	}
+
+	if s == "" || strings.IndexFunc(s, func(r rune) bool { return unicode.IsSpace(r) || r == '"' || r == '=' }) >= 0 {
+		return strconv.Quote(s)
+	}
+	return s
 }
@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// slowQueryThreshold is how long a query runs before it is logged as a warning.
+const slowQueryThreshold = time.Second
+
+// GormLogger writes gorm's messages and queries through a Logger. Failed queries are
+// errors, slow queries are warnings and every other query is a debug entry. Queries
+// run with a request's context are tagged with its request ID.
+type GormLogger struct {
+	log *Logger
+}
+
+// NewGormLogger adapts l to gorm's logger interface.
+// Example
+// conn.Logger = utils.NewGormLogger(output.Logger(utils.LogPrefixDatabase))
+func NewGormLogger(l *Logger) logger.Interface {
+	return &GormLogger{log: l}
+}
+
+func NewGormConsoleLogger(prefix string) logger.Interface {
+	return NewGormLogger(NewConsoleLogger(prefix).WithLevel(LevelDebug))
+}
+
+func NewGormFileLogger(prefix string, file *os.File) logger.Interface {
+	return NewGormLogger(NewFileLogger(prefix, file).WithLevel(LevelError))
+}
+
+// LogMode implements logger.Interface
+func (g *GormLogger) LogMode(level logger.LogLevel) logger.Interface {
+	switch level {
+	case logger.Silent:
+		return &GormLogger{log: g.log.WithLevel(LevelError + 1)}
+	case logger.Error:
+		return &GormLogger{log: g.log.WithLevel(LevelError)}
+	case logger.Warn:
+		return &GormLogger{log: g.log.WithLevel(LevelWarn)}
+	}
+	return &GormLogger{log: g.log.WithLevel(LevelDebug)}
+}
+
+// Info implements logger.Interface
+func (g *GormLogger) Info(ctx context.Context, msg string, data ...interface{}) {
+	g.log.WithContext(ctx).Info(fmt.Sprintf(msg, data...))
+}
+
+// Warn implements logger.Interface
+func (g *GormLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	g.log.WithContext(ctx).Warn(fmt.Sprintf(msg, data...))
+}
+
+// Error implements logger.Interface
+func (g *GormLogger) Error(ctx context.Context, msg string, data ...interface{}) {
+	g.log.WithContext(ctx).Error(fmt.Sprintf(msg, data...))
+}
+
+// Trace implements logger.Interface
+func (g *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	elapsed := time.Since(begin)
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound) && g.log.Enabled(LevelError):
+		sql, rows := fc()
+		g.log.WithContext(ctx).Error("query failed", "sql", sql, "rows", rows, "elapsed", elapsed, "error", err)
+	case elapsed > slowQueryThreshold && g.log.Enabled(LevelWarn):
+		sql, rows := fc()
+		g.log.WithContext(ctx).Warn("slow query", "sql", sql, "rows", rows, "elapsed", elapsed)
+	case g.log.Enabled(LevelDebug):
+		sql, rows := fc()
+		g.log.WithContext(ctx).Debug("query", "sql", sql, "rows", rows, "elapsed", elapsed)
+	}
+}
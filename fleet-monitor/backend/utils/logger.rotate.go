@@ -0,0 +1,176 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RotationOptions decides when a RotatingFile starts a new file and how many old
+// ones it keeps. Zero values disable the corresponding rule.
+type RotationOptions struct {
+	// MaxSizeMB rotates the file before it grows past this size.
+	MaxSizeMB int
+	// MaxAge rotates the file once it has been written to for this long, e.g. 24h
+	// for a file per day.
+	MaxAge time.Duration
+	// MaxBackups is how many rotated files are kept.
+	MaxBackups int
+	// Retention deletes rotated files older than this.
+	Retention time.Duration
+}
+
+// backupTimeFormat is inserted before the extension of rotated files, e.g.
+// fleet-monitor-2023-11-01T10-00-00.000.log. It sorts in time order.
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// RotatingFile is a log file that is renamed with a timestamp and replaced by a new
+// one when it gets too big or too old.
+type RotatingFile struct {
+	mu       sync.Mutex
+	path     string
+	opts     RotationOptions
+	file     *os.File
+	size     int64
+	openedAt time.Time
+}
+
+// OpenRotatingFile opens path for appending, creating it and its directory if needed.
+// Example
+// file, err := utils.OpenRotatingFile("logs/fleet-monitor.log", utils.RotationOptions{MaxSizeMB: 10, MaxAge: 24 * time.Hour, MaxBackups: 7})
+func OpenRotatingFile(path string, opts RotationOptions) (*RotatingFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+
+	r := &RotatingFile{path: path, opts: opts}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Write implements io.Writer. An entry is never split across files.
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return 0, os.ErrClosed
+	}
+	// A failed rotation leaves the current file open, and the entry goes there.
+	var rotateErr error
+	if r.needsRotation(len(p)) {
+		if rotateErr = r.rotate(); r.file == nil {
+			return 0, rotateErr
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	if err == nil {
+		err = rotateErr
+	}
+	return n, err
+}
+
+// Close implements io.Closer
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+func (r *RotatingFile) open() error {
+	file, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	r.file = file
+	r.size = info.Size()
+	r.openedAt = time.Now()
+	return nil
+}
+
+func (r *RotatingFile) needsRotation(next int) bool {
+	if r.size == 0 {
+		return false
+	}
+	if r.opts.MaxSizeMB > 0 && r.size+int64(next) > int64(r.opts.MaxSizeMB)<<20 {
+		return true
+	}
+	return r.opts.MaxAge > 0 && time.Since(r.openedAt) >= r.opts.MaxAge
+}
+
+func (r *RotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return err
+	}
+	r.file = nil
+
+	ext := filepath.Ext(r.path)
+	backup := strings.TrimSuffix(r.path, ext) + "-" + time.Now().Format(backupTimeFormat) + ext
+	if err := os.Rename(r.path, backup); err != nil {
+		// Keep appending to the current file rather than losing every later entry.
+		if openErr := r.open(); openErr != nil {
+			return fmt.Errorf("%w; reopening %s: %v", err, r.path, openErr)
+		}
+		return err
+	}
+
+	if err := r.open(); err != nil {
+		return err
+	}
+	return r.prune()
+}
+
+// prune deletes the rotated files beyond MaxBackups or older than Retention.
+func (r *RotatingFile) prune() error {
+	ext := filepath.Ext(r.path)
+	base := strings.TrimSuffix(r.path, ext) + "-"
+	matches, err := filepath.Glob(base + "*" + ext)
+	if err != nil {
+		return err
+	}
+
+	var backups []string
+	for _, match := range matches {
+		stamp := strings.TrimSuffix(strings.TrimPrefix(match, base), ext)
+		if _, err := time.Parse(backupTimeFormat, stamp); err == nil {
+			backups = append(backups, match)
+		}
+	}
+	// Newest first.
+	sort.Sort(sort.Reverse(sort.StringSlice(backups)))
+
+	for i, backup := range backups {
+		expired := false
+		if r.opts.Retention > 0 {
+			if info, err := os.Stat(backup); err == nil && time.Since(info.ModTime()) > r.opts.Retention {
+				expired = true
+			}
+		}
+		if (r.opts.MaxBackups > 0 && i >= r.opts.MaxBackups) || expired {
+			if err := os.Remove(backup); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
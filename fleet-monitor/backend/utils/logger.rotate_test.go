@@ -0,0 +1,136 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// backupsOf returns the rotated files of the log file at path.
+func backupsOf(t *testing.T, path string) []string {
+	t.Helper()
+	ext := filepath.Ext(path)
+	matches, err := filepath.Glob(strings.TrimSuffix(path, ext) + "-*" + ext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return matches
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestRotatingFileBySize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "fleet.log")
+	file, err := OpenRotatingFile(path, RotationOptions{MaxSizeMB: 1, MaxBackups: 2})
+	if err != nil {
+		t.Fatalf("OpenRotatingFile: %v", err)
+	}
+	defer file.Close()
+
+	entry := []byte(strings.Repeat("x", 600<<10) + "\n")
+	for i := 0; i < 4; i++ {
+		if _, err := file.Write(entry); err != nil {
+			t.Fatalf("Write %d: %v", i, err)
+		}
+		// Backups are named to the millisecond.
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	// Each entry after the first starts a new file, and only two backups are kept.
+	if backups := backupsOf(t, path); len(backups) != 2 {
+		t.Errorf("backups = %v, want 2", backups)
+	}
+	if got := readFile(t, path); got != string(entry) {
+		t.Errorf("current file holds %d bytes, want one entry", len(got))
+	}
+}
+
+func TestRotatingFileByAge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fleet.log")
+	file, err := OpenRotatingFile(path, RotationOptions{MaxAge: time.Hour})
+	if err != nil {
+		t.Fatalf("OpenRotatingFile: %v", err)
+	}
+	defer file.Close()
+
+	file.Write([]byte("first\n"))
+	file.Write([]byte("second\n"))
+	if backups := backupsOf(t, path); len(backups) != 0 {
+		t.Fatalf("backups of a new file = %v", backups)
+	}
+
+	file.openedAt = file.openedAt.Add(-time.Hour)
+	file.Write([]byte("third\n"))
+	backups := backupsOf(t, path)
+	if len(backups) != 1 || readFile(t, backups[0]) != "first\nsecond\n" || readFile(t, path) != "third\n" {
+		t.Errorf("after a day, backups = %v and the file holds %q", backups, readFile(t, path))
+	}
+}
+
+func TestRotatingFileKeepsWritingWhenRenameFails(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fleet.log")
+	file, err := OpenRotatingFile(path, RotationOptions{MaxAge: time.Hour})
+	if err != nil {
+		t.Fatalf("OpenRotatingFile: %v", err)
+	}
+	defer file.Close()
+
+	file.Write([]byte("first\n"))
+	// With the file gone the rename fails.
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	file.openedAt = file.openedAt.Add(-time.Hour)
+
+	n, err := file.Write([]byte("second\n"))
+	if err == nil || n != len("second\n") {
+		t.Fatalf("Write during a failed rotation = %d, %v, want the entry written and the error", n, err)
+	}
+	if _, err := file.Write([]byte("third\n")); err != nil {
+		t.Fatalf("Write after a failed rotation: %v", err)
+	}
+	if got := readFile(t, path); got != "second\nthird\n" {
+		t.Errorf("file after a failed rotation = %q", got)
+	}
+}
+
+func TestRotatingFileRetention(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fleet.log")
+	old := filepath.Join(filepath.Dir(path), "fleet-2023-11-01T10-00-00.000.log")
+	unrelated := filepath.Join(filepath.Dir(path), "fleet-notes.log")
+	for _, name := range []string{old, unrelated} {
+		if err := os.WriteFile(name, []byte("old\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		past := time.Now().Add(-48 * time.Hour)
+		os.Chtimes(name, past, past)
+	}
+
+	file, err := OpenRotatingFile(path, RotationOptions{MaxAge: time.Hour, Retention: 24 * time.Hour})
+	if err != nil {
+		t.Fatalf("OpenRotatingFile: %v", err)
+	}
+	defer file.Close()
+	file.Write([]byte("first\n"))
+	file.openedAt = file.openedAt.Add(-time.Hour)
+	file.Write([]byte("second\n"))
+
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Errorf("expired backup still exists: %v", err)
+	}
+	if _, err := os.Stat(unrelated); err != nil {
+		t.Errorf("a file that is not a backup was removed: %v", err)
+	}
+	if backups := backupsOf(t, path); len(backups) != 2 {
+		t.Errorf("backups = %v, want the new one and fleet-notes.log", backups)
+	}
+}
@@ -1,59 +1,62 @@
 package utils
 
 import (
-	"log"
 	"os"
 
 	"github.com/wailsapp/wails/v2/pkg/logger"
 )
 
+// WailsLogger writes Wails' messages through a Logger. Trace messages are debug
+// entries and Print messages are info entries.
 type WailsLogger struct {
-	log *log.Logger
+	log *Logger
+}
+
+// NewWailsLogger adapts l to Wails' logger interface.
+func NewWailsLogger(l *Logger) logger.Logger {
+	return &WailsLogger{log: l}
 }
 
 // Print implements logger.Logger
 func (w *WailsLogger) Print(message string) {
-	w.log.Println(message)
+	w.log.Info(message)
 }
 
 // Trace implements logger.Logger
 func (w *WailsLogger) Trace(message string) {
-	w.log.Println("TRA | " + message)
+	w.log.Debug(message)
 }
 
 // Debug implements logger.Logger
 func (w *WailsLogger) Debug(message string) {
-	w.log.Println("DEB | " + message)
+	w.log.Debug(message)
 }
 
 // Info implements logger.Logger
 func (w *WailsLogger) Info(message string) {
-	w.log.Println("INF | " + message)
+	w.log.Info(message)
 }
 
 // Warning implements logger.Logger
 func (w *WailsLogger) Warning(message string) {
-	w.log.Println("WAR | " + message)
+	w.log.Warn(message)
 }
 
 // Error implements logger.Logger
 func (w *WailsLogger) Error(message string) {
-	w.log.Println("ERR | " + message)
+	w.log.Error(message)
 }
 
 // Fatal implements logger.Logger
 func (w *WailsLogger) Fatal(message string) {
-	w.log.Fatalln("FAT | " + message)
+	w.log.Error(message, "fatal", true)
+	os.Exit(1)
 }
 
 func NewWailsConsoleLogger(prefix string) logger.Logger {
-	return &WailsLogger{
-		log: NewConsoleLogger(prefix).Logger,
-	}
+	return NewWailsLogger(NewConsoleLogger(prefix).WithLevel(LevelDebug))
 }
 
 func NewWailsFileLogger(prefix string, file *os.File) logger.Logger {
-	return &WailsLogger{
-		log: NewFileLogger(prefix, file).Logger,
-	}
+	return NewWailsLogger(NewFileLogger(prefix, file).WithLevel(LevelDebug))
 }
@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoggerLevels(t *testing.T) {
+	var buf bytes.Buffer
+	output := &LogOutput{w: &buf, format: LogFormatConsole, level: LevelInfo, levels: map[string]Level{LogPrefixDatabase: LevelError}}
+
+	web := output.Logger(LogPrefixWeb)
+	web.Debug("hidden")
+	web.Info("shown")
+	web.WithLevel(LevelDebug).Debug("debug shown")
+	database := output.Logger(LogPrefixDatabase)
+	database.Warn("hidden")
+	database.Error("failed")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("logged %d lines, want 3:\n%s", len(lines), buf.String())
+	}
+	for i, want := range []string{"INF [WEB] shown", "DEB [WEB] debug shown", "ERR [DBS] failed"} {
+		if !strings.Contains(lines[i], want) {
+			t.Errorf("line %d = %q, want it to contain %q", i, lines[i], want)
+		}
+	}
+}
+
+func TestLoggerConsoleFields(t *testing.T) {
+	var buf bytes.Buffer
+	logger := newWriterOutput(&buf, LevelInfo).Logger(LogPrefixMavlink)
+
+	logger.With("drone", 7).Warn("battery low", "remaining", 18, "note", "land now", "dangling")
+
+	want := ` WAR [MAV] battery low drone=7 remaining=18 note="land now" !extra=dangling` + "\n"
+	if got := buf.String(); !strings.HasSuffix(got, want) {
+		t.Errorf("entry = %q, want it to end with %q", got, want)
+	}
+}
+
+func TestLoggerJSON(t *testing.T) {
+	var buf bytes.Buffer
+	output := &LogOutput{w: &buf, format: LogFormatJSON, level: LevelInfo}
+
+	output.Logger(LogPrefixWeb).With("requestId", "abc").Error("request failed",
+		"status", 500, "elapsed", 1500*time.Millisecond, "err", errors.New("db down"))
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("entry %q is not JSON: %v", buf.String(), err)
+	}
+	want := map[string]interface{}{
+		"level": "error", "logger": "WEB", "msg": "request failed",
+		"requestId": "abc", "status": 500.0, "elapsed": "1.5s", "err": "db down",
+	}
+	for key, value := range want {
+		if entry[key] != value {
+			t.Errorf("%s = %v, want %v", key, entry[key], value)
+		}
+	}
+	if _, err := time.Parse(time.RFC3339Nano, entry["time"].(string)); err != nil {
+		t.Errorf("time %v: %v", entry["time"], err)
+	}
+}
+
+func TestParseLevels(t *testing.T) {
+	levels, err := ParseLevels(" DBS=warn, WEB=DEBUG ,")
+	if err != nil || len(levels) != 2 || levels["DBS"] != LevelWarn || levels["WEB"] != LevelDebug {
+		t.Errorf("ParseLevels = %v, %v", levels, err)
+	}
+
+	for _, s := range []string{"DBS", "DBS=loud"} {
+		if _, err := ParseLevels(s); err == nil {
+			t.Errorf("ParseLevels(%q) succeeded", s)
+		}
+	}
+}
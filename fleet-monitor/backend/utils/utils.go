@@ -3,6 +3,7 @@ package utils
 import (
 	"crypto/sha1"
 	"encoding/hex"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -49,7 +50,9 @@ func Utils() *utils {
 }
 
 func (u *utils) Panic(v ...any) {
-	u.panicLogger.Panicln(v...)
+	message := strings.TrimSuffix(fmt.Sprintln(v...), "\n")
+	u.panicLogger.Error(message)
+	panic(message)
 }
 
 // GetExecutableName get the filename with the same name as application executable
@@ -0,0 +1,103 @@
+package webserver
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"fleet-monitor/backend/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader carries the ID of a request, from the client or a proxy in front
+// of the server, and back in the response.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds the request IDs taken from clients.
+const maxRequestIDLength = 128
+
+// RequestID keeps the X-Request-ID of incoming requests, or assigns a new one, echoes
+// it in the response and attaches it to the request's context for the loggers.
+// Use it before AccessLog and RequestTimeout.
+// Example
+// r.Use(RequestID(), AccessLog(output.Logger(utils.LogPrefixWeb)))
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		c.Header(RequestIDHeader, id)
+		c.Request = c.Request.WithContext(utils.WithRequestID(c.Request.Context(), id))
+		c.Next()
+	}
+}
+
+// validRequestID accepts IDs of printable ASCII without spaces, so a client cannot
+// forge log lines through it.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return time.Now().UTC().Format("20060102T150405.000000000")
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// AccessLog logs every request with its method, path, status, latency, user and
+// response size once it has been handled. Server errors are logged as errors along
+// with the errors the handler recorded, client errors as warnings.
+// The user is the one set under gin.AuthUserKey by an authentication middleware
+// such as gin.BasicAuth, and is left out for anonymous requests.
+func AccessLog(logger *utils.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		size := c.Writer.Size()
+		if size < 0 {
+			size = 0
+		}
+
+		fields := []interface{}{
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"status", status,
+			"latency", time.Since(start),
+			"bytes", size,
+			"clientIp", c.ClientIP(),
+		}
+		if user := c.GetString(gin.AuthUserKey); user != "" {
+			fields = append(fields, "user", user)
+		}
+		if len(c.Errors) > 0 {
+			fields = append(fields, "errors", strings.Join(c.Errors.Errors(), "; "))
+		}
+
+		log := logger.WithContext(c.Request.Context())
+		switch {
+		case status >= http.StatusInternalServerError:
+			log.Error("request", fields...)
+		case status >= http.StatusBadRequest:
+			log.Warn("request", fields...)
+		default:
+			log.Info("request", fields...)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package webserver
+
+// USAGE EXAMPLE
+// func main() {
+// 	r := gin.New()
+// 	r.Use(gin.Recovery(), RequestID(), AccessLog(output.Logger(utils.LogPrefixWeb)), HTTPMetrics(), AuditActor())
+// 	r.Use(RequestTimeout(DefaultRequestTimeout))
+// 	db := // Your GORM database initialization
+// 	auditService := service.NewAuditService(repository.NewGormStore(db))
+// 	auditHandler := NewAuditHandler(auditService)
+
+// 	r.GET("/audit", auditHandler.GetAuditLogHandler)
+
+// 	r.Run(":8080")
+// }
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"fleet-monitor/backend/db"
+	"fleet-monitor/backend/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ActorAnonymous is the audit log actor of requests without an authenticated user.
+const ActorAnonymous = "anonymous"
+
+// AuditActor records the changes made by a request in the audit log as made by the
+// user set under gin.AuthUserKey by an authentication middleware, or ActorAnonymous,
+// from the client IP. Use it after the authentication middleware.
+// Example
+// r.Use(RequestID(), AccessLog(output.Logger(utils.LogPrefixWeb)), gin.BasicAuth(accounts), AuditActor())
+func AuditActor() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actor := service.Actor{Name: c.GetString(gin.AuthUserKey), SourceIP: c.ClientIP()}
+		if actor.Name == "" {
+			actor.Name = ActorAnonymous
+		}
+
+		c.Request = c.Request.WithContext(service.WithActor(c.Request.Context(), actor))
+		c.Next()
+	}
+}
+
+type AuditHandler struct {
+	AuditService service.AuditService
+}
+
+func NewAuditHandler(auditService service.AuditService) *AuditHandler {
+	return &AuditHandler{AuditService: auditService}
+}
+
+// auditCSVHeader names the columns of the CSV export.
+var auditCSVHeader = []string{"id", "created_at", "actor", "action", "entity", "entity_id", "changes", "request_id", "source_ip"}
+
+// GetAuditLogHandler handles HTTP requests for the audit log, newest first.
+// Optional query parameters: actor, action, entity, entityId and requestId, since and
+// until (RFC 3339), limit, 100 by default, and format=csv to download the entries as
+// CSV, all of them unless limit is given.
+// Example
+// GET /audit?entity=drone&entityId=7&since=2023-11-01T10:00:00Z
+// GET /audit?actor=alice&format=csv
+func (h *AuditHandler) GetAuditLogHandler(c *gin.Context) {
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		respondBadRequest(c, "Invalid format, expected json or csv")
+		return
+	}
+
+	since, until, limit, ok := historyQuery(c)
+	if !ok {
+		return
+	}
+	if c.Query("limit") == "" && format == "json" {
+		limit = 100
+	}
+
+	filter := service.AuditFilter{
+		Actor:     c.Query("actor"),
+		Action:    db.AuditAction(c.Query("action")),
+		Entity:    c.Query("entity"),
+		RequestID: c.Query("requestId"),
+		Since:     since,
+		Until:     until,
+		Limit:     limit,
+	}
+	if value := c.Query("entityId"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 0)
+		if err != nil {
+			respondBadRequest(c, "Invalid entityId")
+			return
+		}
+		filter.EntityID = uint(id)
+	}
+
+	entries, err := h.AuditService.GetEntries(c.Request.Context(), filter)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if format == "json" {
+		c.JSON(http.StatusOK, entries)
+		return
+	}
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="audit.csv"`)
+	c.Status(http.StatusOK)
+	w := csv.NewWriter(c.Writer)
+	_ = w.Write(auditCSVHeader)
+	for _, entry := range entries {
+		changes, err := json.Marshal(entry.Changes)
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+		_ = w.Write([]string{
+			strconv.FormatUint(uint64(entry.ID), 10),
+			entry.CreatedAt.UTC().Format(time.RFC3339Nano),
+			csvText(entry.Actor),
+			string(entry.Action),
+			entry.Entity,
+			strconv.FormatUint(uint64(entry.EntityID), 10),
+			string(changes),
+			csvText(entry.RequestID),
+			csvText(entry.SourceIP),
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		_ = c.Error(err)
+	}
+}
+
+// csvText keeps spreadsheets from evaluating text that came from clients as a formula.
+func csvText(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
@@ -0,0 +1,200 @@
+package webserver
+
+// USAGE EXAMPLE
+// func main() {
+// 	r := gin.New()
+// 	r.Use(gin.Recovery(), RequestID(), AccessLog(output.Logger(utils.LogPrefixWeb)), HTTPMetrics(), AuditActor())
+// 	r.Use(RequestTimeout(DefaultRequestTimeout))
+// 	db := // Your GORM database initialization
+// 	batteryService := service.NewBatteryService(repository.NewGormStore(db))
+// 	batteryHandler := NewBatteryHandler(batteryService)
+
+// 	r.POST("/batteries", batteryHandler.CreateBatteryPackHandler)
+// 	r.GET("/batteries", batteryHandler.GetBatteryPacksHandler)
+// 	r.GET("/batteries/:packID", batteryHandler.GetBatteryPackHandler)
+// 	r.PUT("/batteries/:packID", batteryHandler.UpdateBatteryPackHandler)
+// 	r.PUT("/batteries/:packID/drone", batteryHandler.InstallBatteryPackHandler)
+// 	r.GET("/batteries/:packID/discharges", batteryHandler.GetDischargesHandler)
+// 	r.POST("/drones/:droneID/discharges", batteryHandler.RecordDischargeHandler)
+
+// 	r.Run(":8080")
+// }
+
+import (
+	"net/http"
+	"strconv"
+
+	"fleet-monitor/backend/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BatteryHandler struct {
+	BatteryService service.BatteryService
+}
+
+func NewBatteryHandler(batteryService service.BatteryService) *BatteryHandler {
+	return &BatteryHandler{BatteryService: batteryService}
+}
+
+// CreateBatteryPackHandler handles HTTP requests for adding a battery pack to the inventory.
+// Example
+// POST /batteries {"serialNumber": "BP-0042", "chemistry": "LiPo", "cellCount": 6, "ratedCapacityMah": 10000}
+func (h *BatteryHandler) CreateBatteryPackHandler(c *gin.Context) {
+	var request service.BatteryPackDetails
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondBadRequest(c, "Invalid JSON request")
+		return
+	}
+
+	pack, err := h.BatteryService.CreateBatteryPack(c.Request.Context(), request)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, pack)
+}
+
+// GetBatteryPacksHandler handles HTTP requests for listing battery packs.
+// Optional query parameter: lowHealth=true to list only the packs below the health threshold.
+func (h *BatteryHandler) GetBatteryPacksHandler(c *gin.Context) {
+	lowHealth := false
+	if value := c.Query("lowHealth"); value != "" {
+		var err error
+		if lowHealth, err = strconv.ParseBool(value); err != nil {
+			respondBadRequest(c, "Invalid lowHealth")
+			return
+		}
+	}
+
+	packs, err := h.BatteryService.GetBatteryPacks(c.Request.Context(), lowHealth)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, packs)
+}
+
+// GetBatteryPackHandler handles HTTP requests for a single battery pack.
+func (h *BatteryHandler) GetBatteryPackHandler(c *gin.Context) {
+	packID, ok := uintParam(c, "packID", "Invalid Battery Pack ID")
+	if !ok {
+		return
+	}
+
+	pack, err := h.BatteryService.GetBatteryPack(c.Request.Context(), packID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, pack)
+}
+
+// UpdateBatteryPackHandler handles HTTP requests for replacing a battery pack's details.
+// Example
+// PUT /batteries/3 {"serialNumber": "BP-0042", "chemistry": "LiPo", "cellCount": 6, "ratedCapacityMah": 10000, "retired": true}
+func (h *BatteryHandler) UpdateBatteryPackHandler(c *gin.Context) {
+	packID, ok := uintParam(c, "packID", "Invalid Battery Pack ID")
+	if !ok {
+		return
+	}
+
+	var request service.BatteryPackDetails
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondBadRequest(c, "Invalid JSON request")
+		return
+	}
+
+	pack, err := h.BatteryService.UpdateBatteryPack(c.Request.Context(), packID, request)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, pack)
+}
+
+// InstallBatteryPackHandler handles HTTP requests for putting a battery pack in a drone.
+// A droneId of 0 takes the pack out of its drone.
+// Example
+// PUT /batteries/3/drone {"droneId": 7}
+func (h *BatteryHandler) InstallBatteryPackHandler(c *gin.Context) {
+	packID, ok := uintParam(c, "packID", "Invalid Battery Pack ID")
+	if !ok {
+		return
+	}
+
+	var request struct {
+		DroneID *uint `json:"droneId"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil || request.DroneID == nil {
+		respondBadRequest(c, "Invalid JSON request")
+		return
+	}
+
+	pack, err := h.BatteryService.InstallBatteryPack(c.Request.Context(), packID, *request.DroneID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, pack)
+}
+
+// GetDischargesHandler handles HTTP requests for a battery pack's discharges, newest first.
+// Optional query parameter: limit.
+func (h *BatteryHandler) GetDischargesHandler(c *gin.Context) {
+	packID, ok := uintParam(c, "packID", "Invalid Battery Pack ID")
+	if !ok {
+		return
+	}
+
+	limit := 0
+	if value := c.Query("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil || limit < 0 {
+			respondBadRequest(c, "Invalid limit")
+			return
+		}
+	}
+
+	discharges, err := h.BatteryService.GetDischarges(c.Request.Context(), packID, limit)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, discharges)
+}
+
+// RecordDischargeHandler handles HTTP requests for adding a discharge to the pack installed
+// in a drone, for flights whose telemetry was not received.
+// Example
+// POST /drones/7/discharges {"startedAt": "2023-11-01T10:00:00Z", "endedAt": "2023-11-01T10:24:00Z", "startVoltage": 25.1, "endVoltage": 22.4, "startRemaining": -1, "endRemaining": -1, "consumedMah": 7200}
+func (h *BatteryHandler) RecordDischargeHandler(c *gin.Context) {
+	droneID, ok := uintParam(c, "droneID", "Invalid Drone ID")
+	if !ok {
+		return
+	}
+
+	var request service.DischargeDetails
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondBadRequest(c, "Invalid JSON request")
+		return
+	}
+
+	result, err := h.BatteryService.RecordDischarge(c.Request.Context(), droneID, request)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, result)
+}
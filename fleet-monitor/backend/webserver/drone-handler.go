@@ -2,27 +2,37 @@ package webserver
 
 // USAGE EXAMPLE
 // func main() {
-// 	r := gin.Default()
+// 	r := gin.New()
+// 	r.Use(gin.Recovery(), RequestID(), AccessLog(output.Logger(utils.LogPrefixWeb)), HTTPMetrics(), AuditActor())
+// 	r.Use(RequestTimeout(DefaultRequestTimeout))
 // 	db := // Your GORM database initialization
-// 	droneService := service.NewDroneService(db)
+// 	droneService := service.NewDroneService(repository.NewGormStore(db))
 // 	droneHandler := NewDroneHandler(droneService)
 
 // 	r.POST("/drones", droneHandler.CreateDroneHandler)
+// 	r.PUT("/drones/:droneID", droneHandler.UpdateDroneHandler)
 // 	r.GET("/drones", droneHandler.GetAllDronesHandler)
-// 	r.GET("/drones/user/:userName", droneHandler.GetDronesByUserNameHandler)
+// 	r.GET("/drones/:droneID", droneHandler.GetDroneHandler)
+// 	r.GET("/drones/user/:user", droneHandler.GetDronesByUserHandler)
 // 	r.GET("/drones/taskstatus", droneHandler.GetDronesByTaskStatusHandler)
-// 	r.GET("/drones/flightstatus", droneHandler.GetDronesByFlightStatusHandler)
+// 	r.GET("/drones/healthstatus", droneHandler.GetDronesByHealthStatusHandler)
+// 	r.GET("/drones/flightphase", droneHandler.GetDronesByFlightPhaseHandler)
 // 	r.DELETE("/drones/:droneID", droneHandler.DeleteDroneHandler)
 // 	r.POST("/drones/json", droneHandler.CreateDroneFromJSONHandler)
 // 	r.PUT("/drones/:droneID/realtime", droneHandler.UpdateDroneRealTimeHandler)
+// 	r.GET("/drones/:droneID/telemetry", droneHandler.GetDroneTelemetryHandler)
+// 	r.GET("/drones/:droneID/quarantine", droneHandler.GetQuarantinedSamplesHandler)
+// 	r.GET("/admin/deleted/drones", droneHandler.GetDeletedDronesHandler)
+// 	r.POST("/admin/deleted/drones/:droneID/restore", droneHandler.RestoreDroneHandler)
+// 	r.DELETE("/admin/deleted/drones/:droneID", droneHandler.PurgeDroneHandler)
 
 // 	r.Run(":8080")
 // }
 
 import (
-	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"fleet-monitor/backend/db"
 	"fleet-monitor/backend/service"
@@ -31,52 +41,97 @@ import (
 )
 
 type DroneHandler struct {
-	DroneService *service.DroneService
+	DroneService service.DroneService
 }
 
-func NewDroneHandler(droneService *service.DroneService) *DroneHandler {
+func NewDroneHandler(droneService service.DroneService) *DroneHandler {
 	return &DroneHandler{DroneService: droneService}
 }
 
 // CreateDroneHandler handles HTTP requests for creating a new drone.
 func (h *DroneHandler) CreateDroneHandler(c *gin.Context) {
-	var request struct {
-		MavlinkID string `json:"mavlinkId"`
-		OwnerID   int    `json:"ownerId"`
-	}
+	var request service.DroneDetails
 
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON request"})
+		respondBadRequest(c, "Invalid JSON request")
 		return
 	}
 
-	drone, err := h.DroneService.CreateDrone(request.MavlinkID, request.OwnerID)
+	drone, err := h.DroneService.CreateDrone(c.Request.Context(), request)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to create drone: %v", err)})
+		respondError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusCreated, drone)
 }
 
+// UpdateDroneHandler handles HTTP requests for replacing a drone's details.
+// Example
+// PUT /drones/7 {"mavlinkId": "1", "ownerId": 2, "airframeType": "quadcopter", "serialNumber": "SN-0042"}
+func (h *DroneHandler) UpdateDroneHandler(c *gin.Context) {
+	droneID, err := strconv.Atoi(c.Param("droneID"))
+	if err != nil {
+		respondBadRequest(c, "Invalid Drone ID")
+		return
+	}
+
+	var request service.DroneDetails
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondBadRequest(c, "Invalid JSON request")
+		return
+	}
+
+	err = h.DroneService.UpdateDrone(c.Request.Context(), uint(droneID), request)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Drone updated successfully"})
+}
+
 // GetAllDronesHandler handles HTTP requests for getting all drones.
 func (h *DroneHandler) GetAllDronesHandler(c *gin.Context) {
-	drones, err := h.DroneService.GetAllDrones()
+	drones, err := h.DroneService.GetAllDrones(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to get drones: %v", err)})
+		respondError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, drones)
 }
 
-// GetDronesByUserNameHandler handles HTTP requests for getting drones by username.
-func (h *DroneHandler) GetDronesByUserNameHandler(c *gin.Context) {
-	userName := c.Param("userName")
+// GetDroneHandler handles HTTP requests for a single drone with its latest live state.
+func (h *DroneHandler) GetDroneHandler(c *gin.Context) {
+	droneID, err := strconv.Atoi(c.Param("droneID"))
+	if err != nil {
+		respondBadRequest(c, "Invalid Drone ID")
+		return
+	}
 
-	drones, err := h.DroneService.GetDronesByUserName(userName)
+	drone, err := h.DroneService.GetDroneByID(c.Request.Context(), droneID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to get drones by username: %v", err)})
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, drone)
+}
+
+// GetDronesByUserHandler handles HTTP requests for getting the drones of a user.
+// Example
+// GET /drones/user/name:alice
+func (h *DroneHandler) GetDronesByUserHandler(c *gin.Context) {
+	ref, ok := userRefParam(c)
+	if !ok {
+		return
+	}
+
+	drones, err := h.DroneService.GetDronesByUser(c.Request.Context(), ref)
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
@@ -90,37 +145,61 @@ func (h *DroneHandler) GetDronesByTaskStatusHandler(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON request"})
+		respondBadRequest(c, "Invalid JSON request")
 		return
 	}
 
 	taskStatus := db.TaskStatus(request.TaskStatus)
 
-	drones, err := h.DroneService.GetDronesByTaskStatus(taskStatus)
+	drones, err := h.DroneService.GetDronesByTaskStatus(c.Request.Context(), taskStatus)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, drones)
+}
+
+// GetDronesByHealthStatusHandler handles HTTP requests for getting drones by health status.
+func (h *DroneHandler) GetDronesByHealthStatusHandler(c *gin.Context) {
+	var request struct {
+		HealthStatus string `json:"healthStatus"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondBadRequest(c, "Invalid JSON request")
+		return
+	}
+
+	healthStatus := db.HealthStatus(request.HealthStatus)
+
+	drones, err := h.DroneService.GetDronesByHealthStatus(c.Request.Context(), healthStatus)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to get drones by task status: %v", err)})
+		respondError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, drones)
 }
 
-// GetDronesByFlightStatusHandler handles HTTP requests for getting drones by flight status.
-func (h *DroneHandler) GetDronesByFlightStatusHandler(c *gin.Context) {
+// GetDronesByFlightPhaseHandler handles HTTP requests for getting drones by flight phase.
+// Example
+// GET /drones/flightphase {"flightPhase": "airborne"}
+func (h *DroneHandler) GetDronesByFlightPhaseHandler(c *gin.Context) {
 	var request struct {
-		FlightStatus string `json:"flightStatus"`
+		FlightPhase string `json:"flightPhase"`
 	}
 
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON request"})
+		respondBadRequest(c, "Invalid JSON request")
 		return
 	}
 
-	flightStatus := db.FlyingStatus(request.FlightStatus)
+	flightPhase := db.FlightPhase(request.FlightPhase)
 
-	drones, err := h.DroneService.GetDronesByFlightStatus(flightStatus)
+	drones, err := h.DroneService.GetDronesByFlightPhase(c.Request.Context(), flightPhase)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to get drones by flight status: %v", err)})
+		respondError(c, err)
 		return
 	}
 
@@ -132,13 +211,13 @@ func (h *DroneHandler) DeleteDroneHandler(c *gin.Context) {
 	droneIDStr := c.Param("droneID")
 	droneID, err := strconv.Atoi(droneIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Drone ID"})
+		respondBadRequest(c, "Invalid Drone ID")
 		return
 	}
 
-	err = h.DroneService.DeleteDroneByID(droneID)
+	err = h.DroneService.DeleteDroneByID(c.Request.Context(), droneID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to delete drone: %v", err)})
+		respondError(c, err)
 		return
 	}
 
@@ -151,36 +230,153 @@ func (h *DroneHandler) UpdateDroneRealTimeHandler(c *gin.Context) {
 	droneIDStr := c.Param("droneID")
 	droneID, err := strconv.Atoi(droneIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Drone ID"})
+		respondBadRequest(c, "Invalid Drone ID")
 		return
 	}
 
-	var request struct {
-		Velocity db.Velocity     `json:"velocity"`
-		GPS      db.GPS          `json:"gps"`
-		Altitude float64         `json:"altitude"`
-		Battery  int             `json:"battery"`
-		Status   db.FlyingStatus `json:"status"`
-	}
+	var request service.RealTimeState
 
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON request"})
+		respondBadRequest(c, "Invalid JSON request")
 		return
 	}
 
 	// Retrieve the drone by ID
-	drone, err := h.DroneService.GetDroneByID(droneID)
+	drone, err := h.DroneService.GetDroneByID(c.Request.Context(), droneID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to update drone real-time data: %v", err)})
+		respondError(c, err)
 		return
 	}
 
 	// Update the drone's real-time information
-	err = h.DroneService.UpdateDroneRealTime(drone, request.Velocity, request.GPS, request.Altitude, request.Battery, request.Status)
+	err = h.DroneService.UpdateDroneRealTime(c.Request.Context(), drone, request)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to update drone real-time data: %v", err)})
+		respondError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Drone real-time data updated successfully"})
 }
+
+// GetDroneTelemetryHandler handles HTTP requests for a drone's telemetry history.
+// Optional query parameters: since and until (RFC 3339) and limit.
+// Example
+// GET /drones/7/telemetry?since=2023-11-01T10:00:00Z&limit=500
+func (h *DroneHandler) GetDroneTelemetryHandler(c *gin.Context) {
+	droneID, err := strconv.Atoi(c.Param("droneID"))
+	if err != nil {
+		respondBadRequest(c, "Invalid Drone ID")
+		return
+	}
+
+	since, until, limit, ok := historyQuery(c)
+	if !ok {
+		return
+	}
+
+	samples, err := h.DroneService.GetDroneTelemetry(c.Request.Context(), droneID, since, until, limit)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, samples)
+}
+
+// GetQuarantinedSamplesHandler handles HTTP requests for a drone's position updates
+// held back as implausible, newest first. Takes the query parameters of
+// GetDroneTelemetryHandler.
+// Example
+// GET /drones/7/quarantine?since=2023-11-01T10:00:00Z
+func (h *DroneHandler) GetQuarantinedSamplesHandler(c *gin.Context) {
+	droneID, err := strconv.Atoi(c.Param("droneID"))
+	if err != nil {
+		respondBadRequest(c, "Invalid Drone ID")
+		return
+	}
+
+	since, until, limit, ok := historyQuery(c)
+	if !ok {
+		return
+	}
+
+	samples, err := h.DroneService.GetQuarantinedSamples(c.Request.Context(), droneID, since, until, limit)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, samples)
+}
+
+// historyQuery reads the optional since and until (RFC 3339) and limit query
+// parameters of a history. It answers 400 and reports false if one is malformed.
+func historyQuery(c *gin.Context) (since, until time.Time, limit int, ok bool) {
+	var err error
+	if value := c.Query("since"); value != "" {
+		if since, err = time.Parse(time.RFC3339, value); err != nil {
+			respondBadRequest(c, "Invalid since, expected RFC 3339")
+			return
+		}
+	}
+	if value := c.Query("until"); value != "" {
+		if until, err = time.Parse(time.RFC3339, value); err != nil {
+			respondBadRequest(c, "Invalid until, expected RFC 3339")
+			return
+		}
+	}
+
+	if value := c.Query("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil || limit < 0 {
+			respondBadRequest(c, "Invalid limit")
+			return
+		}
+	}
+
+	return since, until, limit, true
+}
+
+// GetDeletedDronesHandler handles HTTP requests for listing soft-deleted drones.
+func (h *DroneHandler) GetDeletedDronesHandler(c *gin.Context) {
+	drones, err := h.DroneService.GetDeletedDrones(c.Request.Context())
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, drones)
+}
+
+// RestoreDroneHandler handles HTTP requests for restoring a soft-deleted drone.
+func (h *DroneHandler) RestoreDroneHandler(c *gin.Context) {
+	droneID, err := strconv.Atoi(c.Param("droneID"))
+	if err != nil {
+		respondBadRequest(c, "Invalid Drone ID")
+		return
+	}
+
+	drone, err := h.DroneService.RestoreDrone(c.Request.Context(), uint(droneID))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, drone)
+}
+
+// PurgeDroneHandler handles HTTP requests for permanently removing a soft-deleted drone.
+func (h *DroneHandler) PurgeDroneHandler(c *gin.Context) {
+	droneID, err := strconv.Atoi(c.Param("droneID"))
+	if err != nil {
+		respondBadRequest(c, "Invalid Drone ID")
+		return
+	}
+
+	err = h.DroneService.PurgeDrone(c.Request.Context(), uint(droneID))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Drone purged successfully"})
+}
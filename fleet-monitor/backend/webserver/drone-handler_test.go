@@ -0,0 +1,172 @@
+package webserver
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"fleet-monitor/backend/db"
+	"fleet-monitor/backend/repository"
+	"fleet-monitor/backend/service"
+)
+
+// createUser creates a user through the API and returns its ID.
+func createUser(t *testing.T, s *testServer, userName string) uint {
+	t.Helper()
+	var user db.User
+	s.mustDo(t, http.MethodPost, "/users", service.UserDetails{UserName: userName}, &user, http.StatusCreated)
+	return user.ID
+}
+
+// createDrone creates a drone through the API and returns its ID.
+func createDrone(t *testing.T, s *testServer, mavlinkID string, ownerID uint) uint {
+	t.Helper()
+	var drone db.Drone
+	details := service.DroneDetails{Name: "drone " + mavlinkID, MavlinkID: mavlinkID, OwnerID: int(ownerID)}
+	s.mustDo(t, http.MethodPost, "/drones", details, &drone, http.StatusCreated)
+	return drone.ID
+}
+
+func TestCreateDroneHandler(t *testing.T) {
+	s := newTestServer(t)
+	owner := createUser(t, s, "alice")
+
+	var drone db.Drone
+	details := service.DroneDetails{Name: " scout ", MavlinkID: "1", OwnerID: int(owner), MaxSpeedMps: 18}
+	s.mustDo(t, http.MethodPost, "/drones", details, &drone, http.StatusCreated)
+	if drone.ID == 0 || drone.Name != "scout" || drone.MaxSpeedMps != 18 || drone.SigningPolicy != db.SigningPolicyAccept {
+		t.Fatalf("created drone = %+v", drone)
+	}
+
+	body := s.expectError(t, http.MethodPost, "/drones", service.DroneDetails{MavlinkID: "1", OwnerID: int(owner)},
+		http.StatusConflict, service.ErrorCodeConflict)
+	if id := conflictID(t, body); id != float64(drone.ID) {
+		t.Errorf("conflict holds drone %v, want %d", id, drone.ID)
+	}
+
+	body = s.expectError(t, http.MethodPost, "/drones", service.DroneDetails{OwnerID: 999, MaxSpeedMps: -1},
+		http.StatusUnprocessableEntity, service.ErrorCodeValidation)
+	for _, field := range []string{"mavlink_id", "owner_id", "max_speed_mps"} {
+		if !hasField(body.Fields, field) {
+			t.Errorf("validation fields %+v lack %s", body.Fields, field)
+		}
+	}
+	if body.Conflict != nil {
+		t.Errorf("validation error has a conflict: %v", body.Conflict)
+	}
+
+	s.expectError(t, http.MethodPost, "/drones", "not an object", http.StatusBadRequest, ErrorCodeBadRequest)
+}
+
+func TestGetDroneHandler(t *testing.T) {
+	s := newTestServer(t)
+	id := createDrone(t, s, "1", createUser(t, s, "alice"))
+
+	var drone db.Drone
+	s.mustDo(t, http.MethodGet, "/drones/"+itoa(id), nil, &drone, http.StatusOK)
+	if drone.ID != id || drone.MavlinkID != "1" {
+		t.Fatalf("GET /drones/%d = %+v", id, drone)
+	}
+
+	var drones []db.Drone
+	s.mustDo(t, http.MethodGet, "/drones", nil, &drones, http.StatusOK)
+	if len(drones) != 1 {
+		t.Fatalf("GET /drones returned %d drones, want 1", len(drones))
+	}
+
+	body := s.expectError(t, http.MethodGet, "/drones/999", nil, http.StatusNotFound, service.ErrorCodeNotFound)
+	if body.Message != "drone 999 not found" {
+		t.Errorf("message = %q", body.Message)
+	}
+	s.expectError(t, http.MethodGet, "/drones/seven", nil, http.StatusBadRequest, ErrorCodeBadRequest)
+}
+
+func TestUpdateDroneHandler(t *testing.T) {
+	s := newTestServer(t)
+	owner := createUser(t, s, "alice")
+	first := createDrone(t, s, "1", owner)
+	second := createDrone(t, s, "2", owner)
+
+	details := service.DroneDetails{Name: "hauler", MavlinkID: "2", OwnerID: int(owner), SerialNumber: "SN-0042"}
+	s.mustDo(t, http.MethodPut, "/drones/"+itoa(second), details, nil, http.StatusOK)
+	var drone db.Drone
+	s.mustDo(t, http.MethodGet, "/drones/"+itoa(second), nil, &drone, http.StatusOK)
+	if drone.Name != "hauler" || drone.SerialNumber != "SN-0042" {
+		t.Fatalf("updated drone = %+v", drone)
+	}
+
+	details.MavlinkID = "1"
+	body := s.expectError(t, http.MethodPut, "/drones/"+itoa(second), details, http.StatusConflict, service.ErrorCodeConflict)
+	if id := conflictID(t, body); id != float64(first) {
+		t.Errorf("conflict holds drone %v, want %d", id, first)
+	}
+
+	details.MavlinkID = ""
+	body = s.expectError(t, http.MethodPut, "/drones/"+itoa(second), details, http.StatusUnprocessableEntity, service.ErrorCodeValidation)
+	if !hasField(body.Fields, "mavlink_id") {
+		t.Errorf("validation fields %+v lack mavlink_id", body.Fields)
+	}
+
+	details.MavlinkID = "3"
+	s.expectError(t, http.MethodPut, "/drones/999", details, http.StatusNotFound, service.ErrorCodeNotFound)
+}
+
+func TestUpdateDroneRealTimeHandler(t *testing.T) {
+	s := newTestServer(t)
+	id := createDrone(t, s, "1", createUser(t, s, "alice"))
+
+	state := service.RealTimeState{
+		GPS:          db.GPS{Latitude: 47.37, Longitude: 8.54, FixType: 3},
+		Altitude:     120,
+		Battery:      80,
+		HealthStatus: db.HealthStatusStable,
+		FlightPhase:  db.FlightPhaseAirborne,
+		Heading:      90,
+	}
+	s.mustDo(t, http.MethodPut, "/drones/"+itoa(id)+"/realtime", state, nil, http.StatusOK)
+
+	var samples []db.Telemetry
+	s.mustDo(t, http.MethodGet, "/drones/"+itoa(id)+"/telemetry", nil, &samples, http.StatusOK)
+	if len(samples) != 1 || samples[0].Altitude != 120 || samples[0].Battery != 80 {
+		t.Fatalf("telemetry = %+v, want the update", samples)
+	}
+
+	// Updates made through the API are audited, unlike those of telemetry ingest.
+	entries, err := s.store.Audit().Find(context.Background(), repository.AuditFilter{Entity: service.AuditEntityDrone, EntityID: id, Action: db.AuditActionUpdate})
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("audit entries of the update = %d, %v, want 1", len(entries), err)
+	}
+	if change := entries[0].Changes["battery"]; change.Before != 0 || change.After != 80 {
+		t.Errorf("audited battery change = %+v, want 0 to 80", change)
+	}
+
+	state.Battery = 120
+	state.GPS.Latitude = 91
+	body := s.expectError(t, http.MethodPut, "/drones/"+itoa(id)+"/realtime", state, http.StatusUnprocessableEntity, service.ErrorCodeValidation)
+	for _, field := range []string{"battery", "gps.latitude"} {
+		if !hasField(body.Fields, field) {
+			t.Errorf("validation fields %+v lack %s", body.Fields, field)
+		}
+	}
+	var drone db.Drone
+	s.mustDo(t, http.MethodGet, "/drones/"+itoa(id), nil, &drone, http.StatusOK)
+	if drone.Battery != 80 {
+		t.Errorf("battery after a rejected update = %d, want 80", drone.Battery)
+	}
+
+	s.expectError(t, http.MethodPut, "/drones/999/realtime", state, http.StatusNotFound, service.ErrorCodeNotFound)
+}
+
+func TestDeleteDroneHandler(t *testing.T) {
+	s := newTestServer(t)
+	owner := createUser(t, s, "alice")
+	id := createDrone(t, s, "1", owner)
+
+	s.mustDo(t, http.MethodDelete, "/drones/"+itoa(id), nil, nil, http.StatusOK)
+	s.expectError(t, http.MethodGet, "/drones/"+itoa(id), nil, http.StatusNotFound, service.ErrorCodeNotFound)
+	s.expectError(t, http.MethodDelete, "/drones/"+itoa(id), nil, http.StatusNotFound, service.ErrorCodeNotFound)
+
+	// The deleted drone's MavlinkID can be reused, which blocks restoring it.
+	createDrone(t, s, "1", owner)
+	s.expectError(t, http.MethodPost, "/admin/deleted/drones/"+itoa(id)+"/restore", nil, http.StatusConflict, service.ErrorCodeConflict)
+}
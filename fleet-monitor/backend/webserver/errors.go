@@ -0,0 +1,75 @@
+package webserver
+
+import (
+	"errors"
+	"net/http"
+
+	"fleet-monitor/backend/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrorCodeBadRequest is returned for requests that cannot be parsed at all,
+// as opposed to service.ErrorCodeValidation for well-formed requests with invalid values.
+const ErrorCodeBadRequest service.ErrorCode = "bad_request"
+
+// StatusClientClosedRequest is the non-standard status logged for requests whose
+// client disconnected before the response was written.
+const StatusClientClosedRequest = 499
+
+// ErrorBody is the stable shape of every error response.
+// Example
+// {"error": {"code": "not_found", "message": "drone 7 not found"}}
+type ErrorBody struct {
+	Code     service.ErrorCode    `json:"code"`
+	Message  string               `json:"message"`
+	Fields   []service.FieldError `json:"fields,omitempty"`
+	Conflict interface{}          `json:"conflict,omitempty"`
+}
+
+// ErrorResponse wraps ErrorBody under the "error" key.
+type ErrorResponse struct {
+	Error ErrorBody `json:"error"`
+}
+
+// statusByCode maps service error codes onto HTTP status codes.
+var statusByCode = map[service.ErrorCode]int{
+	service.ErrorCodeNotFound:   http.StatusNotFound,
+	service.ErrorCodeConflict:   http.StatusConflict,
+	service.ErrorCodeValidation: http.StatusUnprocessableEntity,
+	service.ErrorCodeForbidden:  http.StatusForbidden,
+	service.ErrorCodeTimeout:    http.StatusGatewayTimeout,
+	service.ErrorCodeCanceled:   StatusClientClosedRequest,
+	ErrorCodeBadRequest:         http.StatusBadRequest,
+}
+
+// respondError writes err as an ErrorResponse with the matching HTTP status.
+// Errors that are not service errors are reported as 500 without leaking their details.
+func respondError(c *gin.Context, err error) {
+	var serviceErr *service.Error
+	if !errors.As(err, &serviceErr) || serviceErr.Code == service.ErrorCodeInternal {
+		_ = c.Error(err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: ErrorBody{
+			Code:    service.ErrorCodeInternal,
+			Message: "Internal Server Error",
+		}})
+		return
+	}
+
+	status, ok := statusByCode[serviceErr.Code]
+	if !ok {
+		status = http.StatusInternalServerError
+	}
+
+	c.JSON(status, ErrorResponse{Error: ErrorBody{
+		Code:     serviceErr.Code,
+		Message:  serviceErr.Message,
+		Fields:   serviceErr.Fields,
+		Conflict: serviceErr.Conflicting,
+	}})
+}
+
+// respondBadRequest writes a 400 ErrorResponse for malformed requests.
+func respondBadRequest(c *gin.Context, message string) {
+	respondError(c, &service.Error{Code: ErrorCodeBadRequest, Message: message})
+}
@@ -0,0 +1,153 @@
+package webserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"fleet-monitor/backend/repository"
+	"fleet-monitor/backend/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// testServer serves the drone, task and user routes from services backed by an
+// in-memory store.
+type testServer struct {
+	*httptest.Server
+	store repository.Store
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	store := repository.NewMemoryStore()
+	droneHandler := NewDroneHandler(service.NewDroneService(store))
+	taskHandler := NewTaskHandler(service.NewTaskService(store))
+	userHandler := NewUserHandler(service.NewUserService(store))
+
+	r := gin.New()
+	r.Use(gin.Recovery(), RequestID(), AuditActor())
+
+	r.POST("/drones", droneHandler.CreateDroneHandler)
+	r.PUT("/drones/:droneID", droneHandler.UpdateDroneHandler)
+	r.GET("/drones", droneHandler.GetAllDronesHandler)
+	r.GET("/drones/:droneID", droneHandler.GetDroneHandler)
+	r.DELETE("/drones/:droneID", droneHandler.DeleteDroneHandler)
+	r.PUT("/drones/:droneID/realtime", droneHandler.UpdateDroneRealTimeHandler)
+	r.GET("/drones/:droneID/telemetry", droneHandler.GetDroneTelemetryHandler)
+	r.POST("/admin/deleted/drones/:droneID/restore", droneHandler.RestoreDroneHandler)
+
+	r.POST("/tasks", taskHandler.CreateTaskHandler)
+	r.PUT("/tasks/:taskID", taskHandler.UpdateTaskHandler)
+	r.GET("/tasks", taskHandler.GetAllTasksHandler)
+	r.DELETE("/tasks/:taskID", taskHandler.DeleteTaskHandler)
+	r.POST("/admin/deleted/tasks/:taskID/restore", taskHandler.RestoreTaskHandler)
+
+	r.POST("/users", userHandler.CreateUserHandler)
+	r.GET("/users/:user", userHandler.GetUserHandler)
+	r.PUT("/users/:user", userHandler.UpdateUserHandler)
+	r.DELETE("/users/:user", userHandler.DeleteUserHandler)
+
+	s := &testServer{Server: httptest.NewServer(r), store: store}
+	t.Cleanup(s.Close)
+	return s
+}
+
+// do sends body, if not nil, as JSON and decodes the JSON response into out, if not nil.
+// It returns the response status.
+func (s *testServer) do(t *testing.T, method, path string, body, out interface{}) int {
+	t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, s.URL+path, reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := s.Client().Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("%s %s: decoding the %d response: %v", method, path, resp.StatusCode, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+// mustDo is do for requests expected to succeed with status.
+func (s *testServer) mustDo(t *testing.T, method, path string, body, out interface{}, status int) {
+	t.Helper()
+	var raw json.RawMessage
+	if got := s.do(t, method, path, body, &raw); got != status {
+		t.Fatalf("%s %s = %d %s, want %d", method, path, got, raw, status)
+	}
+	if out != nil {
+		if err := json.Unmarshal(raw, out); err != nil {
+			t.Fatalf("%s %s: %v", method, path, err)
+		}
+	}
+}
+
+// expectError sends the request and checks that it fails with status and code,
+// returning the error body.
+func (s *testServer) expectError(t *testing.T, method, path string, body interface{}, status int, code service.ErrorCode) ErrorBody {
+	t.Helper()
+	var resp ErrorResponse
+	if got := s.do(t, method, path, body, &resp); got != status {
+		t.Fatalf("%s %s = %d %+v, want %d", method, path, got, resp.Error, status)
+	}
+	if resp.Error.Code != code {
+		t.Fatalf("%s %s error code = %q, want %q", method, path, resp.Error.Code, code)
+	}
+	if resp.Error.Message == "" {
+		t.Fatalf("%s %s error has no message", method, path)
+	}
+	return resp.Error
+}
+
+// hasField reports whether the field errors include field.
+func hasField(fields []service.FieldError, field string) bool {
+	for _, f := range fields {
+		if f.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+// conflictID returns the ID of the record an error body's conflict holds.
+func conflictID(t *testing.T, body ErrorBody) float64 {
+	t.Helper()
+	conflict, ok := body.Conflict.(map[string]interface{})
+	if !ok {
+		t.Fatalf("conflict = %#v, want the conflicting record", body.Conflict)
+	}
+	id, _ := conflict["ID"].(float64)
+	return id
+}
+
+// itoa formats an ID for a URL path.
+func itoa(id uint) string {
+	return strconv.FormatUint(uint64(id), 10)
+}
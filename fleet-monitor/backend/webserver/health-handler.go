@@ -0,0 +1,56 @@
+package webserver
+
+// USAGE EXAMPLE
+// func main() {
+// 	r := gin.New()
+// 	r.Use(gin.Recovery(), RequestID(), AccessLog(output.Logger(utils.LogPrefixWeb)), HTTPMetrics(), AuditActor())
+// 	db := // Your GORM database initialization
+// 	checker := health.NewChecker()
+// 	checker.Add("database", health.Database(db))
+// 	checker.Add("migrations", health.Migrations(db))
+// 	checker.Add("links", health.Links(listener.Links))
+// 	healthHandler := NewHealthHandler(checker)
+
+// 	r.GET("/healthz", healthHandler.HealthzHandler)
+// 	r.GET("/readyz", healthHandler.ReadyzHandler)
+
+// 	r.Run(":8080")
+// }
+
+import (
+	"net/http"
+
+	"fleet-monitor/backend/health"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HealthHandler struct {
+	Checker *health.Checker
+}
+
+func NewHealthHandler(checker *health.Checker) *HealthHandler {
+	return &HealthHandler{Checker: checker}
+}
+
+// HealthzHandler answers 200 as long as the process serves requests. It checks
+// nothing else, so a supervisor only restarts a process that is stuck.
+func (h *HealthHandler) HealthzHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": health.StatusOK})
+}
+
+// ReadyzHandler runs the checks and answers with the status of each component: 200
+// when ok or degraded, 503 when a component is down.
+// Example
+// GET /readyz
+// {"status": "degraded", "components": {"database": {"status": "ok"}, "migrations": {"status": "ok"},
+// "links": {"status": "degraded", "message": "/dev/ttyUSB1 silent", "details": [...]}}}
+func (h *HealthHandler) ReadyzHandler(c *gin.Context) {
+	report := h.Checker.Run(c.Request.Context())
+
+	status := http.StatusOK
+	if report.Status == health.StatusDown {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, report)
+}
@@ -0,0 +1,159 @@
+package webserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"fleet-monitor/backend/db"
+	"fleet-monitor/backend/health"
+	"fleet-monitor/backend/ingest"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// openHealthDB opens a migrated SQLite database for the health checks.
+func openHealthDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	conn, err := db.OpenDB("sqlite://" + filepath.Join(t.TempDir(), "fleet.db"))
+	if err != nil {
+		t.Fatalf("OpenDB: %v", err)
+	}
+	sqlDB, err := conn.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	return conn
+}
+
+// readyz runs GET /readyz against checker and returns the status and report.
+func readyz(t *testing.T, checker *health.Checker) (int, health.Report) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/readyz", NewHealthHandler(checker).ReadyzHandler)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	var report health.Report
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatalf("decoding %q: %v", rec.Body.String(), err)
+	}
+	return rec.Code, report
+}
+
+func TestReadyzHandler(t *testing.T) {
+	connected := ingest.LinkStatus{Name: "udp:14550", State: ingest.LinkConnected}
+	silent := ingest.LinkStatus{Name: "/dev/ttyUSB1", State: ingest.LinkSilent}
+	closed := ingest.LinkStatus{Name: "/dev/ttyUSB2", State: ingest.LinkClosed}
+
+	tests := []struct {
+		name       string
+		setup      func(t *testing.T, conn *gorm.DB)
+		links      []ingest.LinkStatus
+		wantCode   int
+		wantStatus health.Status
+		// component is the one that sets the overall status; message is part of its message.
+		component string
+		message   string
+	}{
+		{
+			name:       "ready",
+			links:      []ingest.LinkStatus{connected},
+			wantCode:   http.StatusOK,
+			wantStatus: health.StatusOK,
+			component:  "links",
+		},
+		{
+			name:       "stale link",
+			links:      []ingest.LinkStatus{connected, silent},
+			wantCode:   http.StatusOK,
+			wantStatus: health.StatusDegraded,
+			component:  "links",
+			message:    "/dev/ttyUSB1 silent",
+		},
+		{
+			name:       "no open link",
+			links:      []ingest.LinkStatus{closed},
+			wantCode:   http.StatusServiceUnavailable,
+			wantStatus: health.StatusDown,
+			component:  "links",
+			message:    "/dev/ttyUSB2 closed",
+		},
+		{
+			name: "pending migrations",
+			setup: func(t *testing.T, conn *gorm.DB) {
+				if _, err := db.MigrateDown(conn, 1); err != nil {
+					t.Fatalf("MigrateDown: %v", err)
+				}
+			},
+			links:      []ingest.LinkStatus{connected},
+			wantCode:   http.StatusServiceUnavailable,
+			wantStatus: health.StatusDown,
+			component:  "migrations",
+			message:    "1 pending migrations",
+		},
+		{
+			name: "database down",
+			setup: func(t *testing.T, conn *gorm.DB) {
+				sqlDB, err := conn.DB()
+				if err != nil {
+					t.Fatal(err)
+				}
+				sqlDB.Close()
+			},
+			links:      []ingest.LinkStatus{connected},
+			wantCode:   http.StatusServiceUnavailable,
+			wantStatus: health.StatusDown,
+			component:  "database",
+			message:    "closed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := openHealthDB(t)
+			if tt.setup != nil {
+				tt.setup(t, conn)
+			}
+			links := tt.links
+			checker := health.NewChecker()
+			checker.Add("database", health.Database(conn))
+			checker.Add("migrations", health.Migrations(conn))
+			checker.Add("links", health.Links(func() []ingest.LinkStatus { return links }))
+
+			code, report := readyz(t, checker)
+			if code != tt.wantCode || report.Status != tt.wantStatus {
+				t.Fatalf("GET /readyz = %d %s, want %d %s: %+v", code, report.Status, tt.wantCode, tt.wantStatus, report.Components)
+			}
+			component := report.Components[tt.component]
+			if component.Status != tt.wantStatus || !strings.Contains(component.Message, tt.message) {
+				t.Errorf("%s = %+v, want %s with %q", tt.component, component, tt.wantStatus, tt.message)
+			}
+			if len(report.Components) != 3 {
+				t.Errorf("components = %+v, want all three", report.Components)
+			}
+		})
+	}
+}
+
+func TestHealthzHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	// A failing check does not make the process unhealthy, only unready.
+	checker := health.NewChecker()
+	checker.Add("links", health.Links(func() []ingest.LinkStatus { return nil }))
+	r.GET("/healthz", NewHealthHandler(checker).HealthzHandler)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"ok"`) {
+		t.Errorf("GET /healthz = %d %s", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,203 @@
+package webserver
+
+// USAGE EXAMPLE
+// func main() {
+// 	r := gin.New()
+// 	r.Use(gin.Recovery(), RequestID(), AccessLog(output.Logger(utils.LogPrefixWeb)), HTTPMetrics(), AuditActor())
+// 	r.Use(RequestTimeout(DefaultRequestTimeout))
+// 	db := // Your GORM database initialization
+// 	maintenanceService := service.NewMaintenanceService(repository.NewGormStore(db))
+// 	maintenanceHandler := NewMaintenanceHandler(maintenanceService)
+
+// 	r.POST("/drones/:droneID/flights", maintenanceHandler.RecordFlightHandler)
+// 	r.GET("/drones/:droneID/flights", maintenanceHandler.GetFlightsHandler)
+// 	r.GET("/drones/:droneID/maintenance", maintenanceHandler.GetMaintenanceStatusHandler)
+// 	r.POST("/drones/:droneID/maintenance/intervals", maintenanceHandler.AddServiceIntervalHandler)
+// 	r.PUT("/drones/:droneID/maintenance/intervals/:intervalID", maintenanceHandler.UpdateServiceIntervalHandler)
+// 	r.DELETE("/drones/:droneID/maintenance/intervals/:intervalID", maintenanceHandler.DeleteServiceIntervalHandler)
+// 	r.POST("/drones/:droneID/maintenance/log", maintenanceHandler.LogMaintenanceHandler)
+
+// 	r.Run(":8080")
+// }
+
+import (
+	"net/http"
+	"strconv"
+
+	"fleet-monitor/backend/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MaintenanceHandler struct {
+	MaintenanceService service.MaintenanceService
+}
+
+func NewMaintenanceHandler(maintenanceService service.MaintenanceService) *MaintenanceHandler {
+	return &MaintenanceHandler{MaintenanceService: maintenanceService}
+}
+
+// RecordFlightHandler handles HTTP requests for adding a flight to a drone's history.
+// Example
+// POST /drones/7/flights {"startedAt": "2023-11-01T10:00:00Z", "endedAt": "2023-11-01T10:24:00Z"}
+func (h *MaintenanceHandler) RecordFlightHandler(c *gin.Context) {
+	droneID, ok := uintParam(c, "droneID", "Invalid Drone ID")
+	if !ok {
+		return
+	}
+
+	var request service.FlightDetails
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondBadRequest(c, "Invalid JSON request")
+		return
+	}
+
+	flight, err := h.MaintenanceService.RecordFlight(c.Request.Context(), droneID, request)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, flight)
+}
+
+// GetFlightsHandler handles HTTP requests for a drone's flights.
+func (h *MaintenanceHandler) GetFlightsHandler(c *gin.Context) {
+	droneID, ok := uintParam(c, "droneID", "Invalid Drone ID")
+	if !ok {
+		return
+	}
+
+	flights, err := h.MaintenanceService.GetFlights(c.Request.Context(), droneID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, flights)
+}
+
+// GetMaintenanceStatusHandler handles HTTP requests for a drone's flight totals,
+// service intervals and maintenance log.
+func (h *MaintenanceHandler) GetMaintenanceStatusHandler(c *gin.Context) {
+	droneID, ok := uintParam(c, "droneID", "Invalid Drone ID")
+	if !ok {
+		return
+	}
+
+	status, err := h.MaintenanceService.GetMaintenanceStatus(c.Request.Context(), droneID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// AddServiceIntervalHandler handles HTTP requests for adding a service interval to a drone.
+// Example
+// POST /drones/7/maintenance/intervals {"name": "motor inspection", "flightHours": 25}
+func (h *MaintenanceHandler) AddServiceIntervalHandler(c *gin.Context) {
+	droneID, ok := uintParam(c, "droneID", "Invalid Drone ID")
+	if !ok {
+		return
+	}
+
+	var request service.ServiceIntervalDetails
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondBadRequest(c, "Invalid JSON request")
+		return
+	}
+
+	interval, err := h.MaintenanceService.AddServiceInterval(c.Request.Context(), droneID, request)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, interval)
+}
+
+// UpdateServiceIntervalHandler handles HTTP requests for replacing a service interval's name and limits.
+func (h *MaintenanceHandler) UpdateServiceIntervalHandler(c *gin.Context) {
+	droneID, ok := uintParam(c, "droneID", "Invalid Drone ID")
+	if !ok {
+		return
+	}
+	intervalID, ok := uintParam(c, "intervalID", "Invalid Service Interval ID")
+	if !ok {
+		return
+	}
+
+	var request service.ServiceIntervalDetails
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondBadRequest(c, "Invalid JSON request")
+		return
+	}
+
+	interval, err := h.MaintenanceService.UpdateServiceInterval(c.Request.Context(), droneID, intervalID, request)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, interval)
+}
+
+// DeleteServiceIntervalHandler handles HTTP requests for removing a service interval.
+func (h *MaintenanceHandler) DeleteServiceIntervalHandler(c *gin.Context) {
+	droneID, ok := uintParam(c, "droneID", "Invalid Drone ID")
+	if !ok {
+		return
+	}
+	intervalID, ok := uintParam(c, "intervalID", "Invalid Service Interval ID")
+	if !ok {
+		return
+	}
+
+	err := h.MaintenanceService.DeleteServiceInterval(c.Request.Context(), droneID, intervalID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Service interval deleted successfully"})
+}
+
+// LogMaintenanceHandler handles HTTP requests for adding an entry to a drone's maintenance log.
+// Example
+// POST /drones/7/maintenance/log {"action": "25h motor inspection", "parts": "4x propeller", "serviceIntervalId": 2}
+func (h *MaintenanceHandler) LogMaintenanceHandler(c *gin.Context) {
+	droneID, ok := uintParam(c, "droneID", "Invalid Drone ID")
+	if !ok {
+		return
+	}
+
+	var request service.MaintenanceDetails
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondBadRequest(c, "Invalid JSON request")
+		return
+	}
+
+	entry, err := h.MaintenanceService.LogMaintenance(c.Request.Context(), droneID, request)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, entry)
+}
+
+// uintParam parses a positive ID path parameter, responding 400 with message if it is not one.
+func uintParam(c *gin.Context, name, message string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(name), 10, 0)
+	if err != nil || id == 0 {
+		respondBadRequest(c, message)
+		return 0, false
+	}
+	return uint(id), true
+}
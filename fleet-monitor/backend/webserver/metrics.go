@@ -0,0 +1,38 @@
+package webserver
+
+import (
+	"strconv"
+	"time"
+
+	"fleet-monitor/backend/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HTTPMetrics records how long each request takes in
+// fleet_monitor_http_request_duration_seconds, labelled with the route pattern such as
+// /drones/:droneID rather than the path, so IDs don't create new series.
+// Example
+// r.Use(RequestID(), AccessLog(logger), HTTPMetrics())
+// r.GET("/metrics", MetricsHandler)
+func HTTPMetrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		metrics.HTTPRequestDuration.
+			WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).
+			Observe(time.Since(start).Seconds())
+	}
+}
+
+var metricsHandler = metrics.Handler()
+
+// MetricsHandler serves the Prometheus metrics.
+func MetricsHandler(c *gin.Context) {
+	metricsHandler.ServeHTTP(c.Writer, c.Request)
+}
@@ -0,0 +1,40 @@
+package webserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestHTTPMetricsLabelsByRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(HTTPMetrics())
+	r.GET("/drones/:droneID", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/metrics", MetricsHandler)
+
+	for _, path := range []string{"/drones/7", "/drones/8", "/drones/7", "/no/such/route"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rec.Body.String()
+
+	for _, want := range []string{
+		`fleet_monitor_http_request_duration_seconds_count{method="GET",route="/drones/:droneID",status="200"} 3`,
+		`fleet_monitor_http_request_duration_seconds_count{method="GET",route="unmatched",status="404"} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics lack %s", want)
+		}
+	}
+	for _, path := range []string{"/drones/7", "/drones/8", "/no/such/route"} {
+		if strings.Contains(body, `route="`+path+`"`) {
+			t.Errorf("metrics are labelled with the path %s", path)
+		}
+	}
+}
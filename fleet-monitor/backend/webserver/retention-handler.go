@@ -0,0 +1,56 @@
+package webserver
+
+// USAGE EXAMPLE
+// func main() {
+// 	r := gin.New()
+// 	r.Use(gin.Recovery(), RequestID(), AccessLog(output.Logger(utils.LogPrefixWeb)), HTTPMetrics(), AuditActor())
+// 	r.Use(RequestTimeout(DefaultRequestTimeout))
+// 	db := // Your GORM database initialization
+// 	retentionService := service.NewRetentionService(repository.NewGormStore(db))
+// 	retentionHandler := NewRetentionHandler(retentionService)
+
+// 	r.POST("/admin/purge", retentionHandler.PurgeDeletedHandler)
+
+// 	r.Run(":8080")
+// }
+
+import (
+	"net/http"
+	"time"
+
+	"fleet-monitor/backend/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RetentionHandler struct {
+	RetentionService service.RetentionService
+}
+
+func NewRetentionHandler(retentionService service.RetentionService) *RetentionHandler {
+	return &RetentionHandler{RetentionService: retentionService}
+}
+
+// PurgeDeletedHandler handles HTTP requests for permanently removing records that were
+// soft-deleted longer ago than the retention period.
+// Optional query parameter: olderThan, a Go duration defaulting to service.DefaultRetention.
+// Example
+// POST /admin/purge?olderThan=720h
+func (h *RetentionHandler) PurgeDeletedHandler(c *gin.Context) {
+	retention := service.DefaultRetention
+	if value := c.Query("olderThan"); value != "" {
+		var err error
+		if retention, err = time.ParseDuration(value); err != nil || retention < 0 {
+			respondBadRequest(c, "Invalid olderThan, expected a duration such as 720h")
+			return
+		}
+	}
+
+	report, err := h.RetentionService.PurgeDeleted(c.Request.Context(), time.Now().Add(-retention))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
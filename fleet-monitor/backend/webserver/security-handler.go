@@ -0,0 +1,118 @@
+package webserver
+
+// USAGE EXAMPLE
+// func main() {
+// 	r := gin.New()
+// 	r.Use(gin.Recovery(), RequestID(), AccessLog(output.Logger(utils.LogPrefixWeb)), HTTPMetrics(), AuditActor())
+// 	r.Use(RequestTimeout(DefaultRequestTimeout))
+// 	db := // Your GORM database initialization
+// 	securityService := service.NewSecurityService(repository.NewGormStore(db))
+// 	securityHandler := NewSecurityHandler(securityService)
+
+// 	r.GET("/drones/:droneID/signing", securityHandler.GetSigningHandler)
+// 	r.PUT("/drones/:droneID/signing", securityHandler.SetSigningHandler)
+// 	r.GET("/security/alerts", securityHandler.GetAlertsHandler)
+
+// 	r.Run(":8080")
+// }
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"fleet-monitor/backend/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SecurityHandler struct {
+	SecurityService service.SecurityService
+}
+
+func NewSecurityHandler(securityService service.SecurityService) *SecurityHandler {
+	return &SecurityHandler{SecurityService: securityService}
+}
+
+// GetSigningHandler handles HTTP requests for a drone's signing policy and whether it
+// has a signing key. The key itself is never returned.
+func (h *SecurityHandler) GetSigningHandler(c *gin.Context) {
+	droneID, err := strconv.Atoi(c.Param("droneID"))
+	if err != nil {
+		respondBadRequest(c, "Invalid Drone ID")
+		return
+	}
+
+	signing, err := h.SecurityService.GetSigning(c.Request.Context(), uint(droneID))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, signing)
+}
+
+// SetSigningHandler handles HTTP requests for changing a drone's signing policy and
+// key. Leave out key to keep the current one and send "" to remove it.
+// Example
+// PUT /drones/7/signing {"policy": "reject", "key": "correct horse battery staple"}
+func (h *SecurityHandler) SetSigningHandler(c *gin.Context) {
+	droneID, err := strconv.Atoi(c.Param("droneID"))
+	if err != nil {
+		respondBadRequest(c, "Invalid Drone ID")
+		return
+	}
+
+	var request service.SigningSettings
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondBadRequest(c, "Invalid JSON request")
+		return
+	}
+
+	signing, err := h.SecurityService.SetSigning(c.Request.Context(), uint(droneID), request)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, signing)
+}
+
+// GetAlertsHandler handles HTTP requests for the security alerts, newest first.
+// Optional query parameters: drone (ID), since (RFC 3339) and limit, 100 by default.
+// Example
+// GET /security/alerts?drone=7&since=2023-11-01T10:00:00Z
+func (h *SecurityHandler) GetAlertsHandler(c *gin.Context) {
+	droneID := 0
+	var err error
+	if value := c.Query("drone"); value != "" {
+		if droneID, err = strconv.Atoi(value); err != nil || droneID < 0 {
+			respondBadRequest(c, "Invalid drone")
+			return
+		}
+	}
+
+	var since time.Time
+	if value := c.Query("since"); value != "" {
+		if since, err = time.Parse(time.RFC3339, value); err != nil {
+			respondBadRequest(c, "Invalid since, expected RFC 3339")
+			return
+		}
+	}
+
+	limit := 100
+	if value := c.Query("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil || limit < 0 {
+			respondBadRequest(c, "Invalid limit")
+			return
+		}
+	}
+
+	alerts, err := h.SecurityService.GetAlerts(c.Request.Context(), uint(droneID), since, limit)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, alerts)
+}
@@ -2,9 +2,11 @@ package webserver
 
 // EXAMPLE USAGE
 // func main() {
-// 	r := gin.Default()
+// 	r := gin.New()
+// 	r.Use(gin.Recovery(), RequestID(), AccessLog(output.Logger(utils.LogPrefixWeb)), HTTPMetrics(), AuditActor())
+// 	r.Use(RequestTimeout(DefaultRequestTimeout))
 // 	db := // Your GORM database initialization
-// 	taskService := service.NewTaskService(db)
+// 	taskService := service.NewTaskService(repository.NewGormStore(db))
 // 	taskHandler := NewTaskHandler(taskService)
 
 // 	r.POST("/tasks", taskHandler.CreateTaskHandler)
@@ -12,12 +14,15 @@ package webserver
 // 	r.PUT("/tasks/:taskID", taskHandler.UpdateTaskHandler)
 // 	r.GET("/tasks", taskHandler.GetAllTasksHandler)
 // 	r.GET("/tasks/status", taskHandler.GetTasksByStatusHandler)
+// 	r.DELETE("/tasks/:taskID", taskHandler.DeleteTaskHandler)
+// 	r.GET("/admin/deleted/tasks", taskHandler.GetDeletedTasksHandler)
+// 	r.POST("/admin/deleted/tasks/:taskID/restore", taskHandler.RestoreTaskHandler)
+// 	r.DELETE("/admin/deleted/tasks/:taskID", taskHandler.PurgeTaskHandler)
 
 // 	r.Run(":8080")
 // }
 
 import (
-	"fmt"
 	"net/http"
 	"strconv"
 
@@ -28,10 +33,10 @@ import (
 )
 
 type TaskHandler struct {
-	TaskService *service.TaskService
+	TaskService service.TaskService
 }
 
-func NewTaskHandler(taskService *service.TaskService) *TaskHandler {
+func NewTaskHandler(taskService service.TaskService) *TaskHandler {
 	return &TaskHandler{TaskService: taskService}
 }
 
@@ -48,13 +53,13 @@ func (h *TaskHandler) CreateTaskHandler(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON request"})
+		respondBadRequest(c, "Invalid JSON request")
 		return
 	}
 
-	task, err := h.TaskService.CreateTask(request.UserID, request.DroneID, request.StartLon, request.StartLat, request.EndLon, request.EndLat, request.Description)
+	task, err := h.TaskService.CreateTask(c.Request.Context(), request.UserID, request.DroneID, request.StartLon, request.StartLat, request.EndLon, request.EndLat, request.Description)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to create task: %v", err)})
+		respondError(c, err)
 		return
 	}
 
@@ -66,7 +71,7 @@ func (h *TaskHandler) UpdateTaskHandler(c *gin.Context) {
 	taskIDStr := c.Param("taskID")
 	taskID, err := strconv.Atoi(taskIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Task ID"})
+		respondBadRequest(c, "Invalid Task ID")
 		return
 	}
 
@@ -75,15 +80,15 @@ func (h *TaskHandler) UpdateTaskHandler(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON request"})
+		respondBadRequest(c, "Invalid JSON request")
 		return
 	}
 
 	taskStatus := db.TaskStatus(request.Status)
 
-	err = h.TaskService.UpdateTask(uint(taskID), taskStatus)
+	err = h.TaskService.UpdateTask(c.Request.Context(), uint(taskID), taskStatus)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to update task: %v", err)})
+		respondError(c, err)
 		return
 	}
 
@@ -92,9 +97,9 @@ func (h *TaskHandler) UpdateTaskHandler(c *gin.Context) {
 
 // GetAllTasksHandler handles HTTP requests for getting all tasks.
 func (h *TaskHandler) GetAllTasksHandler(c *gin.Context) {
-	tasks, err := h.TaskService.GetAllTasks()
+	tasks, err := h.TaskService.GetAllTasks(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to get tasks: %v", err)})
+		respondError(c, err)
 		return
 	}
 
@@ -108,17 +113,79 @@ func (h *TaskHandler) GetTasksByStatusHandler(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON request"})
+		respondBadRequest(c, "Invalid JSON request")
 		return
 	}
 
 	taskStatus := db.TaskStatus(request.Status)
 
-	tasks, err := h.TaskService.GetTasksByStatus(taskStatus)
+	tasks, err := h.TaskService.GetTasksByStatus(c.Request.Context(), taskStatus)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to get tasks by status: %v", err)})
+		respondError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, tasks)
 }
+
+// DeleteTaskHandler handles HTTP requests for deleting a task by ID.
+func (h *TaskHandler) DeleteTaskHandler(c *gin.Context) {
+	taskID, err := strconv.Atoi(c.Param("taskID"))
+	if err != nil {
+		respondBadRequest(c, "Invalid Task ID")
+		return
+	}
+
+	err = h.TaskService.DeleteTaskByID(c.Request.Context(), taskID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Task deleted successfully"})
+}
+
+// GetDeletedTasksHandler handles HTTP requests for listing soft-deleted tasks.
+func (h *TaskHandler) GetDeletedTasksHandler(c *gin.Context) {
+	tasks, err := h.TaskService.GetDeletedTasks(c.Request.Context())
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tasks)
+}
+
+// RestoreTaskHandler handles HTTP requests for restoring a soft-deleted task.
+func (h *TaskHandler) RestoreTaskHandler(c *gin.Context) {
+	taskID, err := strconv.Atoi(c.Param("taskID"))
+	if err != nil {
+		respondBadRequest(c, "Invalid Task ID")
+		return
+	}
+
+	task, err := h.TaskService.RestoreTask(c.Request.Context(), uint(taskID))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, task)
+}
+
+// PurgeTaskHandler handles HTTP requests for permanently removing a soft-deleted task.
+func (h *TaskHandler) PurgeTaskHandler(c *gin.Context) {
+	taskID, err := strconv.Atoi(c.Param("taskID"))
+	if err != nil {
+		respondBadRequest(c, "Invalid Task ID")
+		return
+	}
+
+	err = h.TaskService.PurgeTask(c.Request.Context(), uint(taskID))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Task purged successfully"})
+}
@@ -0,0 +1,102 @@
+package webserver
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"fleet-monitor/backend/db"
+	"fleet-monitor/backend/service"
+)
+
+// taskRequest is the body of POST /tasks.
+type taskRequest struct {
+	UserID      uint    `json:"userId"`
+	DroneID     uint    `json:"droneId"`
+	StartLon    float64 `json:"startLon"`
+	StartLat    float64 `json:"startLat"`
+	EndLon      float64 `json:"endLon"`
+	EndLat      float64 `json:"endLat"`
+	Description string  `json:"description"`
+}
+
+func TestCreateTaskHandler(t *testing.T) {
+	s := newTestServer(t)
+	user := createUser(t, s, "alice")
+	drone := createDrone(t, s, "1", user)
+
+	var task db.Task
+	request := taskRequest{UserID: user, DroneID: drone, StartLon: 8.54, StartLat: 47.37, EndLon: 8.55, EndLat: 47.38, Description: "survey"}
+	s.mustDo(t, http.MethodPost, "/tasks", request, &task, http.StatusCreated)
+	if task.ID == 0 || task.Status != db.TaskStatusWaiting || task.Description != "survey" {
+		t.Fatalf("created task = %+v", task)
+	}
+
+	body := s.expectError(t, http.MethodPost, "/tasks", taskRequest{UserID: 999, DroneID: drone, StartLat: 91},
+		http.StatusUnprocessableEntity, service.ErrorCodeValidation)
+	for _, field := range []string{"userId", "startLat"} {
+		if !hasField(body.Fields, field) {
+			t.Errorf("validation fields %+v lack %s", body.Fields, field)
+		}
+	}
+
+	// Grounded drones take no new tasks.
+	grounded, err := s.store.Drones().FindByID(context.Background(), drone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	grounded.Grounded = true
+	if err := s.store.Drones().Save(context.Background(), grounded); err != nil {
+		t.Fatal(err)
+	}
+	body = s.expectError(t, http.MethodPost, "/tasks", request, http.StatusConflict, service.ErrorCodeConflict)
+	if id := conflictID(t, body); id != float64(drone) {
+		t.Errorf("conflict holds drone %v, want %d", id, drone)
+	}
+}
+
+func TestUpdateTaskHandler(t *testing.T) {
+	s := newTestServer(t)
+	user := createUser(t, s, "alice")
+	drone := createDrone(t, s, "1", user)
+	var task db.Task
+	s.mustDo(t, http.MethodPost, "/tasks", taskRequest{UserID: user, DroneID: drone}, &task, http.StatusCreated)
+
+	s.mustDo(t, http.MethodPut, "/tasks/"+itoa(task.ID), map[string]string{"status": "ongoing"}, nil, http.StatusOK)
+	var tasks []db.Task
+	s.mustDo(t, http.MethodGet, "/tasks", nil, &tasks, http.StatusOK)
+	if len(tasks) != 1 || tasks[0].Status != db.TaskStatusOngoing {
+		t.Fatalf("tasks after the update = %+v", tasks)
+	}
+
+	body := s.expectError(t, http.MethodPut, "/tasks/"+itoa(task.ID), map[string]string{"status": "flying"},
+		http.StatusUnprocessableEntity, service.ErrorCodeValidation)
+	if !hasField(body.Fields, "status") {
+		t.Errorf("validation fields %+v lack status", body.Fields)
+	}
+
+	s.expectError(t, http.MethodPut, "/tasks/999", map[string]string{"status": "ongoing"}, http.StatusNotFound, service.ErrorCodeNotFound)
+	s.expectError(t, http.MethodPut, "/tasks/one", map[string]string{"status": "ongoing"}, http.StatusBadRequest, ErrorCodeBadRequest)
+}
+
+func TestDeleteTaskHandler(t *testing.T) {
+	s := newTestServer(t)
+	user := createUser(t, s, "alice")
+	drone := createDrone(t, s, "1", user)
+	var task db.Task
+	s.mustDo(t, http.MethodPost, "/tasks", taskRequest{UserID: user, DroneID: drone}, &task, http.StatusCreated)
+
+	s.mustDo(t, http.MethodDelete, "/tasks/"+itoa(task.ID), nil, nil, http.StatusOK)
+	s.expectError(t, http.MethodDelete, "/tasks/"+itoa(task.ID), nil, http.StatusNotFound, service.ErrorCodeNotFound)
+
+	// A task can't be restored while its drone is deleted.
+	s.mustDo(t, http.MethodDelete, "/drones/"+itoa(drone), nil, nil, http.StatusOK)
+	s.expectError(t, http.MethodPost, "/admin/deleted/tasks/"+itoa(task.ID)+"/restore", nil, http.StatusConflict, service.ErrorCodeConflict)
+
+	s.mustDo(t, http.MethodPost, "/admin/deleted/drones/"+itoa(drone)+"/restore", nil, nil, http.StatusOK)
+	var restored db.Task
+	s.mustDo(t, http.MethodPost, "/admin/deleted/tasks/"+itoa(task.ID)+"/restore", nil, &restored, http.StatusOK)
+	if restored.ID != task.ID {
+		t.Fatalf("restored task = %+v", restored)
+	}
+}
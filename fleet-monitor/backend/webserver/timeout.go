@@ -0,0 +1,34 @@
+package webserver
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultRequestTimeout bounds how long a request may keep the database busy.
+const DefaultRequestTimeout = 30 * time.Second
+
+// RequestTimeout gives every request a context that is canceled after timeout.
+// The services stop their queries when it expires and the handler responds
+// with 504 Gateway Timeout. A timeout <= 0 disables the deadline.
+// Apply it per route group for different limits; a nested RequestTimeout can only
+// shorten the deadline of an outer one.
+// Example
+// api := r.Group("/", RequestTimeout(5*time.Second))
+// history := r.Group("/drones/:droneID/telemetry", RequestTimeout(time.Minute))
+func RequestTimeout(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if timeout <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
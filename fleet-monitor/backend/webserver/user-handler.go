@@ -2,22 +2,28 @@ package webserver
 
 //USAGE EXAMPLE
 // func main() {
-// 	r := gin.Default()
+// 	r := gin.New()
+// 	r.Use(gin.Recovery(), RequestID(), AccessLog(output.Logger(utils.LogPrefixWeb)), HTTPMetrics(), AuditActor())
+// 	r.Use(RequestTimeout(DefaultRequestTimeout))
 // 	db := // Your GORM database initialization
-// 	userService := service.NewUserService(db)
+// 	userService := service.NewUserService(repository.NewGormStore(db))
 // 	userHandler := NewUserHandler(userService)
 
 // 	r.POST("/users", userHandler.CreateUserHandler)
 // 	r.GET("/usernames", userHandler.GetAllUsernamesHandler)
-// 	r.PUT("/users/:id", userHandler.UpdateUserHandler)
+// 	r.GET("/users", userHandler.GetAllUsersHandler)
+// 	r.GET("/users/:user", userHandler.GetUserHandler)
+// 	r.PUT("/users/:user", userHandler.UpdateUserHandler)
 // 	r.POST("/users/json", userHandler.CreateUserFromJSONHandler)
-// 	r.DELETE("/users/:identifier", userHandler.DeleteUserHandler)
+// 	r.DELETE("/users/:user", userHandler.DeleteUserHandler)
+// 	r.GET("/admin/deleted/users", userHandler.GetDeletedUsersHandler)
+// 	r.POST("/admin/deleted/users/:id/restore", userHandler.RestoreUserHandler)
+// 	r.DELETE("/admin/deleted/users/:id", userHandler.PurgeUserHandler)
 
 // 	r.Run(":8080")
 // }
 
 import (
-	"fmt"
 	"net/http"
 	"strconv"
 
@@ -27,27 +33,25 @@ import (
 )
 
 type UserHandler struct {
-	UserService *service.UserService
+	UserService service.UserService
 }
 
-func NewUserHandler(userService *service.UserService) *UserHandler {
+func NewUserHandler(userService service.UserService) *UserHandler {
 	return &UserHandler{UserService: userService}
 }
 
 // CreateUserHandler handles HTTP requests for creating a new user.
 func (h *UserHandler) CreateUserHandler(c *gin.Context) {
-	var request struct {
-		UserName string `json:"userName"`
-	}
+	var request service.UserDetails
 
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON request"})
+		respondBadRequest(c, "Invalid JSON request")
 		return
 	}
 
-	user, err := h.UserService.CreateUser(request.UserName)
+	user, err := h.UserService.CreateUser(c.Request.Context(), request)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to create user: %v", err)})
+		respondError(c, err)
 		return
 	}
 
@@ -56,36 +60,61 @@ func (h *UserHandler) CreateUserHandler(c *gin.Context) {
 
 // GetAllUsernamesHandler handles HTTP requests for getting all usernames.
 func (h *UserHandler) GetAllUsernamesHandler(c *gin.Context) {
-	usernames, err := h.UserService.GetAllUsernames()
+	usernames, err := h.UserService.GetAllUsernames(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to get usernames: %v", err)})
+		respondError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, usernames)
 }
 
-// UpdateUserHandler handles HTTP requests for updating a user.
-func (h *UserHandler) UpdateUserHandler(c *gin.Context) {
-	userIDStr := c.Param("id")
-	userID, err := strconv.Atoi(userIDStr)
+// GetAllUsersHandler handles HTTP requests for listing users with their fleet counts.
+func (h *UserHandler) GetAllUsersHandler(c *gin.Context) {
+	users, err := h.UserService.GetAllUsers(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid User ID"})
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, users)
+}
+
+// GetUserHandler handles HTTP requests for a user with their drones, tasks and fleet counts.
+// Example
+// GET /users/id:42
+func (h *UserHandler) GetUserHandler(c *gin.Context) {
+	ref, ok := userRefParam(c)
+	if !ok {
 		return
 	}
 
-	var request struct {
-		UserName string `json:"userName"`
+	user, err := h.UserService.GetUser(c.Request.Context(), ref)
+	if err != nil {
+		respondError(c, err)
+		return
 	}
 
+	c.JSON(http.StatusOK, user)
+}
+
+// UpdateUserHandler handles HTTP requests for updating a user.
+func (h *UserHandler) UpdateUserHandler(c *gin.Context) {
+	ref, ok := userRefParam(c)
+	if !ok {
+		return
+	}
+
+	var request service.UserDetails
+
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON request"})
+		respondBadRequest(c, "Invalid JSON request")
 		return
 	}
 
-	err = h.UserService.UpdateUser(uint(userID), request.UserName)
+	err := h.UserService.UpdateUser(c.Request.Context(), ref, request)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to update user: %v", err)})
+		respondError(c, err)
 		return
 	}
 
@@ -93,14 +122,96 @@ func (h *UserHandler) UpdateUserHandler(c *gin.Context) {
 }
 
 // DeleteUserHandler handles HTTP requests for deleting a user.
+// Optional query parameters: policy (reject, reassign or cascade; default reject),
+// reassignTo (the user ID receiving the drones and tasks with reassign) and dryRun.
+// Example
+// DELETE /users/name:alice?policy=reassign&reassignTo=2&dryRun=true
 func (h *UserHandler) DeleteUserHandler(c *gin.Context) {
-	identifier := c.Param("identifier")
+	ref, ok := userRefParam(c)
+	if !ok {
+		return
+	}
 
-	err := h.UserService.DeleteUser(identifier)
+	opts := service.DeleteUserOptions{Policy: service.DeletePolicy(c.Query("policy"))}
+	if value := c.Query("reassignTo"); value != "" {
+		reassignTo, err := strconv.ParseUint(value, 10, 0)
+		if err != nil {
+			respondBadRequest(c, "Invalid reassignTo")
+			return
+		}
+		opts.ReassignTo = uint(reassignTo)
+	}
+	if value := c.Query("dryRun"); value != "" {
+		dryRun, err := strconv.ParseBool(value)
+		if err != nil {
+			respondBadRequest(c, "Invalid dryRun")
+			return
+		}
+		opts.DryRun = dryRun
+	}
+
+	result, err := h.UserService.DeleteUser(c.Request.Context(), ref, opts)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to delete user: %v", err)})
+		respondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "User deleted successfully"})
+	c.JSON(http.StatusOK, result)
+}
+
+// GetDeletedUsersHandler handles HTTP requests for listing soft-deleted users.
+func (h *UserHandler) GetDeletedUsersHandler(c *gin.Context) {
+	users, err := h.UserService.GetDeletedUsers(c.Request.Context())
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, users)
+}
+
+// RestoreUserHandler handles HTTP requests for restoring a soft-deleted user.
+func (h *UserHandler) RestoreUserHandler(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		respondBadRequest(c, "Invalid User ID")
+		return
+	}
+
+	user, err := h.UserService.RestoreUser(c.Request.Context(), uint(userID))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// PurgeUserHandler handles HTTP requests for permanently removing a soft-deleted user
+// along with their soft-deleted drones and tasks.
+func (h *UserHandler) PurgeUserHandler(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		respondBadRequest(c, "Invalid User ID")
+		return
+	}
+
+	err = h.UserService.PurgeUser(c.Request.Context(), uint(userID))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "User purged successfully"})
+}
+
+// userRefParam parses the :user path parameter, "id:42" or "name:alice", and
+// responds with 400 if it is malformed.
+func userRefParam(c *gin.Context) (service.UserRef, bool) {
+	ref, err := service.ParseUserRef(c.Param("user"))
+	if err != nil {
+		respondBadRequest(c, err.Error())
+		return service.UserRef{}, false
+	}
+	return ref, true
 }
@@ -0,0 +1,118 @@
+package webserver
+
+import (
+	"net/http"
+	"testing"
+
+	"fleet-monitor/backend/db"
+	"fleet-monitor/backend/service"
+)
+
+func TestCreateUserHandler(t *testing.T) {
+	s := newTestServer(t)
+
+	var user db.User
+	details := service.UserDetails{UserName: " alice ", Email: "alice@example.com", ContactPhone: "+14155550100"}
+	s.mustDo(t, http.MethodPost, "/users", details, &user, http.StatusCreated)
+	if user.ID == 0 || user.UserName != "alice" || user.Email != "alice@example.com" {
+		t.Fatalf("created user = %+v", user)
+	}
+
+	body := s.expectError(t, http.MethodPost, "/users", service.UserDetails{UserName: "alice"}, http.StatusConflict, service.ErrorCodeConflict)
+	if id := conflictID(t, body); id != float64(user.ID) {
+		t.Errorf("conflict holds user %v, want %d", id, user.ID)
+	}
+
+	body = s.expectError(t, http.MethodPost, "/users", service.UserDetails{Email: "not an address", ContactPhone: "0800"},
+		http.StatusUnprocessableEntity, service.ErrorCodeValidation)
+	for _, field := range []string{"username", "email", "contactPhone"} {
+		if !hasField(body.Fields, field) {
+			t.Errorf("validation fields %+v lack %s", body.Fields, field)
+		}
+	}
+}
+
+func TestGetUserHandler(t *testing.T) {
+	s := newTestServer(t)
+	id := createUser(t, s, "alice")
+	createDrone(t, s, "1", id)
+
+	for _, ref := range []string{"id:" + itoa(id), "name:alice"} {
+		var profile service.UserProfile
+		s.mustDo(t, http.MethodGet, "/users/"+ref, nil, &profile, http.StatusOK)
+		if profile.ID != id || profile.Summary.Drones != 1 {
+			t.Errorf("GET /users/%s = %+v", ref, profile)
+		}
+	}
+
+	body := s.expectError(t, http.MethodGet, "/users/id:999", nil, http.StatusNotFound, service.ErrorCodeNotFound)
+	if body.Fields != nil || body.Conflict != nil {
+		t.Errorf("not found error = %+v", body)
+	}
+	s.expectError(t, http.MethodGet, "/users/name:bob", nil, http.StatusNotFound, service.ErrorCodeNotFound)
+	s.expectError(t, http.MethodGet, "/users/42", nil, http.StatusBadRequest, ErrorCodeBadRequest)
+}
+
+func TestUpdateUserHandler(t *testing.T) {
+	s := newTestServer(t)
+	alice := createUser(t, s, "alice")
+	bob := createUser(t, s, "bob")
+
+	s.mustDo(t, http.MethodPut, "/users/id:"+itoa(bob), service.UserDetails{UserName: "robert", DisplayName: "Robert"}, nil, http.StatusOK)
+	var profile service.UserProfile
+	s.mustDo(t, http.MethodGet, "/users/name:robert", nil, &profile, http.StatusOK)
+	if profile.ID != bob || profile.DisplayName != "Robert" {
+		t.Fatalf("renamed user = %+v", profile)
+	}
+
+	body := s.expectError(t, http.MethodPut, "/users/id:"+itoa(bob), service.UserDetails{UserName: "alice"},
+		http.StatusConflict, service.ErrorCodeConflict)
+	if id := conflictID(t, body); id != float64(alice) {
+		t.Errorf("conflict holds user %v, want %d", id, alice)
+	}
+
+	body = s.expectError(t, http.MethodPut, "/users/id:"+itoa(bob), service.UserDetails{UserName: "robert", Email: "robert"},
+		http.StatusUnprocessableEntity, service.ErrorCodeValidation)
+	if !hasField(body.Fields, "email") {
+		t.Errorf("validation fields %+v lack email", body.Fields)
+	}
+
+	s.expectError(t, http.MethodPut, "/users/id:999", service.UserDetails{UserName: "carol"}, http.StatusNotFound, service.ErrorCodeNotFound)
+}
+
+func TestDeleteUserHandler(t *testing.T) {
+	s := newTestServer(t)
+	alice := createUser(t, s, "alice")
+	bob := createUser(t, s, "bob")
+	drone := createDrone(t, s, "1", alice)
+
+	body := s.expectError(t, http.MethodDelete, "/users/name:alice", nil, http.StatusConflict, service.ErrorCodeConflict)
+	conflict, ok := body.Conflict.(map[string]interface{})
+	if !ok {
+		t.Fatalf("conflict = %#v, want the affected records", body.Conflict)
+	}
+	if drones, _ := conflict["drones"].([]interface{}); len(drones) != 1 {
+		t.Errorf("conflict lists drones %v, want drone %d", conflict["drones"], drone)
+	}
+
+	// A dry run reports what blocks the delete instead of failing.
+	var report service.DeleteUserResult
+	s.mustDo(t, http.MethodDelete, "/users/name:alice?dryRun=true", nil, &report, http.StatusOK)
+	if !report.Blocked || !report.DryRun || len(report.Drones) != 1 || report.Drones[0].ID != drone {
+		t.Errorf("dry run report = %+v, want drone %d blocking", report, drone)
+	}
+	s.mustDo(t, http.MethodGet, "/users/name:alice", nil, nil, http.StatusOK)
+
+	s.expectError(t, http.MethodDelete, "/users/name:alice?policy=disown", nil, http.StatusUnprocessableEntity, service.ErrorCodeValidation)
+	s.expectError(t, http.MethodDelete, "/users/name:alice?policy=reassign&reassignTo=999", nil,
+		http.StatusUnprocessableEntity, service.ErrorCodeValidation)
+	s.expectError(t, http.MethodDelete, "/users/name:alice?dryRun=maybe", nil, http.StatusBadRequest, ErrorCodeBadRequest)
+
+	var result service.DeleteUserResult
+	s.mustDo(t, http.MethodDelete, "/users/name:alice?policy=reassign&reassignTo="+itoa(bob), nil, &result, http.StatusOK)
+	if len(result.Drones) != 1 || result.Drones[0].OwnerID != int(bob) {
+		t.Fatalf("reassigned drones = %+v, want drone %d owned by %d", result.Drones, drone, bob)
+	}
+	s.expectError(t, http.MethodGet, "/users/name:alice", nil, http.StatusNotFound, service.ErrorCodeNotFound)
+	s.expectError(t, http.MethodDelete, "/users/name:alice", nil, http.StatusNotFound, service.ErrorCodeNotFound)
+}
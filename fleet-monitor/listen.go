@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+
+	"fleet-monitor/backend/health"
+	"fleet-monitor/backend/ingest"
+	"fleet-monitor/backend/metrics"
+	"fleet-monitor/backend/repository"
+	"fleet-monitor/backend/secrets"
+	"fleet-monitor/backend/service"
+	"fleet-monitor/backend/utils"
+)
+
+const listenUsage = `usage: fleet-monitor listen [-db dsn] (-device path | -udp address) [-http address] [log flags]
+
+  read MAVLink from a telemetry radio or a UDP port, keep the drones' autopilot
+  type and firmware version up to date and record their flights. Frames of
+  drones with a MAVLink 2 signing key are checked against it
+
+  -http serves Prometheus metrics on /metrics and health checks on /healthz and
+  /readyz, e.g. -http :9100
+
+log flags:
+  -log-format console|json   -log-level debug|info|warn|error
+  -log-levels DBS=debug,MAV=warn
+  -log-file path  -log-max-size MB  -log-max-age duration
+  -log-max-backups N  -log-retention duration
+`
+
+// runListen implements the `listen` command.
+// Example
+// fleet-monitor listen -db tasks.db -device /dev/ttyUSB0
+// fleet-monitor listen -db tasks.db -udp :14550
+func runListen(args []string) error {
+	flags := flag.NewFlagSet("listen", flag.ContinueOnError)
+	dsn := flags.String("db", "tasks.db", "SQLite path or postgres:// URL of the database")
+	device := flags.String("device", "", "serial device of the telemetry radio, already set to its baud rate")
+	udp := flags.String("udp", "", "UDP address to receive MAVLink on, e.g. :14550")
+	httpAddr := flags.String("http", "", "address to serve metrics and health checks on, e.g. :9100")
+	logging := addLogFlags(flags)
+	flags.Usage = func() { fmt.Fprint(flags.Output(), listenUsage) }
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		flags.Usage()
+		return fmt.Errorf("unexpected arguments %v", flags.Args())
+	}
+	if (*device == "") == (*udp == "") {
+		flags.Usage()
+		return errors.New("exactly one of -device and -udp is required")
+	}
+
+	output, err := logging.open()
+	if err != nil {
+		return err
+	}
+	defer output.Close()
+
+	var link io.ReadCloser
+	linkName := *udp
+	if *device != "" {
+		linkName = *device
+		f, err := os.Open(*device)
+		if err != nil {
+			return err
+		}
+		link = f
+	} else {
+		addr, err := net.ResolveUDPAddr("udp", *udp)
+		if err != nil {
+			return err
+		}
+		conn, err := net.ListenUDP("udp", addr)
+		if err != nil {
+			return err
+		}
+		link = conn
+	}
+	defer link.Close()
+
+	if err := secrets.UseMasterKey(""); err != nil {
+		return err
+	}
+	conn, err := openDatabase(*dsn)
+	if err != nil {
+		return err
+	}
+	conn.Logger = utils.NewGormLogger(output.Logger(utils.LogPrefixDatabase))
+	if err := conn.Use(metrics.GormPlugin{}); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	store := repository.NewGormStore(conn)
+	droneService := service.NewDroneService(store)
+	maintenanceService := service.NewMaintenanceService(store)
+	batteryService := service.NewBatteryService(store)
+	securityService := service.NewSecurityService(store)
+	listener := ingest.NewListener(droneService, maintenanceService, batteryService, securityService, output.Logger(utils.LogPrefixMavlink))
+
+	if *httpAddr != "" {
+		if err := registerAlerts(droneService, batteryService, securityService); err != nil {
+			return err
+		}
+		checker := health.NewChecker()
+		checker.Add("database", health.Database(conn))
+		checker.Add("migrations", health.Migrations(conn))
+		checker.Add("links", health.Links(listener.Links))
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		go func() {
+			web := output.Logger(utils.LogPrefixWeb)
+			if err := serveStatus(ctx, *httpAddr, checker, web); err != nil {
+				web.Error("failed to serve metrics and health checks", "error", err)
+			}
+		}()
+	}
+
+	err = listener.Listen(ctx, linkName, link)
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"flag"
+	"time"
+
+	"fleet-monitor/backend/utils"
+)
+
+// logFlags are the logging options of the commands that keep running, like listen.
+type logFlags struct {
+	format     *string
+	level      *string
+	levels     *string
+	file       *string
+	maxSizeMB  *int
+	maxAge     *time.Duration
+	maxBackups *int
+	retention  *time.Duration
+}
+
+func addLogFlags(flags *flag.FlagSet) *logFlags {
+	return &logFlags{
+		format:     flags.String("log-format", "console", "log format, console or json"),
+		level:      flags.String("log-level", "info", "minimum log level: debug, info, warn or error"),
+		levels:     flags.String("log-levels", "", "per-subsystem levels, e.g. DBS=debug,MAV=warn"),
+		file:       flags.String("log-file", "", "log to this file instead of stdout"),
+		maxSizeMB:  flags.Int("log-max-size", 10, "rotate the log file at this size in MB, 0 to disable"),
+		maxAge:     flags.Duration("log-max-age", 24*time.Hour, "rotate the log file after this long, 0 to disable"),
+		maxBackups: flags.Int("log-max-backups", 7, "number of rotated log files to keep, 0 for all"),
+		retention:  flags.Duration("log-retention", 0, "delete rotated log files older than this, 0 to keep them"),
+	}
+}
+
+// open creates the log output the flags describe.
+func (f *logFlags) open() (*utils.LogOutput, error) {
+	level, err := utils.ParseLevel(*f.level)
+	if err != nil {
+		return nil, err
+	}
+	levels, err := utils.ParseLevels(*f.levels)
+	if err != nil {
+		return nil, err
+	}
+
+	return utils.NewLogOutput(utils.LogOptions{
+		Format: utils.LogFormat(*f.format),
+		Level:  level,
+		Levels: levels,
+		File:   *f.file,
+		Rotation: utils.RotationOptions{
+			MaxSizeMB:  *f.maxSizeMB,
+			MaxAge:     *f.maxAge,
+			MaxBackups: *f.maxBackups,
+			Retention:  *f.retention,
+		},
+	})
+}
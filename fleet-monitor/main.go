@@ -9,32 +9,69 @@
 package main
 
 import (
+	"context"
 	"fleet-monitor/backend/db"
+	"fleet-monitor/backend/repository"
 	"fleet-monitor/backend/service"
 	"fmt"
+	"os"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(os.Args[2:]); err != nil {
+			fmt.Println("Error running migrations:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "purge" {
+		if err := runPurge(os.Args[2:]); err != nil {
+			fmt.Println("Error purging deleted records:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "secrets" {
+		if err := runSecrets(os.Args[2:]); err != nil {
+			fmt.Println("Error managing secrets:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "listen" {
+		if err := runListen(os.Args[2:]); err != nil {
+			fmt.Println("Error listening for MAVLink:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	sql_db, err := db.OpenDB("tasks.db")
 	if err != nil {
 		fmt.Println("Error connecting to the database:", err)
 		return
 	}
 
-	userService := service.NewUserService(sql_db)
-	droneService := service.NewDroneService(sql_db)
-	taskService := service.NewTaskService(sql_db)
+	ctx := context.Background()
+	store := repository.NewGormStore(sql_db)
+	userService := service.NewUserService(store)
+	droneService := service.NewDroneService(store)
+	taskService := service.NewTaskService(store)
 
 	userID := 1
 
 	var user db.User
-	if err := db.First(&user, userID).Error; err != nil {
+	if err := sql_db.First(&user, userID).Error; err != nil {
 		fmt.Println("Error retrieving user:", err)
 		return
 	}
 
 	newUserName := "NewName"
-	if err := userService.UpdateUser(user.ID, newUserName); err != nil {
+	if err := userService.UpdateUser(ctx, service.UserByID(user.ID), service.UserDetails{UserName: newUserName}); err != nil {
 		fmt.Println("Error updating user information:", err)
 		return
 	}
@@ -44,16 +81,21 @@ func main() {
 	droneID := 1
 
 	var drone db.Drone
-	if err := db.First(&drone, droneID).Error; err != nil {
+	if err := sql_db.First(&drone, droneID).Error; err != nil {
 		fmt.Println("Error retrieving drone:", err)
 		return
 	}
 
-	velocity := db.Velocity{X: 2.0, Y: 1.0, Z: 0.5}
-	gps := db.GPS{Latitude: 40.0, Longitude: -75.0}
-	altitude := 100.0
+	state := service.RealTimeState{
+		Velocity:     db.Velocity{X: 2.0, Y: 1.0, Z: 0.5},
+		GPS:          db.GPS{Latitude: 40.0, Longitude: -75.0},
+		Altitude:     100.0,
+		Battery:      100,
+		HealthStatus: db.HealthStatusStable,
+		FlightPhase:  db.FlightPhaseAirborne,
+	}
 
-	if err := droneService.UpdateDroneRealTime(&drone, velocity, gps, altitude); err != nil {
+	if err := droneService.UpdateDroneRealTime(ctx, &drone, state); err != nil {
 		fmt.Println("Error updating drone real-time information:", err)
 		return
 	}
@@ -66,14 +108,14 @@ func main() {
 
 	// Retrieve the task from the database
 	var task db.Task
-	if err := db.First(&task, taskID).Error; err != nil {
+	if err := sql_db.First(&task, taskID).Error; err != nil {
 		fmt.Println("Error retrieving task:", err)
 		return
 	}
 
 	// Update task status
 	newStatus := db.TaskStatusCompleted
-	if err := taskService.UpdateTask(task.ID, newStatus); err != nil {
+	if err := taskService.UpdateTask(ctx, task.ID, newStatus); err != nil {
 		fmt.Println("Error updating task status:", err)
 		return
 	}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"fleet-monitor/backend/db"
+	"fleet-monitor/backend/metrics"
+	"fleet-monitor/backend/service"
+)
+
+// Names of the fleet_monitor_active_alerts series.
+const (
+	alertMaintenanceOverdue = "maintenance_overdue"
+	alertDroneDamaged       = "drone_damaged"
+	alertBatteryLowHealth   = "battery_low_health"
+	alertSecurity           = "mavlink_security"
+)
+
+// securityAlertWindow is how long a security alert counts as active.
+const securityAlertWindow = time.Hour
+
+// registerAlerts exposes the alerts counted by countAlerts.
+func registerAlerts(drones service.DroneService, batteries service.BatteryService, security service.SecurityService) error {
+	return metrics.RegisterAlerts(countAlerts(drones, batteries, security),
+		alertMaintenanceOverdue, alertDroneDamaged, alertBatteryLowHealth, alertSecurity)
+}
+
+// countAlerts counts the grounded and damaged drones, the packs in service with low
+// health and the security alerts of the last hour.
+func countAlerts(drones service.DroneService, batteries service.BatteryService, security service.SecurityService) metrics.AlertCounter {
+	return func(ctx context.Context) (map[string]int, error) {
+		counts := map[string]int{}
+
+		all, err := drones.GetAllDrones(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, drone := range all {
+			if drone.Grounded {
+				counts[alertMaintenanceOverdue]++
+			}
+			if drone.HealthStatus == db.HealthStatusDamaged {
+				counts[alertDroneDamaged]++
+			}
+		}
+
+		packs, err := batteries.GetBatteryPacks(ctx, true)
+		if err != nil {
+			return nil, err
+		}
+		for _, pack := range packs {
+			if !pack.Retired {
+				counts[alertBatteryLowHealth]++
+			}
+		}
+
+		alerts, err := security.GetAlerts(ctx, 0, time.Now().Add(-securityAlertWindow), 0)
+		if err != nil {
+			return nil, err
+		}
+		counts[alertSecurity] = len(alerts)
+
+		return counts, nil
+	}
+}
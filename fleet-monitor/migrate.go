@@ -0,0 +1,100 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"fleet-monitor/backend/db"
+	"fleet-monitor/backend/secrets"
+)
+
+const migrateUsage = `usage: fleet-monitor migrate [-db dsn] up|down|status [-steps N]
+
+  up      apply all pending migrations
+  down    roll back the last applied migration (-steps N for more)
+  status  list migrations and whether they are applied
+`
+
+// runMigrate implements the `migrate up|down|status` command.
+// Example
+// fleet-monitor migrate -db tasks.db status
+func runMigrate(args []string) error {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	dsn := flags.String("db", "tasks.db", "SQLite path or postgres:// URL of the database")
+	steps := flags.Int("steps", 1, "number of migrations to roll back with down")
+	flags.Usage = func() { fmt.Fprint(flags.Output(), migrateUsage) }
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errors.New("expected one of up, down or status")
+	}
+
+	// flags may also follow the command, e.g. `migrate down -steps 2`
+	command := flags.Arg(0)
+	if err := flags.Parse(flags.Args()[1:]); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		flags.Usage()
+		return fmt.Errorf("unexpected arguments %v", flags.Args())
+	}
+
+	if err := secrets.UseMasterKey(""); err != nil {
+		return err
+	}
+	revealed, err := secrets.Reveal(*dsn)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt -db: %w", err)
+	}
+	conn, err := db.Connect(revealed)
+	if err != nil {
+		return err
+	}
+
+	switch command {
+	case "up":
+		applied, err := db.MigrateUp(conn)
+		for _, m := range applied {
+			fmt.Printf("applied %04d %s\n", m.Version, m.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Println("database is up to date")
+		}
+		return err
+
+	case "down":
+		if *steps < 1 {
+			return errors.New("-steps must be at least 1")
+		}
+		reverted, err := db.MigrateDown(conn, *steps)
+		for _, m := range reverted {
+			fmt.Printf("rolled back %04d %s\n", m.Version, m.Name)
+		}
+		return err
+
+	case "status":
+		statuses, err := db.MigrationStatuses(conn)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+		for _, s := range statuses {
+			state, appliedAt := "pending", ""
+			if s.Applied {
+				state, appliedAt = "applied", s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\t%s\n", s.Version, s.Name, state, appliedAt)
+		}
+		return w.Flush()
+	}
+
+	flags.Usage()
+	return fmt.Errorf("unknown migrate command %q", command)
+}
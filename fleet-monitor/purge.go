@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+
+	"fleet-monitor/backend/repository"
+	"fleet-monitor/backend/secrets"
+	"fleet-monitor/backend/service"
+)
+
+const purgeUsage = `usage: fleet-monitor purge [-db dsn] [-older-than duration]
+
+  permanently remove tasks, drones and users soft-deleted longer ago than -older-than
+`
+
+// runPurge implements the `purge` command, meant to be run periodically, e.g. from cron.
+// Example
+// fleet-monitor purge -db tasks.db -older-than 720h
+func runPurge(args []string) error {
+	flags := flag.NewFlagSet("purge", flag.ContinueOnError)
+	dsn := flags.String("db", "tasks.db", "SQLite path or postgres:// URL of the database")
+	olderThan := flags.Duration("older-than", service.DefaultRetention, "retention period of soft-deleted records")
+	flags.Usage = func() { fmt.Fprint(flags.Output(), purgeUsage) }
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		flags.Usage()
+		return fmt.Errorf("unexpected arguments %v", flags.Args())
+	}
+	if *olderThan < 0 {
+		return errors.New("-older-than must not be negative")
+	}
+
+	if err := secrets.UseMasterKey(""); err != nil {
+		return err
+	}
+	conn, err := openDatabase(*dsn)
+	if err != nil {
+		return err
+	}
+
+	retentionService := service.NewRetentionService(repository.NewGormStore(conn))
+	report, err := retentionService.PurgeDeleted(context.Background(), time.Now().Add(-*olderThan))
+	if report != nil {
+		fmt.Printf("purged %d tasks, %d drones and %d users deleted before %s\n",
+			len(report.Tasks), len(report.Drones), len(report.Users), report.Before.Format("2006-01-02 15:04:05"))
+		for _, skipped := range report.Skipped {
+			fmt.Printf("kept %s %d: %s\n", skipped.Entity, skipped.ID, skipped.Reason)
+		}
+	}
+	return err
+}
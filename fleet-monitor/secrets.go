@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"fleet-monitor/backend/db"
+	"fleet-monitor/backend/repository"
+	"fleet-monitor/backend/secrets"
+	"fleet-monitor/backend/service"
+
+	"gorm.io/gorm"
+)
+
+const secretsUsage = `usage: fleet-monitor secrets [-db dsn] [-key-file path] set NAME|list|delete NAME|encrypt|rotate [-new-key-file path]
+
+  set NAME   store a secret, reading its value from stdin
+  list       list the stored secrets
+  delete     remove a secret
+  encrypt    encrypt a config value read from stdin, e.g. a database URL, into
+             the enc:... form accepted by -db
+  rotate     re-encrypt every encrypted column under the key in -new-key-file
+
+  Encrypted columns written in the legacy format are upgraded whenever the
+  database is opened with the master key.
+
+  The master key is read from -key-file, FLEET_MONITOR_MASTER_KEY_FILE or
+  FLEET_MONITOR_MASTER_KEY.
+`
+
+// runSecrets implements the `secrets` command.
+// Example
+// printf %s "$SLACK_WEBHOOK" | fleet-monitor secrets -db tasks.db set webhooks/slack
+// fleet-monitor secrets -db tasks.db rotate -new-key-file /etc/fleet-monitor/master.key.new
+func runSecrets(args []string) error {
+	flags := flag.NewFlagSet("secrets", flag.ContinueOnError)
+	dsn := flags.String("db", "tasks.db", "SQLite path or postgres:// URL of the database")
+	keyFile := flags.String("key-file", "", "file holding the master key")
+	newKeyFile := flags.String("new-key-file", "", "file holding the new master key, for rotate")
+	flags.Usage = func() { fmt.Fprint(flags.Output(), secretsUsage) }
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errors.New("expected one of set, list, delete, encrypt or rotate")
+	}
+
+	// flags may also follow the command, e.g. `secrets rotate -new-key-file path`
+	command := flags.Arg(0)
+	rest := flags.Args()[1:]
+	var name string
+	if (command == "set" || command == "delete") && len(rest) > 0 {
+		name, rest = rest[0], rest[1:]
+	}
+	if err := flags.Parse(rest); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		flags.Usage()
+		return fmt.Errorf("unexpected arguments %v", flags.Args())
+	}
+	if (command == "set" || command == "delete") && name == "" {
+		flags.Usage()
+		return fmt.Errorf("%s needs the name of the secret", command)
+	}
+
+	key, err := secrets.LoadMasterKey(*keyFile)
+	if err != nil {
+		return err
+	}
+	box, err := secrets.NewBox(key)
+	if err != nil {
+		return err
+	}
+	secrets.Use(box)
+
+	if command == "encrypt" {
+		value, err := readSecret()
+		if err != nil {
+			return err
+		}
+		encrypted, err := secrets.EncryptValue(value)
+		if err != nil {
+			return err
+		}
+		fmt.Println(encrypted)
+		return nil
+	}
+
+	conn, err := openDatabase(*dsn)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	secretService := service.NewSecretService(repository.NewGormStore(conn))
+
+	switch command {
+	case "set":
+		value, err := readSecret()
+		if err != nil {
+			return err
+		}
+		if _, err := secretService.SetSecret(ctx, name, value); err != nil {
+			return err
+		}
+		fmt.Printf("stored %s\n", name)
+
+	case "list":
+		stored, err := secretService.GetSecrets(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tUPDATED")
+		for _, secret := range stored {
+			fmt.Fprintf(w, "%s\t%s\n", secret.Name, secret.UpdatedAt.Format("2006-01-02 15:04:05"))
+		}
+		return w.Flush()
+
+	case "delete":
+		if err := secretService.DeleteSecret(ctx, name); err != nil {
+			return err
+		}
+		fmt.Printf("deleted %s\n", name)
+
+	case "rotate":
+		if *newKeyFile == "" {
+			return errors.New("rotate needs -new-key-file")
+		}
+		newKey, err := secrets.LoadMasterKey(*newKeyFile)
+		if err != nil {
+			return err
+		}
+		newBox, err := secrets.NewBox(newKey)
+		if err != nil {
+			return err
+		}
+
+		var rotated int
+		err = conn.Transaction(func(tx *gorm.DB) error {
+			rotated, err = secrets.Rotate(ctx, tx, db.EncryptedColumns, box, newBox)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("rotation failed, nothing was changed: %w", err)
+		}
+		fmt.Printf("re-encrypted %d values, now use the new master key\n", rotated)
+		fmt.Println("config values in the enc:... form must be encrypted again with `secrets encrypt`")
+
+	default:
+		flags.Usage()
+		return fmt.Errorf("unknown command %q", command)
+	}
+
+	return nil
+}
+
+// readSecret reads a value from stdin, without the line break ending it.
+func readSecret() (string, error) {
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return "", err
+	}
+	value := strings.TrimRight(string(data), "\r\n")
+	if value == "" {
+		return "", errors.New("expected the value on stdin")
+	}
+	return value, nil
+}
+
+// openDatabase opens and migrates the database selected by dsn, which may be an
+// encrypted config value, and upgrades the encrypted columns still in the legacy
+// format. They stay as they are while no master key is configured.
+func openDatabase(dsn string) (*gorm.DB, error) {
+	dsn, err := secrets.Reveal(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt -db: %w", err)
+	}
+	conn, err := db.OpenDB(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	err = conn.Transaction(func(tx *gorm.DB) error {
+		_, err := secrets.Upgrade(context.Background(), tx, db.EncryptedColumns)
+		return err
+	})
+	if err != nil && !errors.Is(err, secrets.ErrNoMasterKey) {
+		return nil, fmt.Errorf("failed to upgrade encrypted values: %w", err)
+	}
+	return conn, nil
+}
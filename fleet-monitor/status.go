@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"fleet-monitor/backend/health"
+	"fleet-monitor/backend/utils"
+	"fleet-monitor/backend/webserver"
+
+	"github.com/gin-gonic/gin"
+)
+
+// serveStatus serves /metrics, /healthz and /readyz on addr until ctx is done.
+func serveStatus(ctx context.Context, addr string, checker *health.Checker, logger *utils.Logger) error {
+	gin.SetMode(gin.ReleaseMode)
+	r := gin.New()
+	r.Use(gin.Recovery(), webserver.RequestID(), webserver.AccessLog(logger), webserver.HTTPMetrics())
+
+	healthHandler := webserver.NewHealthHandler(checker)
+	r.GET("/metrics", webserver.MetricsHandler)
+	r.GET("/healthz", healthHandler.HealthzHandler)
+	r.GET("/readyz", healthHandler.ReadyzHandler)
+
+	server := &http.Server{Addr: addr, Handler: r, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+
+	logger.Info("serving metrics and health checks", "address", addr)
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}